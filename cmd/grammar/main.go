@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mygo_c_compiler/grammar"
+	"os"
+)
+
+// 消除文法的左递归并提取左公因子，输出变换后的文法以及每条产生式的来源。
+// 使用 -analyze 时只输出文法分析报告
+func main() {
+	analyze := flag.Bool("analyze", false, "输出可空性、FIRST/FOLLOW 集以及不可达、无用的符号和推导环，不做变换")
+	recursion := flag.Bool("recursion", true, "消除直接与间接左递归")
+	factor := flag.Bool("factor", true, "提取左公因子")
+	outPath := flag.String("o", "", "变换后文法的输出文件，默认输出到标准输出")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		fmt.Println("请提供文法文件路径")
+		return
+	}
+	text, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		fmt.Println("无法打开文件:", err)
+		return
+	}
+	g, err := grammar.Parse(string(text))
+	if err != nil {
+		fmt.Println("解析文法错误:", err)
+		return
+	}
+	if *analyze {
+		grammar.Analyze(g).Print(os.Stdout)
+		return
+	}
+
+	t := grammar.NewTransform(g)
+	if *recursion {
+		if err := t.EliminateLeftRecursion(); err != nil {
+			fmt.Println("消除左递归错误:", err)
+			return
+		}
+	}
+	if *factor {
+		t.LeftFactor()
+	}
+
+	if *outPath != "" {
+		if err := os.WriteFile(*outPath, []byte(t.Result.String()), 0644); err != nil {
+			fmt.Println("无法写入文件:", err)
+			return
+		}
+	} else {
+		fmt.Print(t.Result)
+		fmt.Println()
+	}
+	fmt.Println("产生式来源:")
+	t.PrintMapping(os.Stdout)
+}
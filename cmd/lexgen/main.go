@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mygo_c_compiler/lexer"
+	"mygo_c_compiler/lexgen"
+	"os"
+)
+
+// 根据词法说明构建 DFA，可选地导出 DOT 并对输入文件进行词法分析
+func main() {
+	specPath := flag.String("spec", "lexgen/course.spec", "词法说明文件")
+	dotPath := flag.String("dot", "", "DFA 的 .dot 输出文件")
+	flag.Parse()
+
+	spec, err := os.ReadFile(*specPath)
+	if err != nil {
+		fmt.Println("无法打开文件:", err)
+		return
+	}
+	rules, err := lexgen.ParseSpec(string(spec))
+	if err != nil {
+		fmt.Println("解析词法说明错误:", err)
+		return
+	}
+
+	nfa, err := lexgen.BuildNFA(rules)
+	if err != nil {
+		fmt.Println("构建 NFA 错误:", err)
+		return
+	}
+	dfa := nfa.ToDFA(rules)
+	minDFA := dfa.Minimize()
+	fmt.Printf("规则数: %d\nNFA 状态数: %d\nDFA 状态数: %d\n最小化后 DFA 状态数: %d\n",
+		len(rules), nfa.StateCount(), dfa.StateCount(), minDFA.StateCount())
+
+	if *dotPath != "" {
+		if err := minDFA.PrintDOT(*dotPath); err != nil {
+			fmt.Println("Error printing DFA:", err)
+			return
+		}
+	}
+
+	if flag.NArg() < 1 {
+		return
+	}
+	source, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		fmt.Println("无法打开文件:", err)
+		return
+	}
+	fmt.Println("\n词法分析结果:")
+	l := lexgen.NewLexer(minDFA, string(source), lexgen.WithFilename(flag.Arg(0)))
+	for tok := l.NextToken(); tok.Type != lexer.EOF; tok = l.NextToken() {
+		if tok.Error != "" {
+			fmt.Printf("%s: 错误: (%s, %s) - %s\n", tok.Span.Start, tok.Type, tok.Value, tok.Error)
+		} else {
+			fmt.Printf("%s: (%s, %s)\n", tok.Span.Start, tok.Type, tok.Value)
+		}
+	}
+}
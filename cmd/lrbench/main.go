@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mygo_c_compiler/lr_parser"
+	"os"
+	"strings"
+	"time"
+)
+
+// 各阶段用时
+type timing struct {
+	parse, collection, merge, table time.Duration
+}
+
+func (t timing) total() time.Duration {
+	return t.parse + t.collection + t.merge + t.table
+}
+
+// 统计用各种方法为文法构造 LR 分析表的用时，每种方法重复 -n 次，取各阶段的最短用时
+func main() {
+	modes := flag.String("mode", "lr0,slr1,lr1,lalr1", "要测试的分析表构造方法，用逗号分隔")
+	runs := flag.Int("n", 3, "每种方法重复构造的次数")
+	flag.Parse()
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"lr_parser/grammar.md", "lr_parser/grammars/c11.md"}
+	}
+	for _, file := range files {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Println("无法打开文件:", err)
+			return
+		}
+		fmt.Printf("%s:\n", file)
+		for _, name := range strings.Split(*modes, ",") {
+			mode, err := lr_parser.ParseMode(strings.TrimSpace(name))
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			if err := bench(string(text), mode, *runs); err != nil {
+				fmt.Println("解析文法错误:", err)
+				return
+			}
+		}
+	}
+}
+
+func bench(text string, mode lr_parser.Mode, runs int) error {
+	var best timing
+	var p *lr_parser.Parser
+	for i := 0; i < runs; i++ {
+		var t timing
+		start := time.Now()
+		p = &lr_parser.Parser{Mode: mode}
+		if err := p.ParseGrammar(text); err != nil {
+			return err
+		}
+		t.parse = lap(&start)
+		p.GenerateCanonicalCollection()
+		t.collection = lap(&start)
+		if mode == lr_parser.ModeLALR1 {
+			p.MergeCores()
+			t.merge = lap(&start)
+		}
+		p.BuildParsingTable()
+		t.table = lap(&start)
+
+		if i == 0 || t.total() < best.total() {
+			best = t
+		}
+	}
+
+	fmt.Printf("  %-8s 项目集 %5d  冲突 %3d  按优先级解决 %3d  ", mode, len(p.ItemSets), len(p.Conflicts), len(p.Resolved))
+	fmt.Printf("解析文法 %v  项目集规范族 %v", round(best.parse), round(best.collection))
+	if mode == lr_parser.ModeLALR1 {
+		fmt.Printf("  合并同心项目集 %v", round(best.merge))
+	}
+	fmt.Printf("  分析表 %v  共 %v\n", round(best.table), round(best.total()))
+	return nil
+}
+
+// 返回从 start 到现在的用时，并把 start 设为现在
+func lap(start *time.Time) time.Duration {
+	now := time.Now()
+	d := now.Sub(*start)
+	*start = now
+	return d
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"mygo_c_compiler/lr_parser"
+	"os"
+	"path/filepath"
+)
+
+// 由文法文件生成独立的 Go 语法分析器包，分析表嵌入在生成的源代码中。
+// 输出文件中的 GrammarHash 与 GeneratorFlags 都与本次一致时不重新生成，可以在 //go:generate 中使用：
+//
+//	//go:generate go run mygo_c_compiler/cmd/lrgen -o parser.go grammar.md
+func main() {
+	modeName := flag.String("mode", "lalr1", "分析表构造方法: lr0, slr1, lr1, lalr1")
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "生成的包名，默认为 go generate 所在的包，否则为 parser")
+	outPath := flag.String("o", "parser.go", "输出文件")
+	strict := flag.Bool("strict", false, "分析表有冲突时不生成")
+	force := flag.Bool("f", false, "文法没有变化时也重新生成")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		fail("请提供文法文件路径")
+	}
+	if *pkg == "" {
+		*pkg = "parser"
+	}
+	mode, err := lr_parser.ParseMode(*modeName)
+	if err != nil {
+		fail(err)
+	}
+	text, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		fail("无法打开文件:", err)
+	}
+
+	hash := lr_parser.GrammarHash(string(text), mode)
+	flags := fmt.Sprintf("-package %s -strict=%t", *pkg, *strict)
+	if !*force && upToDate(*outPath, hash, flags) {
+		fmt.Printf("%s 已是最新\n", *outPath)
+		return
+	}
+
+	p := &lr_parser.Parser{Mode: mode, Strict: *strict}
+	if err := p.ParseGrammar(string(text)); err != nil {
+		fail("解析文法错误:", err)
+	}
+	for _, problem := range p.Analysis.Problems() {
+		fmt.Println("文法警告:", problem)
+	}
+	p.GenerateCanonicalCollection()
+	if mode == lr_parser.ModeLALR1 {
+		p.MergeCores()
+	}
+	if err := p.BuildParsingTable(); err != nil {
+		p.PrintConflicts(os.Stdout)
+		fail("构建分析表错误:", err)
+	}
+	if len(p.Conflicts) > 0 {
+		p.PrintConflicts(os.Stdout)
+	}
+
+	var buf bytes.Buffer
+	if err := p.GenerateGo(&buf, *pkg, filepath.Base(flag.Arg(0)), flags); err != nil {
+		fail("生成代码错误:", err)
+	}
+	if err := os.WriteFile(*outPath, buf.Bytes(), 0644); err != nil {
+		fail("无法写入文件:", err)
+	}
+	fmt.Printf("%s: %s 分析表共 %d 个状态\n", *outPath, mode, len(p.Action))
+}
+
+// 已有的输出文件是否由同样的文法、构造方法与参数生成
+func upToDate(path, hash, flags string) bool {
+	old, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(old, []byte(fmt.Sprintf("const GrammarHash = %q", hash))) &&
+		bytes.Contains(old, []byte(fmt.Sprintf("const GeneratorFlags = %q", flags)))
+}
+
+// 输出错误并以非零状态退出，使 go generate 失败
+func fail(a ...any) {
+	fmt.Println(a...)
+	os.Exit(1)
+}
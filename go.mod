@@ -13,3 +13,19 @@ replace mygo_c_compiler/rec_des_parser => ./rec_des_parser
 require mygo_c_compiler/lr_parser v0.0.0
 
 replace mygo_c_compiler/lr_parser => ./lr_parser
+
+require mygo_c_compiler/preprocessor v0.0.0
+
+replace mygo_c_compiler/preprocessor => ./preprocessor
+
+require mygo_c_compiler/lexgen v0.0.0
+
+replace mygo_c_compiler/lexgen => ./lexgen
+
+require mygo_c_compiler/ll_parser v0.0.0
+
+replace mygo_c_compiler/ll_parser => ./ll_parser
+
+require mygo_c_compiler/grammar v0.0.0
+
+replace mygo_c_compiler/grammar => ./grammar
@@ -0,0 +1,295 @@
+package grammar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 文法分析的结果
+type Analysis struct {
+	Grammar      *Grammar
+	Terminals    []string                   // 按出现顺序排列，包含结束符 $
+	Nullable     map[string]bool            // 可以推导出空串的非终结符
+	First        map[string]map[string]bool // 每个文法符号的 FIRST 集，终结符的 FIRST 集为其自身
+	Follow       map[string]map[string]bool // 每个非终结符的 FOLLOW 集，$ 表示输入结束
+	Unreachable  []string                   // 从开始符号推导不到的非终结符
+	Unproductive []string                   // 推导不出任何终结符串的非终结符
+	Cycles       [][]string                 // A =>+ A 形式的推导环，如 [A B A] 表示 A => B => A
+}
+
+// 分析文法：计算可空性、FIRST 集与 FOLLOW 集，并检查不可达、无用的符号以及推导环。
+// 未声明的符号由 Parse 报告
+func Analyze(g *Grammar) *Analysis {
+	a := &Analysis{
+		Grammar:   g,
+		Terminals: append(g.Terminals(), "$"),
+	}
+	a.computeFirst()
+	a.computeFollow()
+	a.findUnreachable()
+	a.findUnproductive()
+	a.findCycles()
+	return a
+}
+
+// 迭代计算可空性与 FIRST 集，直到不再变化
+func (a *Analysis) computeFirst() {
+	g := a.Grammar
+	a.Nullable = make(map[string]bool)
+	a.First = make(map[string]map[string]bool)
+	for _, t := range a.Terminals {
+		a.First[t] = map[string]bool{t: true}
+	}
+	for _, nt := range g.NonTerminals() {
+		a.First[nt] = make(map[string]bool)
+	}
+
+	changed := true
+	for changed {
+		changed = false
+		for _, prod := range g.Productions {
+			first, nullable := a.FirstOf(prod.Right)
+			for t := range first {
+				if !a.First[prod.Left][t] {
+					a.First[prod.Left][t] = true
+					changed = true
+				}
+			}
+			if nullable && !a.Nullable[prod.Left] {
+				a.Nullable[prod.Left] = true
+				changed = true
+			}
+		}
+	}
+}
+
+// 符号串的 FIRST 集，第二个返回值表示符号串能否推导出空串
+func (a *Analysis) FirstOf(symbols []string) (map[string]bool, bool) {
+	result := make(map[string]bool)
+	for _, symbol := range symbols {
+		if !a.Grammar.IsNonTerminal(symbol) {
+			result[symbol] = true
+			return result, false
+		}
+		for t := range a.First[symbol] {
+			result[t] = true
+		}
+		if !a.Nullable[symbol] {
+			return result, false
+		}
+	}
+	return result, true
+}
+
+// 迭代计算 FOLLOW 集：A -> αBβ 时 FIRST(β) ⊆ FOLLOW(B)，β 可空时 FOLLOW(A) ⊆ FOLLOW(B)
+func (a *Analysis) computeFollow() {
+	g := a.Grammar
+	a.Follow = make(map[string]map[string]bool)
+	for _, nt := range g.NonTerminals() {
+		a.Follow[nt] = make(map[string]bool)
+	}
+	a.Follow[g.Start()]["$"] = true
+
+	changed := true
+	for changed {
+		changed = false
+		for _, prod := range g.Productions {
+			for i, symbol := range prod.Right {
+				if !g.IsNonTerminal(symbol) {
+					continue
+				}
+				follow, nullable := a.FirstOf(prod.Right[i+1:])
+				if nullable {
+					for t := range a.Follow[prod.Left] {
+						follow[t] = true
+					}
+				}
+				for t := range follow {
+					if !a.Follow[symbol][t] {
+						a.Follow[symbol][t] = true
+						changed = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// 从开始符号出发，沿产生式右部找到所有可达的非终结符
+func (a *Analysis) findUnreachable() {
+	g := a.Grammar
+	reached := map[string]bool{g.Start(): true}
+	queue := []string{g.Start()}
+	for len(queue) > 0 {
+		nt := queue[0]
+		queue = queue[1:]
+		for _, i := range g.productionsOf(nt) {
+			for _, symbol := range g.Productions[i].Right {
+				if g.IsNonTerminal(symbol) && !reached[symbol] {
+					reached[symbol] = true
+					queue = append(queue, symbol)
+				}
+			}
+		}
+	}
+	for _, nt := range g.NonTerminals() {
+		if !reached[nt] {
+			a.Unreachable = append(a.Unreachable, nt)
+		}
+	}
+}
+
+// 有产生式的右部全部由终结符与有用的非终结符组成时，左部是有用的
+func (a *Analysis) findUnproductive() {
+	g := a.Grammar
+	productive := make(map[string]bool)
+	changed := true
+	for changed {
+		changed = false
+		for _, prod := range g.Productions {
+			if productive[prod.Left] {
+				continue
+			}
+			ok := true
+			for _, symbol := range prod.Right {
+				if g.IsNonTerminal(symbol) && !productive[symbol] {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				productive[prod.Left] = true
+				changed = true
+			}
+		}
+	}
+	for _, nt := range g.NonTerminals() {
+		if !productive[nt] {
+			a.Unproductive = append(a.Unproductive, nt)
+		}
+	}
+}
+
+// 在 A -> α B β 且 α、β 都可空时，A 可以推导出 B。沿这种推导回到自身的非终结符构成环
+func (a *Analysis) findCycles() {
+	g := a.Grammar
+	next := make(map[string][]string)
+	for _, prod := range g.Productions {
+		for i, symbol := range prod.Right {
+			if !g.IsNonTerminal(symbol) {
+				continue
+			}
+			_, before := a.FirstOf(prod.Right[:i])
+			_, after := a.FirstOf(prod.Right[i+1:])
+			if before && after {
+				next[prod.Left] = append(next[prod.Left], symbol)
+			}
+		}
+	}
+
+	inCycle := make(map[string]bool)
+	for _, nt := range g.NonTerminals() {
+		if inCycle[nt] {
+			continue
+		}
+		// 广度优先搜索从 nt 回到 nt 的最短路径
+		parent := make(map[string]string)
+		queue := []string{nt}
+		found := false
+		for len(queue) > 0 && !found {
+			from := queue[0]
+			queue = queue[1:]
+			for _, to := range next[from] {
+				if to == nt {
+					parent[nt] = from
+					found = true
+					break
+				}
+				if _, seen := parent[to]; !seen {
+					parent[to] = from
+					queue = append(queue, to)
+				}
+			}
+		}
+		if !found {
+			continue
+		}
+		cycle := []string{nt}
+		for s := parent[nt]; s != nt; s = parent[s] {
+			cycle = append([]string{s}, cycle...)
+		}
+		cycle = append([]string{nt}, cycle...)
+		for _, s := range cycle {
+			inCycle[s] = true
+		}
+		a.Cycles = append(a.Cycles, cycle)
+	}
+}
+
+// 文法中的问题，每条一行
+func (a *Analysis) Problems() []string {
+	var problems []string
+	if len(a.Unreachable) > 0 {
+		problems = append(problems, "从开始符号不可达的非终结符: "+strings.Join(a.Unreachable, ", "))
+	}
+	if len(a.Unproductive) > 0 {
+		problems = append(problems, "推导不出终结符串的非终结符: "+strings.Join(a.Unproductive, ", "))
+	}
+	for _, cycle := range a.Cycles {
+		problems = append(problems, "推导环: "+strings.Join(cycle, " => "))
+	}
+	return problems
+}
+
+// 按终结符的出现顺序排列集合中的元素
+func (a *Analysis) sorted(set map[string]bool) []string {
+	var result []string
+	for _, t := range a.Terminals {
+		if set[t] {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+func formatSet(symbols []string) string {
+	if len(symbols) == 0 {
+		return "{ }"
+	}
+	return "{ " + strings.Join(symbols, ", ") + " }"
+}
+
+// 打印分析报告：文法规模、每个非终结符的可空性与 FIRST/FOLLOW 集，以及发现的问题
+func (a *Analysis) Print(w io.Writer) {
+	g := a.Grammar
+	nonTerminals := g.NonTerminals()
+	fmt.Fprintf(w, "开始符号: %s\n", g.Start())
+	fmt.Fprintf(w, "%d 个非终结符，%d 个终结符，%d 条产生式\n", len(nonTerminals), len(a.Terminals)-1, len(g.Productions))
+	fmt.Fprintf(w, "终结符: %s\n\n", strings.Join(a.Terminals[:len(a.Terminals)-1], " "))
+
+	var nullable []string
+	for _, nt := range nonTerminals {
+		if a.Nullable[nt] {
+			nullable = append(nullable, nt)
+		}
+	}
+	fmt.Fprintf(w, "可空的非终结符: %s\n\n", formatSet(nullable))
+	for _, nt := range nonTerminals {
+		fmt.Fprintf(w, "FIRST(%s) = %s\n", nt, formatSet(a.sorted(a.First[nt])))
+	}
+	fmt.Fprintln(w)
+	for _, nt := range nonTerminals {
+		fmt.Fprintf(w, "FOLLOW(%s) = %s\n", nt, formatSet(a.sorted(a.Follow[nt])))
+	}
+	fmt.Fprintln(w)
+	problems := a.Problems()
+	if len(problems) == 0 {
+		fmt.Fprintln(w, "没有发现问题")
+		return
+	}
+	fmt.Fprintf(w, "发现 %d 个问题:\n", len(problems))
+	for _, problem := range problems {
+		fmt.Fprintf(w, "    %s\n", problem)
+	}
+}
@@ -0,0 +1,66 @@
+package grammar
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAnalyzeSets(t *testing.T) {
+	a := Analyze(transform(t, exprGrammar).Result)
+	tests := []struct {
+		symbol   string
+		first    []string
+		follow   []string
+		nullable bool
+	}{
+		{"E", []string{"(", "id"}, []string{")", "$"}, false},
+		{"E'", []string{"+"}, []string{")", "$"}, true},
+		{"T", []string{"(", "id"}, []string{"+", ")", "$"}, false},
+		{"T'", []string{"*"}, []string{"+", ")", "$"}, true},
+		{"F", []string{"(", "id"}, []string{"+", "*", ")", "$"}, false},
+	}
+	for _, tt := range tests {
+		if got := a.sorted(a.First[tt.symbol]); !slices.Equal(got, tt.first) {
+			t.Errorf("FIRST(%s) = %v, 期望 %v", tt.symbol, got, tt.first)
+		}
+		if got := a.sorted(a.Follow[tt.symbol]); !slices.Equal(got, tt.follow) {
+			t.Errorf("FOLLOW(%s) = %v, 期望 %v", tt.symbol, got, tt.follow)
+		}
+		if a.Nullable[tt.symbol] != tt.nullable {
+			t.Errorf("%s 可空 = %v, 期望 %v", tt.symbol, a.Nullable[tt.symbol], tt.nullable)
+		}
+	}
+	if got := a.sorted(a.First["+"]); !slices.Equal(got, []string{"+"}) {
+		t.Errorf("终结符 + 的 FIRST 集为 %v", got)
+	}
+
+	first, nullable := a.FirstOf([]string{"E'", "T'", ")"})
+	if got := a.sorted(first); nullable || !slices.Equal(got, []string{"+", "*", ")"}) {
+		t.Errorf("FIRST(E' T' )) = %v, 可空 = %v", got, nullable)
+	}
+	if _, nullable := a.FirstOf(nil); !nullable {
+		t.Error("空串不可空")
+	}
+}
+
+func TestAnalyzeProblems(t *testing.T) {
+	tests := []struct {
+		name string
+		g    *Grammar
+		want []string
+	}{
+		{"没有问题", mustParse(t, exprGrammar), nil},
+		{"不可达与无用", mustParse(t, "S -> a | B\nB -> b B\nC -> c\n"), []string{
+			"从开始符号不可达的非终结符: C",
+			"推导不出终结符串的非终结符: B",
+		}},
+		{"推导环", mustParse(t, "S -> A | s\nA -> B C\nB -> S\nC -> ε | c\n"), []string{
+			"推导环: S => A => B => S",
+		}},
+	}
+	for _, tt := range tests {
+		if got := Analyze(tt.g).Problems(); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: 得到 %q, 期望 %q", tt.name, got, tt.want)
+		}
+	}
+}
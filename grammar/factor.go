@@ -0,0 +1,76 @@
+package grammar
+
+// 提取左公因子：A -> α β1 | α β2 | γ 改写为 A -> α A' | γ，A' -> β1 | β2，
+// 反复进行直到同一非终结符的任意两个候选式都不以相同的符号开头
+func (t *Transform) LeftFactor() {
+	for t.factorOnce() {
+	}
+}
+
+// 提取一组公共前缀，没有可提取的前缀时返回 false
+func (t *Transform) factorOnce() bool {
+	g := t.Result
+	for _, nt := range g.NonTerminals() {
+		prods := g.productionsOf(nt)
+		for i, p := range prods {
+			first := g.Productions[p].Right
+			if len(first) == 0 {
+				continue
+			}
+			group := []int{p}
+			for _, q := range prods[i+1:] {
+				if right := g.Productions[q].Right; len(right) > 0 && right[0] == first[0] {
+					group = append(group, q)
+				}
+			}
+			if len(group) > 1 {
+				t.factor(nt, group, prods[len(prods)-1])
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 对 group 中的产生式提取最长公共前缀，新的产生式放在 last 之后
+func (t *Transform) factor(a string, group []int, last int) {
+	g := t.Result
+	prefix := g.Productions[group[0]].Right
+	for _, q := range group[1:] {
+		right := g.Productions[q].Right
+		n := 0
+		for n < len(prefix) && n < len(right) && prefix[n] == right[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+
+	tail := g.freshName(a)
+	inGroup := make(map[int]bool, len(group))
+	for _, q := range group {
+		inGroup[q] = true
+	}
+	to := g.withProductions(nil)
+	var origins []origin
+	for i, prod := range g.Productions {
+		switch {
+		case i == group[0]:
+			right := append(append([]string{}, prefix...), tail)
+			// 公共前缀来自多条产生式，不继承 %prec
+			to.Productions = append(to.Productions, Production{Left: a, Right: right, Line: g.Productions[group[0]].Line})
+			origins = append(origins, origin{kind: originFactorHead, sources: group})
+		case !inGroup[i]:
+			to.Productions = append(to.Productions, prod)
+			origins = append(origins, origin{kind: originSame, prod: i, sources: []int{i}})
+		}
+		if i != last {
+			continue
+		}
+		for _, q := range group {
+			right := append([]string{}, g.Productions[q].Right[len(prefix):]...)
+			to.Productions = append(to.Productions, Production{Left: tail, Right: right, Prec: g.Productions[q].Prec, Line: g.Productions[q].Line})
+			origins = append(origins, origin{kind: originFactorTail, prod: q, n: len(prefix), sources: []int{q}})
+		}
+	}
+	t.apply(to, origins)
+}
@@ -0,0 +1,3 @@
+module grammar
+
+go 1.23.2
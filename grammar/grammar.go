@@ -0,0 +1,187 @@
+package grammar
+
+import (
+	"strings"
+)
+
+// 产生式结构
+type Production struct {
+	Left  string   // 左部
+	Right []string // 右部，空产生式为空切片
+	Prec  string   // %prec 指定的优先级符号
+	Line  int      // 在文法文件中的行号，变换生成的产生式取其来源产生式的行号
+}
+
+func (prod Production) String() string {
+	var sb strings.Builder
+	sb.WriteString(prod.Left + " ->")
+	if len(prod.Right) == 0 {
+		sb.WriteString(" ε")
+	}
+	for _, symbol := range prod.Right {
+		sb.WriteString(" " + quoteSymbol(symbol))
+	}
+	if prod.Prec != "" {
+		sb.WriteString(" %prec " + quoteSymbol(prod.Prec))
+	}
+	return sb.String()
+}
+
+// 一条 %left、%right 或 %nonassoc 声明
+type PrecedenceDecl struct {
+	Assoc   string   // left、right 或 nonassoc
+	Symbols []string // 同一优先级的终结符
+	Line    int
+}
+
+// 上下文无关文法
+type Grammar struct {
+	Productions []Production
+	StartSymbol string           // %start 声明的开始符号，为空时以第一条产生式的左部为开始符号
+	Tokens      []string         // 用 %token、引号或优先级声明过的终结符，按声明顺序排列
+	Declared    bool             // 使用了 %token，此时没有声明的符号不是终结符
+	Precedences []PrecedenceDecl // 优先级声明，后声明的优先级更高
+}
+
+// 以文法文件格式输出文法，可以再次由 Parse 读入。
+// 声明过终结符时用 %token 列出全部终结符，使输出在检查未声明符号时仍然合法
+func (g *Grammar) String() string {
+	var sb strings.Builder
+	if len(g.Tokens) > 0 {
+		sb.WriteString("%token")
+		for _, symbol := range g.Terminals() {
+			sb.WriteString(" " + quoteSymbol(symbol))
+		}
+		sb.WriteString("\n")
+	}
+	if g.StartSymbol != "" {
+		sb.WriteString("%start " + g.StartSymbol + "\n")
+	}
+	for _, decl := range g.Precedences {
+		sb.WriteString("%" + decl.Assoc)
+		for _, symbol := range decl.Symbols {
+			sb.WriteString(" " + quoteSymbol(symbol))
+		}
+		sb.WriteString("\n")
+	}
+	for _, prod := range g.Productions {
+		sb.WriteString(prod.String())
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// 拥有相同声明、产生式为 prods 的文法
+func (g *Grammar) withProductions(prods []Production) *Grammar {
+	return &Grammar{
+		Productions: prods,
+		StartSymbol: g.StartSymbol,
+		Tokens:      g.Tokens,
+		Declared:    g.Declared,
+		Precedences: g.Precedences,
+	}
+}
+
+// 开始符号
+func (g *Grammar) Start() string {
+	if g.StartSymbol != "" {
+		return g.StartSymbol
+	}
+	return g.Productions[0].Left
+}
+
+// 添加增广产生式 S' -> S，使开始符号只有一个候选式、不出现在右部并且位于第一条产生式。
+// 文法已经满足这些条件时返回 g 本身
+func (g *Grammar) Augmented() *Grammar {
+	start := g.Start()
+	if g.Productions[0].Left == start && len(g.productionsOf(start)) == 1 && !g.usedInRight(start) {
+		return g
+	}
+	augmented := g.withProductions(nil)
+	augmented.StartSymbol = g.freshName(start)
+	augmented.Productions = append([]Production{{Left: augmented.StartSymbol, Right: []string{start}}}, g.Productions...)
+	return augmented
+}
+
+// 按出现顺序排列的终结符：先是声明过的终结符，然后是只出现在右部的符号
+func (g *Grammar) Terminals() []string {
+	var result []string
+	seen := make(map[string]bool)
+	add := func(symbol string) {
+		if !seen[symbol] && !g.IsNonTerminal(symbol) {
+			seen[symbol] = true
+			result = append(result, symbol)
+		}
+	}
+	for _, symbol := range g.Tokens {
+		add(symbol)
+	}
+	for _, prod := range g.Productions {
+		for _, symbol := range prod.Right {
+			add(symbol)
+		}
+	}
+	return result
+}
+
+// 按出现顺序排列的非终结符
+func (g *Grammar) NonTerminals() []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, prod := range g.Productions {
+		if !seen[prod.Left] {
+			seen[prod.Left] = true
+			result = append(result, prod.Left)
+		}
+	}
+	return result
+}
+
+// 判断是否为非终结符
+func (g *Grammar) IsNonTerminal(symbol string) bool {
+	for _, prod := range g.Productions {
+		if prod.Left == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// symbol 是否出现在某条产生式的右部
+func (g *Grammar) usedInRight(symbol string) bool {
+	for _, prod := range g.Productions {
+		for _, s := range prod.Right {
+			if s == symbol {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 左部为 symbol 的产生式下标
+func (g *Grammar) productionsOf(symbol string) []int {
+	var result []int
+	for i, prod := range g.Productions {
+		if prod.Left == symbol {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// 在 base 后添加 ' 得到文法中尚未使用的符号名
+func (g *Grammar) freshName(base string) string {
+	used := make(map[string]bool)
+	for _, prod := range g.Productions {
+		used[prod.Left] = true
+		for _, symbol := range prod.Right {
+			used[symbol] = true
+		}
+	}
+	name := base + "'"
+	for used[name] {
+		name += "'"
+	}
+	return name
+}
@@ -0,0 +1,555 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 文法文件格式：
+//
+//	%token id num '+'        声明终结符，声明过 %token 后使用未声明的符号会报错
+//	%start program           指定开始符号，默认为第一条规则的左部
+//	%left '+' '-'            声明优先级与结合性，后声明的优先级更高
+//	A -> a B | 'x' C         用 | 分隔候选式，下一行以 | 开头时继续上一条规则
+//	   | ε                   ε 或空候选式表示空产生式
+//	E -> E '-' E %prec NEG   %prec 指定候选式的优先级
+//	list -> item ( ',' item )*   ? * + 表示可选、零次或多次、一次或多次
+//	A -> ( a | b ) c         没有后缀的括号用于分组，作为终结符使用的括号需要加引号
+//
+// 单引号或双引号括起来的是终结符；// 到行尾以及 <!-- --> 之间是注释。
+// 没有 %token 声明时，不出现在左部的符号都视为终结符
+
+// 记号类型
+type tokenKind int
+
+const (
+	tokSymbol    tokenKind = iota // 文法符号
+	tokArrow                      // -> 或 →
+	tokPipe                       // |
+	tokDirective                  // %token、%start 等指令
+)
+
+type token struct {
+	kind   tokenKind
+	text   string // 文法符号去掉引号与 EBNF 后缀后的文本
+	quoted bool   // 用引号括起来的终结符
+	suffix byte   // EBNF 后缀 ?、* 或 +，没有时为 0
+	line   int
+}
+
+// 是否为不带引号的符号 text
+func (tok token) is(text string) bool {
+	return tok.kind == tokSymbol && !tok.quoted && tok.text == text
+}
+
+func (tok token) String() string {
+	switch {
+	case tok.kind != tokSymbol:
+		return tok.text
+	case tok.is(")") && tok.suffix != 0:
+		return ")" + string(tok.suffix)
+	case tok.suffix != 0:
+		return quoteSymbol(tok.text) + string(tok.suffix)
+	case tok.quoted:
+		return "'" + tok.text + "'"
+	}
+	return tok.text
+}
+
+// 把文法文本切分为记号，每行的记号单独成组
+func scan(text string) ([][]token, error) {
+	var lines [][]token
+	line := 1
+	newLine := true
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\n':
+			line++
+			newLine = true
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(text[i:], "//"):
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(text[i:], "<!--"):
+			end := strings.Index(text[i:], "-->")
+			if end < 0 {
+				return nil, fmt.Errorf("第 %d 行: 注释没有结束", line)
+			}
+			if n := strings.Count(text[i:i+end], "\n"); n > 0 {
+				line += n
+				newLine = true
+			}
+			i += end + len("-->")
+			continue
+		}
+
+		tok := token{line: line}
+		if c == '\'' || c == '"' {
+			literal, n, err := scanQuoted(text[i:])
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行: %w", line, err)
+			}
+			i += n
+			tok.text, tok.quoted = literal, true
+			if i < len(text) && strings.IndexByte("?*+", text[i]) >= 0 {
+				tok.suffix = text[i]
+				i++
+			}
+			if i < len(text) && !isSpace(text[i]) {
+				return nil, fmt.Errorf("第 %d 行: 终结符 %s 之后缺少空白", line, tok)
+			}
+		} else {
+			j := i
+			for j < len(text) && !isSpace(text[j]) {
+				j++
+			}
+			word := text[i:j]
+			i = j
+			switch {
+			case word == "->" || word == "→":
+				tok.kind, tok.text = tokArrow, word
+			case word == "|":
+				tok.kind, tok.text = tokPipe, word
+			case len(word) > 1 && word[0] == '%':
+				tok.kind, tok.text = tokDirective, word
+			default:
+				tok.text = word
+				// 名字或 ) 之后紧跟的 ?、*、+ 是 EBNF 后缀，其余情况整个单词是一个终结符，如 ++
+				if n := len(word); n > 1 && strings.IndexByte("?*+", word[n-1]) >= 0 {
+					if base := word[:n-1]; base == ")" || isName(base) {
+						tok.text, tok.suffix = base, word[n-1]
+					}
+				}
+			}
+		}
+
+		if newLine {
+			lines = append(lines, nil)
+			newLine = false
+		}
+		lines[len(lines)-1] = append(lines[len(lines)-1], tok)
+	}
+	return lines, nil
+}
+
+// 读入引号括起来的终结符，返回其内容与读入的字节数。\ 转义下一个字符
+func scanQuoted(text string) (string, int, error) {
+	quote := text[0]
+	var sb strings.Builder
+	for i := 1; i < len(text) && text[i] != '\n'; i++ {
+		c := text[i]
+		if c == '\\' && i+1 < len(text) && text[i+1] != '\n' {
+			i++
+			sb.WriteByte(text[i])
+			continue
+		}
+		if c == quote {
+			if sb.Len() == 0 {
+				return "", 0, fmt.Errorf("空的终结符 %c%c", quote, quote)
+			}
+			return sb.String(), i + 1, nil
+		}
+		sb.WriteByte(c)
+	}
+	return "", 0, fmt.Errorf("引号 %c 没有闭合", quote)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// 是否为合法的非终结符名：字母或下划线开头，由字母、数字、下划线和 ' 组成
+func isName(s string) bool {
+	for i, r := range s {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '\'' || unicode.IsDigit(r)):
+		default:
+			return false
+		}
+	}
+	return s != "" && s != "ε"
+}
+
+// 输出文法时给会被误读的符号加上引号
+func quoteSymbol(symbol string) string {
+	if isName(symbol) {
+		return symbol
+	}
+	r, _ := utf8.DecodeLastRuneInString(symbol)
+	special := symbol == "|" || symbol == "->" || symbol == "(" || symbol == ")" || symbol == "→" || symbol == "ε" ||
+		strings.HasPrefix(symbol, "%") || strings.HasPrefix(symbol, "//") || strings.HasPrefix(symbol, "<!--") ||
+		strings.ContainsAny(symbol, " \t'\"\\") ||
+		len(symbol) > 1 && strings.ContainsRune("?*+", r)
+	if !special {
+		return symbol
+	}
+	quoted := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(symbol)
+	return "'" + quoted + "'"
+}
+
+// 一条规则 A -> α | β ...，body 为 -> 之后的全部记号
+type rule struct {
+	left string
+	line int
+	body []token
+}
+
+// 候选式
+type alternative struct {
+	symbols []string
+	prec    string
+	line    int
+}
+
+type parser struct {
+	g            *Grammar
+	rules        []rule
+	tokens       map[string]bool   // 已声明的终结符
+	precedence   map[string]bool   // 声明过优先级的终结符
+	nonTerminals map[string]bool   // 规则的左部与 EBNF 生成的非终结符
+	expanded     map[string]string // EBNF 结构 -> 展开得到的非终结符
+	groups       map[string]int    // 每个左部中已经生成的分组数
+	startLine    int
+	pending      []Production // 当前规则展开 EBNF 生成的产生式
+}
+
+// 解析文法文件，错误信息带有行号
+func Parse(text string) (*Grammar, error) {
+	lines, err := scan(text)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{
+		g:            &Grammar{},
+		tokens:       make(map[string]bool),
+		precedence:   make(map[string]bool),
+		nonTerminals: make(map[string]bool),
+		expanded:     make(map[string]string),
+		groups:       make(map[string]int),
+	}
+	for _, toks := range lines {
+		if err := p.statement(toks); err != nil {
+			return nil, err
+		}
+	}
+	if len(p.rules) == 0 {
+		return nil, fmt.Errorf("文法中没有产生式")
+	}
+	for _, r := range p.rules {
+		p.nonTerminals[r.left] = true
+	}
+	if start := p.g.StartSymbol; start != "" && !p.nonTerminals[start] {
+		return nil, fmt.Errorf("第 %d 行: 开始符号 %s 没有产生式", p.startLine, start)
+	}
+	for _, r := range p.rules {
+		if p.tokens[r.left] {
+			return nil, fmt.Errorf("第 %d 行: 终结符 %s 不能作为产生式左部", r.line, r.left)
+		}
+	}
+
+	for _, r := range p.rules {
+		alts, err := p.alternatives(r.left, r.body, r.line)
+		if err != nil {
+			return nil, err
+		}
+		for _, alt := range alts {
+			p.g.Productions = append(p.g.Productions, Production{Left: r.left, Right: alt.symbols, Prec: alt.prec, Line: alt.line})
+		}
+		p.g.Productions = append(p.g.Productions, p.pending...)
+		p.pending = nil
+	}
+	return p.g, nil
+}
+
+// 处理一行：指令、规则的开头或以 | 开头的续行
+func (p *parser) statement(toks []token) error {
+	first := toks[0]
+	switch first.kind {
+	case tokDirective:
+		return p.directive(first, toks[1:])
+	case tokPipe:
+		if len(p.rules) == 0 {
+			return fmt.Errorf("第 %d 行: | 之前没有规则", first.line)
+		}
+		r := &p.rules[len(p.rules)-1]
+		r.body = append(r.body, toks...)
+		p.declareLiterals(toks)
+		return nil
+	case tokArrow:
+		return fmt.Errorf("第 %d 行: %s 之前缺少左部", first.line, first.text)
+	}
+
+	if len(toks) < 2 || toks[1].kind != tokArrow {
+		return fmt.Errorf("第 %d 行: %s 之后缺少 ->", first.line, first)
+	}
+	if first.quoted || first.suffix != 0 || !isName(first.text) {
+		return fmt.Errorf("第 %d 行: %s 不能作为产生式左部", first.line, first)
+	}
+	p.rules = append(p.rules, rule{left: first.text, line: first.line, body: toks[2:]})
+	p.declareLiterals(toks[2:])
+	return nil
+}
+
+// 引号括起来的符号都是终结符，视为已经声明
+func (p *parser) declareLiterals(toks []token) {
+	for _, tok := range toks {
+		if tok.quoted {
+			p.declare(tok.text)
+		}
+	}
+}
+
+// 记录已声明的终结符
+func (p *parser) declare(symbol string) {
+	if !p.tokens[symbol] {
+		p.tokens[symbol] = true
+		p.g.Tokens = append(p.g.Tokens, symbol)
+	}
+}
+
+// 处理 %token、%start、%left、%right、%nonassoc
+func (p *parser) directive(d token, args []token) error {
+	var symbols []string
+	for _, tok := range args {
+		if tok.kind != tokSymbol || tok.suffix != 0 {
+			return fmt.Errorf("第 %d 行: %s 的参数 %s 不是文法符号", d.line, d.text, tok)
+		}
+		symbols = append(symbols, tok.text)
+	}
+	if len(symbols) == 0 {
+		return fmt.Errorf("第 %d 行: %s 之后缺少符号", d.line, d.text)
+	}
+
+	switch d.text {
+	case "%token":
+		p.g.Declared = true
+		for _, symbol := range symbols {
+			p.declare(symbol)
+		}
+	case "%start":
+		if p.g.StartSymbol != "" {
+			return fmt.Errorf("第 %d 行: 重复的 %%start，第 %d 行已经指定了开始符号", d.line, p.startLine)
+		}
+		if len(symbols) > 1 || args[0].quoted || !isName(symbols[0]) {
+			return fmt.Errorf("第 %d 行: %%start 之后应为一个非终结符", d.line)
+		}
+		p.g.StartSymbol, p.startLine = symbols[0], d.line
+	case "%left", "%right", "%nonassoc":
+		for _, symbol := range symbols {
+			if p.precedence[symbol] {
+				return fmt.Errorf("第 %d 行: 重复声明符号 %s 的优先级", d.line, quoteSymbol(symbol))
+			}
+			p.precedence[symbol] = true
+			p.declare(symbol)
+		}
+		p.g.Precedences = append(p.g.Precedences, PrecedenceDecl{Assoc: d.text[1:], Symbols: symbols, Line: d.line})
+	case "%prec":
+		return fmt.Errorf("第 %d 行: %%prec 只能出现在候选式末尾", d.line)
+	default:
+		return fmt.Errorf("第 %d 行: 未知的指令 %s", d.line, d.text)
+	}
+	return nil
+}
+
+// 把规则右部按 | 分为候选式，并展开其中的 EBNF 结构
+func (p *parser) alternatives(left string, toks []token, line int) ([]alternative, error) {
+	alts := []alternative{{line: line}}
+	for i := 0; i < len(toks); i++ {
+		tok := toks[i]
+		alt := &alts[len(alts)-1]
+		if alt.prec != "" && tok.kind != tokPipe {
+			return nil, fmt.Errorf("第 %d 行: %%prec 必须位于候选式末尾", tok.line)
+		}
+
+		switch tok.kind {
+		case tokPipe:
+			alts = append(alts, alternative{line: tok.line})
+			continue
+		case tokArrow:
+			return nil, fmt.Errorf("第 %d 行: 多余的 %s，作为终结符使用时需要加引号", tok.line, tok.text)
+		case tokDirective:
+			if tok.text != "%prec" {
+				return nil, fmt.Errorf("第 %d 行: %s 不能出现在产生式中", tok.line, tok.text)
+			}
+			if i+1 == len(toks) || toks[i+1].kind != tokSymbol || toks[i+1].suffix != 0 {
+				return nil, fmt.Errorf("第 %d 行: %%prec 之后缺少符号", tok.line)
+			}
+			i++
+			if !p.precedence[toks[i].text] {
+				return nil, fmt.Errorf("第 %d 行: %%prec 使用了未声明优先级的符号 %s", tok.line, toks[i])
+			}
+			alt.prec = toks[i].text
+			continue
+		}
+
+		switch {
+		case tok.is("("):
+			end := matchGroup(toks, i)
+			if end == i {
+				return nil, fmt.Errorf("第 %d 行: 没有与 ( 匹配的 )，作为终结符使用时需要加引号", tok.line)
+			}
+			inner, err := p.alternatives(left, toks[i+1:end], tok.line)
+			if err != nil {
+				return nil, err
+			}
+			var group [][]string
+			for _, a := range inner {
+				if a.prec != "" {
+					return nil, fmt.Errorf("第 %d 行: %%prec 不能出现在括号中", tok.line)
+				}
+				group = append(group, a.symbols)
+			}
+			if toks[end].suffix == 0 && len(group) == 1 {
+				// 只有一个候选式的 ( α ) 就是 α
+				alt.symbols = append(alt.symbols, group[0]...)
+			} else {
+				alt.symbols = append(alt.symbols, p.expand(left, group, toks[end].suffix, tok.line))
+			}
+			i = end
+			continue
+		case tok.is(")"):
+			if tok.suffix == 0 {
+				return nil, fmt.Errorf("第 %d 行: 没有与 ) 匹配的 (，作为终结符使用时需要加引号", tok.line)
+			}
+			return nil, fmt.Errorf("第 %d 行: 没有与 %s 匹配的 (", tok.line, tok)
+		case tok.is("ε"):
+			continue
+		}
+
+		if err := p.checkSymbol(tok); err != nil {
+			return nil, err
+		}
+		if tok.suffix != 0 {
+			alt.symbols = append(alt.symbols, p.expand(left, [][]string{{tok.text}}, tok.suffix, tok.line))
+		} else {
+			alt.symbols = append(alt.symbols, tok.text)
+		}
+	}
+	for i := range alts {
+		if alts[i].symbols == nil {
+			alts[i].symbols = []string{}
+		}
+	}
+	return alts, nil
+}
+
+// 与 toks[i] 处的 ( 配对的 )，没有时返回 i
+func matchGroup(toks []token, i int) int {
+	depth := 0
+	for j := i + 1; j < len(toks); j++ {
+		switch {
+		case toks[j].is("(") && toks[j].suffix == 0:
+			depth++
+		case toks[j].is(")"):
+			if depth == 0 {
+				return j
+			}
+			depth--
+		}
+	}
+	return i
+}
+
+// 检查右部的符号：引号括起来的终结符不能与非终结符同名，声明过 %token 时其余终结符必须已经声明
+func (p *parser) checkSymbol(tok token) error {
+	if tok.quoted {
+		if p.nonTerminals[tok.text] {
+			return fmt.Errorf("第 %d 行: 终结符 %s 与非终结符同名", tok.line, tok)
+		}
+		return nil
+	}
+	if p.g.Declared && !p.nonTerminals[tok.text] && !p.tokens[tok.text] {
+		return fmt.Errorf("第 %d 行: 未声明的符号 %s", tok.line, tok.text)
+	}
+	return nil
+}
+
+// 把 (α)、α?、α*、α+ 展开为新的非终结符，返回其名字。相同的结构只展开一次：
+//
+//	N -> α            (α 有多个候选式的 (α))
+//	N -> α | ε        (α?)
+//	N -> α N | ε      (α*)
+//	N -> α M          (α+，M 为 α* 展开得到的非终结符)
+//
+// α 有多个候选式时每个候选式各得到一条产生式。使用右递归，使展开结果也可以用于 LL(1) 分析
+func (p *parser) expand(left string, alts [][]string, suffix byte, line int) string {
+	var parts []string
+	for _, alt := range alts {
+		parts = append(parts, strings.Join(alt, " "))
+	}
+	key := strings.Join(parts, " | ")
+	if name, ok := p.expanded[string(suffix)+key]; ok {
+		return name
+	}
+
+	var base string
+	if len(alts) == 1 && len(alts[0]) == 1 && isName(alts[0][0]) {
+		base = alts[0][0]
+	} else {
+		p.groups[left]++
+		base = fmt.Sprintf("%s_%d", left, p.groups[left])
+	}
+
+	var name string
+	switch suffix {
+	case 0:
+		name = p.fresh(base)
+		for _, alt := range alts {
+			p.add(name, alt, line)
+		}
+	case '?':
+		name = p.fresh(base + "_opt")
+		for _, alt := range alts {
+			p.add(name, alt, line)
+		}
+		p.add(name, nil, line)
+	case '*':
+		name = p.fresh(base + "_star")
+		for _, alt := range alts {
+			p.add(name, append(append([]string{}, alt...), name), line)
+		}
+		p.add(name, nil, line)
+	case '+':
+		star, ok := p.expanded["*"+key]
+		if !ok {
+			star = p.fresh(base + "_star")
+			p.expanded["*"+key] = star
+			for _, alt := range alts {
+				p.add(star, append(append([]string{}, alt...), star), line)
+			}
+			p.add(star, nil, line)
+		}
+		name = p.fresh(base + "_plus")
+		for _, alt := range alts {
+			p.add(name, append(append([]string{}, alt...), star), line)
+		}
+	}
+	p.expanded[string(suffix)+key] = name
+	return name
+}
+
+func (p *parser) add(left string, right []string, line int) {
+	if right == nil {
+		right = []string{}
+	}
+	p.pending = append(p.pending, Production{Left: left, Right: right, Line: line})
+}
+
+// 在 name 后添加 ' 得到尚未使用的非终结符名
+func (p *parser) fresh(name string) string {
+	for p.nonTerminals[name] || p.tokens[name] {
+		name += "'"
+	}
+	p.nonTerminals[name] = true
+	return name
+}
@@ -0,0 +1,146 @@
+package grammar
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		want   []string
+		tokens []string
+	}{
+		{"候选式与续行", "A -> x B | y\n   | ε\nB -> 'z'\n", []string{
+			"A -> x B",
+			"A -> y",
+			"A -> ε",
+			"B -> z",
+		}, []string{"z"}},
+		{"注释与 %start", "// 注释\n%start B\nA -> x <!-- 跨行\n注释 -->\n   | y\nB -> A '++' \"q\" ε | ε\n", []string{
+			"A -> x",
+			"A -> y",
+			"B -> A '++' q",
+			"B -> ε",
+		}, []string{"++", "q"}},
+		{"%token 与 EBNF", "%token id ','\nlist -> item ( ',' item )*\nitem -> id?\n", []string{
+			"list -> item list_1_star",
+			"list_1_star -> , item list_1_star",
+			"list_1_star -> ε",
+			"item -> id_opt",
+			"id_opt -> id",
+			"id_opt -> ε",
+		}, []string{"id", ","}},
+		{"一次或多次", "A -> a+ b\n", []string{
+			"A -> a_plus b",
+			"a_star -> a a_star",
+			"a_star -> ε",
+			"a_plus -> a a_star",
+		}, nil},
+		{"可选的分组", "S -> ( a | b c )? d\n", []string{
+			"S -> S_1_opt d",
+			"S_1_opt -> a",
+			"S_1_opt -> b c",
+			"S_1_opt -> ε",
+		}, nil},
+		{"相同的 EBNF 只展开一次", "A -> x* y | x* z\n", []string{
+			"A -> x_star y",
+			"A -> x_star z",
+			"x_star -> x x_star",
+			"x_star -> ε",
+		}, nil},
+		{"优先级", "%left '+'\n%left '*'\nE -> E '+' E | '-' E %prec '*' | id\n", []string{
+			"E -> E + E",
+			"E -> - E %prec *",
+			"E -> id",
+		}, []string{"+", "*", "-"}},
+		{"箭头与非名字的终结符", "S → S ++ | '(' ')' | '(' a\n", []string{
+			"S -> S '++'",
+			"S -> '(' ')'",
+			"S -> '(' a",
+		}, []string{"(", ")"}},
+		// 没有后缀的括号用于分组
+		{"分组", "A -> ( a | b ) c | ( x y ) z | ( ) w\nB -> ( a | b ) d\n", []string{
+			"A -> A_1 c",
+			"A -> x y z",
+			"A -> w",
+			"A_1 -> a",
+			"A_1 -> b",
+			"B -> A_1 d",
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mustParse(t, tt.text)
+			if got, want := productionLines(g), strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("得到:\n%s\n期望:\n%s", got, want)
+			}
+			if !slices.Equal(g.Tokens, tt.tokens) {
+				t.Errorf("声明的终结符为 %q, 期望 %q", g.Tokens, tt.tokens)
+			}
+			// String 的输出可以再次读入并得到相同的文法
+			again := mustParse(t, g.String())
+			if productionLines(again) != productionLines(g) || again.Start() != g.Start() {
+				t.Errorf("重新读入 %q 后得到:\n%s", g.String(), productionLines(again))
+			}
+		})
+	}
+}
+
+func TestParseDeclarations(t *testing.T) {
+	g := mustParse(t, "%token id\n%start S\n%left '+' '-'\n%right '^'\nE -> E '+' E | id\nS -> E\n")
+	if g.Start() != "S" || !g.Declared {
+		t.Errorf("开始符号为 %s, Declared = %v", g.Start(), g.Declared)
+	}
+	if len(g.Precedences) != 2 || g.Precedences[0].Assoc != "left" || !slices.Equal(g.Precedences[0].Symbols, []string{"+", "-"}) ||
+		g.Precedences[1].Assoc != "right" || g.Precedences[1].Line != 4 {
+		t.Errorf("优先级声明为 %+v", g.Precedences)
+	}
+	if got := g.Terminals(); !slices.Equal(got, []string{"id", "+", "-", "^"}) {
+		t.Errorf("终结符为 %q", got)
+	}
+	if g.Productions[2].Line != 6 {
+		t.Errorf("产生式 %s 的行号为 %d", g.Productions[2], g.Productions[2].Line)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", "文法中没有产生式"},
+		{"// 只有注释\n", "文法中没有产生式"},
+		{"A -> a\n| b\n\n| c\n-> y\n", "第 5 行: -> 之前缺少左部"},
+		{"| a\n", "第 1 行: | 之前没有规则"},
+		{"A -> a\n| b\nx y\n", "第 3 行: x 之后缺少 ->"},
+		{"'a' -> b\n", "第 1 行: 'a' 不能作为产生式左部"},
+		{"A -> 'a\n", "第 1 行: 引号 ' 没有闭合"},
+		{"A -> ''\n", "第 1 行: 空的终结符 ''"},
+		{"A -> 'a'b\n", "第 1 行: 终结符 'a' 之后缺少空白"},
+		{"A -> a <!-- 注释\n", "第 1 行: 注释没有结束"},
+		{"%token a\nA -> a b\n", "第 2 行: 未声明的符号 b"},
+		{"%token A\nA -> a\n", "第 2 行: 终结符 A 不能作为产生式左部"},
+		{"%start B\nA -> a\n", "第 1 行: 开始符号 B 没有产生式"},
+		{"%start A\n%start A\nA -> a\n", "第 2 行: 重复的 %start"},
+		{"%left '+'\n%right '+'\nA -> a\n", "第 2 行: 重复声明符号 + 的优先级"},
+		{"%foo x\nA -> a\n", "第 1 行: 未知的指令 %foo"},
+		{"%token\nA -> a\n", "第 1 行: %token 之后缺少符号"},
+		{"A -> a %prec '+'\n", "第 1 行: %prec 使用了未声明优先级的符号 '+'"},
+		{"%left '+'\nA -> a %prec '+' b\n", "第 2 行: %prec 必须位于候选式末尾"},
+		{"A -> a )*\n", "第 1 行: 没有与 )* 匹配的 ("},
+		{"A -> ( a\n", "第 1 行: 没有与 ( 匹配的 )，作为终结符使用时需要加引号"},
+		{"A -> a ) b\n", "第 1 行: 没有与 ) 匹配的 (，作为终结符使用时需要加引号"},
+		{"%left '+'\nA -> ( a %prec '+' )*\n", "第 2 行: %prec 不能出现在括号中"},
+		{"A -> a -> b\n", "第 1 行: 多余的 ->"},
+		{"B -> b\nA -> 'B'\n", "第 1 行: 终结符 B 不能作为产生式左部"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.text)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: 错误为 %v, 期望包含 %q", tt.text, err, tt.want)
+		}
+	}
+}
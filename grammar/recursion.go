@@ -0,0 +1,203 @@
+package grammar
+
+import "fmt"
+
+// 消除一个非终结符左递归时最多进行的代入次数，防止文法异常时无限循环
+const maxSubstitutions = 1000
+
+// 消除直接与间接左递归。按非终结符的出现顺序处理 Ai：先把 Ai -> Aj γ（j < i，且 Aj 能推导出以 Ai 开头的串）
+// 中的 Aj 替换为它的各个候选式，再把 A -> A α | β 改写为 A -> β A'，A' -> α A' | ε。
+// 与 Ai 无关的 Aj 不做代入，以尽量保持文法原来的形状
+func (t *Transform) EliminateLeftRecursion() error {
+	order := t.Result.NonTerminals()
+	index := make(map[string]int, len(order))
+	for i, nt := range order {
+		index[nt] = i
+	}
+
+	for i, ai := range order {
+		for count := 0; ; count++ {
+			k := t.findSubstitution(ai, i, index)
+			if k < 0 {
+				break
+			}
+			if count == maxSubstitutions {
+				return fmt.Errorf("无法消除 %s 的间接左递归", ai)
+			}
+			t.substitute(k)
+		}
+		if err := t.eliminateDirect(ai); err != nil {
+			return err
+		}
+	}
+	return t.checkLeftRecursion()
+}
+
+// 查找需要代入的产生式 Ai -> Aj γ，没有时返回 -1
+func (t *Transform) findSubstitution(ai string, i int, index map[string]int) int {
+	for k, prod := range t.Result.Productions {
+		if prod.Left != ai || len(prod.Right) == 0 {
+			continue
+		}
+		aj := prod.Right[0]
+		if j, ok := index[aj]; ok && j < i && t.leftReaches(aj, ai) {
+			return k
+		}
+	}
+	return -1
+}
+
+// from 能否推导出以 target 开头的串（只考虑产生式的第一个符号）
+func (t *Transform) leftReaches(from, target string) bool {
+	visited := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		symbol := queue[0]
+		queue = queue[1:]
+		for _, prod := range t.Result.Productions {
+			if prod.Left != symbol || len(prod.Right) == 0 {
+				continue
+			}
+			first := prod.Right[0]
+			if first == target {
+				return true
+			}
+			if !visited[first] {
+				visited[first] = true
+				queue = append(queue, first)
+			}
+		}
+	}
+	return false
+}
+
+// 把第 k 条产生式 Ai -> Aj γ 替换为 Ai -> δ1 γ | δ2 γ | ...
+func (t *Transform) substitute(k int) {
+	g := t.Result
+	target := g.Productions[k]
+	to := g.withProductions(nil)
+	var origins []origin
+	for i, prod := range g.Productions {
+		if i != k {
+			to.Productions = append(to.Productions, prod)
+			origins = append(origins, origin{kind: originSame, prod: i, sources: []int{i}})
+			continue
+		}
+		for _, q := range g.productionsOf(target.Right[0]) {
+			inner := g.Productions[q].Right
+			right := append(append([]string{}, inner...), target.Right[1:]...)
+			to.Productions = append(to.Productions, Production{Left: target.Left, Right: right, Prec: target.Prec, Line: target.Line})
+			origins = append(origins, origin{kind: originSubst, prod: k, inner: q, n: len(inner), sources: []int{k, q}})
+		}
+	}
+	t.apply(to, origins)
+}
+
+// 消除 a 的直接左递归
+func (t *Transform) eliminateDirect(a string) error {
+	g := t.Result
+	prods := g.productionsOf(a)
+	var recursive, base []int
+	for _, i := range prods {
+		right := g.Productions[i].Right
+		switch {
+		case len(right) == 1 && right[0] == a:
+			return fmt.Errorf("产生式 %s 构成环，无法消除左递归", g.Productions[i])
+		case len(right) > 0 && right[0] == a:
+			recursive = append(recursive, i)
+		default:
+			base = append(base, i)
+		}
+	}
+	if len(recursive) == 0 {
+		return nil
+	}
+	if len(base) == 0 {
+		return fmt.Errorf("%s 的产生式都是左递归的，%s 不能推导出终结符串", a, a)
+	}
+
+	tail := g.freshName(a)
+	last := prods[len(prods)-1]
+	to := g.withProductions(nil)
+	var origins []origin
+	for i, prod := range g.Productions {
+		switch {
+		case prod.Left != a:
+			to.Productions = append(to.Productions, prod)
+			origins = append(origins, origin{kind: originSame, prod: i, sources: []int{i}})
+		case len(prod.Right) == 0 || prod.Right[0] != a:
+			right := append(append([]string{}, prod.Right...), tail)
+			to.Productions = append(to.Productions, Production{Left: a, Right: right, Prec: prod.Prec, Line: prod.Line})
+			origins = append(origins, origin{kind: originBase, prod: i, sources: []int{i}})
+		}
+		if i != last {
+			continue
+		}
+		// A' 的产生式紧接在 A 的最后一条产生式之后
+		for _, r := range recursive {
+			right := append(append([]string{}, g.Productions[r].Right[1:]...), tail)
+			to.Productions = append(to.Productions, Production{Left: tail, Right: right, Prec: g.Productions[r].Prec, Line: g.Productions[r].Line})
+			origins = append(origins, origin{kind: originTail, prod: r, sources: []int{r}})
+		}
+		// A' -> ε 没有对应的原产生式，行号取第一条左递归产生式
+		to.Productions = append(to.Productions, Production{Left: tail, Right: []string{}, Line: g.Productions[recursive[0]].Line})
+		origins = append(origins, origin{kind: originTailEnd})
+	}
+	t.apply(to, origins)
+	return nil
+}
+
+// 检查变换后是否仍有左递归。上面的算法只看产生式的第一个符号，
+// 经由可空符号的左递归（如 A -> B A x，B -> ε）无法消除，在这里报告
+func (t *Transform) checkLeftRecursion() error {
+	g := t.Result
+	nullable := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, prod := range g.Productions {
+			if nullable[prod.Left] {
+				continue
+			}
+			all := true
+			for _, symbol := range prod.Right {
+				if !nullable[symbol] {
+					all = false
+					break
+				}
+			}
+			if all {
+				nullable[prod.Left] = true
+				changed = true
+			}
+		}
+	}
+
+	// 左角关系：A -> B1 ... Bk C ...，B1..Bk 可空时 A 的左角包含 B1..Bk 与 C
+	corners := make(map[string][]string)
+	for _, prod := range g.Productions {
+		for _, symbol := range prod.Right {
+			if g.IsNonTerminal(symbol) {
+				corners[prod.Left] = append(corners[prod.Left], symbol)
+			}
+			if !nullable[symbol] {
+				break
+			}
+		}
+	}
+	for _, nt := range g.NonTerminals() {
+		visited := make(map[string]bool)
+		stack := append([]string{}, corners[nt]...)
+		for len(stack) > 0 {
+			symbol := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if symbol == nt {
+				return fmt.Errorf("消除左递归后 %s 仍然是左递归的（经由可空符号）", nt)
+			}
+			if !visited[symbol] {
+				visited[symbol] = true
+				stack = append(stack, corners[symbol]...)
+			}
+		}
+	}
+	return nil
+}
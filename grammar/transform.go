@@ -0,0 +1,201 @@
+package grammar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 变换后的产生式与变换前文法的对应关系
+type originKind int
+
+const (
+	originSame       originKind = iota // 未改变
+	originSubst                        // Ai -> δ γ，由 Ai -> Aj γ 代入 Aj -> δ 得到
+	originBase                         // A -> β A'，来自 A -> β
+	originTail                         // A' -> α A'，来自 A -> A α
+	originTailEnd                      // A' -> ε
+	originFactorHead                   // A -> α A'，由若干条 A -> α βi 提取公共前缀得到
+	originFactorTail                   // A' -> βi，来自 A -> α βi
+)
+
+type origin struct {
+	kind    originKind
+	prod    int   // 对应的变换前产生式；originSubst 中为 Ai -> Aj γ
+	inner   int   // originSubst 中代入的 Aj -> δ
+	n       int   // originSubst 中 δ 的长度，originFactorTail 中公共前缀的长度
+	sources []int // 变换前文法中与之相关的全部产生式
+}
+
+// 一步基本变换，origins 与变换后文法的产生式一一对应
+type step struct {
+	from    *Grammar
+	origins []origin
+}
+
+// 一系列文法变换。Result 为变换后的文法，Restore 把 Result 的语法树还原为 Original 的语法树
+type Transform struct {
+	Original *Grammar
+	Result   *Grammar
+	steps    []step
+}
+
+// 从 g 开始的空变换
+func NewTransform(g *Grammar) *Transform {
+	result := g.withProductions(append([]Production(nil), g.Productions...))
+	return &Transform{Original: g, Result: result}
+}
+
+// 记录一步变换：Result 变为 to
+func (t *Transform) apply(to *Grammar, origins []origin) {
+	t.steps = append(t.steps, step{from: t.Result, origins: origins})
+	t.Result = to
+}
+
+// 把 Result 的语法树还原为 Original 的语法树
+func (t *Transform) Restore(tree *Node) (*Node, error) {
+	for i := len(t.steps) - 1; i >= 0; i-- {
+		var err error
+		if tree, err = t.steps[i].restore(tree); err != nil {
+			return nil, err
+		}
+	}
+	return tree, nil
+}
+
+// Result 中每条产生式对应的 Original 产生式下标
+func (t *Transform) Sources() [][]int {
+	sources := make([][]int, len(t.Result.Productions))
+	for i := range sources {
+		sources[i] = []int{i}
+	}
+	for i := len(t.steps) - 1; i >= 0; i-- {
+		origins := t.steps[i].origins
+		for j, prods := range sources {
+			seen := make(map[int]bool)
+			var mapped []int
+			for _, prod := range prods {
+				for _, src := range origins[prod].sources {
+					if !seen[src] {
+						seen[src] = true
+						mapped = append(mapped, src)
+					}
+				}
+			}
+			sources[j] = mapped
+		}
+	}
+	return sources
+}
+
+// 打印变换后的每条产生式及其来源
+func (t *Transform) PrintMapping(w io.Writer) {
+	sources := t.Sources()
+	for i, prod := range t.Result.Productions {
+		var from []string
+		for _, src := range sources[i] {
+			from = append(from, fmt.Sprintf("(%d) %s", src, t.Original.Productions[src]))
+		}
+		if len(from) == 0 {
+			from = []string{"新增"}
+		}
+		fmt.Fprintf(w, "(%d) %s  <=  %s\n", i, prod, strings.Join(from, "; "))
+	}
+}
+
+func (s *step) restore(n *Node) (*Node, error) {
+	if n.Prod < 0 {
+		return n, nil
+	}
+	if n.Prod >= len(s.origins) {
+		return nil, fmt.Errorf("节点 %s 的产生式 %d 不存在", n.Symbol, n.Prod)
+	}
+	o := s.origins[n.Prod]
+	switch o.kind {
+	case originSame:
+		children, err := s.restoreAll(n.Children)
+		return &Node{Symbol: n.Symbol, Prod: o.prod, Children: children}, err
+
+	case originSubst:
+		if len(n.Children) < o.n {
+			return nil, fmt.Errorf("节点 %s 的子节点数与产生式不符", n.Symbol)
+		}
+		inner, err := s.restoreAll(n.Children[:o.n])
+		if err != nil {
+			return nil, err
+		}
+		rest, err := s.restoreAll(n.Children[o.n:])
+		if err != nil {
+			return nil, err
+		}
+		innerNode := &Node{Symbol: s.from.Productions[o.inner].Left, Prod: o.inner, Children: inner}
+		return &Node{Symbol: n.Symbol, Prod: o.prod, Children: append([]*Node{innerNode}, rest...)}, nil
+
+	case originBase:
+		// A(β A'(α1 A'(α2 A'()))) 还原为 A(A(A(β) α1) α2)
+		tail, err := s.lastChild(n)
+		if err != nil {
+			return nil, err
+		}
+		children, err := s.restoreAll(n.Children[:len(n.Children)-1])
+		if err != nil {
+			return nil, err
+		}
+		acc := &Node{Symbol: n.Symbol, Prod: o.prod, Children: children}
+		for s.origins[tail.Prod].kind == originTail {
+			next, err := s.lastChild(tail)
+			if err != nil {
+				return nil, err
+			}
+			alpha, err := s.restoreAll(tail.Children[:len(tail.Children)-1])
+			if err != nil {
+				return nil, err
+			}
+			acc = &Node{Symbol: n.Symbol, Prod: s.origins[tail.Prod].prod, Children: append([]*Node{acc}, alpha...)}
+			tail = next
+		}
+		if s.origins[tail.Prod].kind != originTailEnd {
+			return nil, fmt.Errorf("节点 %s 不是 %s 的左递归尾部", tail.Symbol, n.Symbol)
+		}
+		return acc, nil
+
+	case originFactorHead:
+		// A(α A'(βi)) 还原为 A(α βi)
+		tail, err := s.lastChild(n)
+		if err != nil {
+			return nil, err
+		}
+		if s.origins[tail.Prod].kind != originFactorTail {
+			return nil, fmt.Errorf("节点 %s 不是 %s 提取公共前缀后的剩余部分", tail.Symbol, n.Symbol)
+		}
+		merged := append(append([]*Node(nil), n.Children[:len(n.Children)-1]...), tail.Children...)
+		children, err := s.restoreAll(merged)
+		return &Node{Symbol: n.Symbol, Prod: s.origins[tail.Prod].prod, Children: children}, err
+
+	default:
+		return nil, fmt.Errorf("节点 %s 只能作为其父节点的一部分还原", n.Symbol)
+	}
+}
+
+func (s *step) restoreAll(nodes []*Node) ([]*Node, error) {
+	result := make([]*Node, len(nodes))
+	for i, node := range nodes {
+		var err error
+		if result[i], err = s.restore(node); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// 最后一个子节点，必须是非终结符节点
+func (s *step) lastChild(n *Node) (*Node, error) {
+	if len(n.Children) == 0 {
+		return nil, fmt.Errorf("节点 %s 缺少子节点", n.Symbol)
+	}
+	last := n.Children[len(n.Children)-1]
+	if last.Prod < 0 || last.Prod >= len(s.origins) {
+		return nil, fmt.Errorf("节点 %s 的最后一个子节点 %s 不是非终结符", n.Symbol, last.Symbol)
+	}
+	return last, nil
+}
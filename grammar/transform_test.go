@@ -0,0 +1,250 @@
+package grammar
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const exprGrammar = `E -> E '+' T | T
+T -> T '*' F | F
+F -> '(' E ')' | id
+`
+
+func mustParse(t *testing.T, text string) *Grammar {
+	t.Helper()
+	g, err := Parse(text)
+	if err != nil {
+		t.Fatalf("解析文法错误: %v", err)
+	}
+	return g
+}
+
+func productionLines(g *Grammar) string {
+	var lines []string
+	for _, prod := range g.Productions {
+		lines = append(lines, prod.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func transform(t *testing.T, text string) *Transform {
+	t.Helper()
+	tr := NewTransform(mustParse(t, text))
+	if err := tr.EliminateLeftRecursion(); err != nil {
+		t.Fatalf("消除左递归错误: %v", err)
+	}
+	tr.LeftFactor()
+	return tr
+}
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		name    string
+		grammar string
+		want    []string
+	}{
+		{"直接左递归", exprGrammar, []string{
+			"E -> T E'",
+			"E' -> + T E'",
+			"E' -> ε",
+			"T -> F T'",
+			"T' -> * F T'",
+			"T' -> ε",
+			"F -> '(' E ')'",
+			"F -> id",
+		}},
+		// 龙书算法 4.19 的例子
+		{"间接左递归", "S -> A a | b\nA -> A c | S d | ε\n", []string{
+			"S -> A a",
+			"S -> b",
+			"A -> b d A'",
+			"A -> A'",
+			"A' -> c A'",
+			"A' -> a d A'",
+			"A' -> ε",
+		}},
+		{"提取左公因子", "S -> if E then S | if E then S else S | other\nE -> b\n", []string{
+			"S -> if E then S S'",
+			"S -> other",
+			"S' -> ε",
+			"S' -> else S",
+			"E -> b",
+		}},
+		{"多层公共前缀", "A -> a b c | a b d | a e\n", []string{
+			"A -> a A'",
+			"A' -> b A''",
+			"A' -> e",
+			"A'' -> c",
+			"A'' -> d",
+		}},
+		{"无需变换", "S -> a S | b\n", []string{
+			"S -> a S",
+			"S -> b",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := transform(t, tt.grammar)
+			if got, want := productionLines(tr.Result), strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("变换结果:\n%s\n期望:\n%s", got, want)
+			}
+			// 输出的文法可以再次读入
+			if again := mustParse(t, tr.Result.String()); productionLines(again) != productionLines(tr.Result) {
+				t.Errorf("重新读入后得到:\n%s", productionLines(again))
+			}
+		})
+	}
+}
+
+func TestTransformErrors(t *testing.T) {
+	tests := []struct {
+		grammar string
+		want    string
+	}{
+		{"A -> A | a\n", "构成环"},
+		{"A -> A a\n", "都是左递归的"},
+		{"S -> B S x | y\nB -> ε\n", "经由可空符号"},
+	}
+	for _, tt := range tests {
+		err := NewTransform(mustParse(t, tt.grammar)).EliminateLeftRecursion()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: 错误为 %v, 期望包含 %q", tt.grammar, err, tt.want)
+		}
+	}
+}
+
+func TestSources(t *testing.T) {
+	tr := transform(t, "S -> A a | b\nA -> A c | S d | ε\n")
+	want := [][]int{{0}, {1}, {3, 1}, {4}, {2}, {3, 0}, nil}
+	sources := tr.Sources()
+	for i := range want {
+		if !slices.Equal(sources[i], want[i]) {
+			t.Errorf("产生式 (%d) %s 的来源为 %v, 期望 %v", i, tr.Result.Productions[i], sources[i], want[i])
+		}
+	}
+}
+
+// 用回溯的自顶向下分析得到 input 在 g 中的一棵语法树，g 不能有左递归
+func derive(g *Grammar, input []string) *Node {
+	var tree *Node
+	var expand func(symbol string, pos int, k func(*Node, int) bool) bool
+	var sequence func(right []string, pos int, done []*Node, k func([]*Node, int) bool) bool
+	expand = func(symbol string, pos int, k func(*Node, int) bool) bool {
+		if !g.IsNonTerminal(symbol) {
+			return pos < len(input) && input[pos] == symbol && k(Leaf(symbol), pos+1)
+		}
+		for _, i := range g.productionsOf(symbol) {
+			found := sequence(g.Productions[i].Right, pos, nil, func(children []*Node, end int) bool {
+				return k(&Node{Symbol: symbol, Prod: i, Children: children}, end)
+			})
+			if found {
+				return true
+			}
+		}
+		return false
+	}
+	sequence = func(right []string, pos int, done []*Node, k func([]*Node, int) bool) bool {
+		if len(right) == 0 {
+			return k(done, pos)
+		}
+		return expand(right[0], pos, func(n *Node, end int) bool {
+			return sequence(right[1:], end, append(append([]*Node(nil), done...), n), k)
+		})
+	}
+	expand(g.Start(), 0, func(n *Node, end int) bool {
+		tree = n
+		return end == len(input)
+	})
+	return tree
+}
+
+// 检查语法树的每个节点都符合文法 g 中对应的产生式
+func checkTree(t *testing.T, g *Grammar, n *Node) {
+	t.Helper()
+	if n.Prod < 0 {
+		return
+	}
+	prod := g.Productions[n.Prod]
+	ok := prod.Left == n.Symbol && len(prod.Right) == len(n.Children)
+	for i := 0; ok && i < len(n.Children); i++ {
+		ok = n.Children[i].Symbol == prod.Right[i]
+	}
+	if !ok {
+		t.Errorf("节点 %s 与产生式 %s 不符", n, prod)
+		return
+	}
+	for _, child := range n.Children {
+		checkTree(t, g, child)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	tests := []struct {
+		grammar string
+		input   string
+		want    string
+	}{
+		{exprGrammar, "id + id * id + id", "E(E(E(T(F(id))) + T(T(F(id)) * F(id))) + T(F(id)))"},
+		{exprGrammar, "( id + id ) * id", "E(T(T(F(( E(E(T(F(id))) + T(F(id))) ))) * F(id)))"},
+		{"S -> A a | b\nA -> A c | S d | ε\n", "b d c a", "S(A(A(S(b) d) c) a)"},
+		{"S -> A a | b\nA -> A c | S d | ε\n", "a d a", "S(A(S(A() a) d) a)"},
+		{"S -> if E then S | if E then S else S | other\nE -> b\n",
+			"if b then other else other", "S(if E(b) then S(other) else S(other))"},
+		{"A -> a b c | a b d | a e\n", "a b d", "A(a b d)"},
+	}
+	for _, tt := range tests {
+		tr := transform(t, tt.grammar)
+		tree := derive(tr.Result, strings.Fields(tt.input))
+		if tree == nil {
+			t.Fatalf("%q 不能由变换后的文法推导", tt.input)
+		}
+		restored, err := tr.Restore(tree)
+		if err != nil {
+			t.Fatalf("还原 %s: %v", tree, err)
+		}
+		if got := restored.String(); got != tt.want {
+			t.Errorf("%s 还原为 %s, 期望 %s", tree, got, tt.want)
+		}
+		checkTree(t, tr.Original, restored)
+	}
+}
+
+func TestRestoreRejectsForeignTree(t *testing.T) {
+	tr := transform(t, exprGrammar)
+	// E' -> ε 不能单独还原
+	if _, err := tr.Restore(&Node{Symbol: "E'", Prod: 2}); err == nil {
+		t.Error("单独的 E' 节点还原成功")
+	}
+	if _, err := tr.Restore(&Node{Symbol: "E", Prod: 100}); err == nil {
+		t.Error("不存在的产生式还原成功")
+	}
+}
+
+// 变换得到的产生式保留来源产生式的 %prec 与行号
+func TestTransformKeepsPrecAndLine(t *testing.T) {
+	tr := transform(t, `%left '-'
+%right NEG
+E -> E '-' T
+   | '-' E %prec NEG
+   | T
+T -> id 'x' | id 'y' %prec NEG
+`)
+	want := []string{
+		"E -> - E E' %prec NEG @4",
+		"E -> T E' @5",
+		"E' -> - T E' @3",
+		"E' -> ε @3",
+		"T -> id T' @6",
+		"T' -> x @6",
+		"T' -> y %prec NEG @6",
+	}
+	var got []string
+	for _, prod := range tr.Result.Productions {
+		got = append(got, fmt.Sprintf("%s @%d", prod, prod.Line))
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("产生式为\n%s\n期望\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,37 @@
+package grammar
+
+import "strings"
+
+// 语法树节点。非终结符节点的 Prod 为所用产生式在文法中的下标，终结符节点的 Prod 为 -1
+type Node struct {
+	Symbol   string
+	Prod     int
+	Children []*Node
+}
+
+// 终结符节点
+func Leaf(symbol string) *Node {
+	return &Node{Symbol: symbol, Prod: -1}
+}
+
+// 以括号形式输出语法树，如 E(E(T(id)) + T(id))
+func (n *Node) String() string {
+	var sb strings.Builder
+	n.write(&sb)
+	return sb.String()
+}
+
+func (n *Node) write(sb *strings.Builder) {
+	sb.WriteString(n.Symbol)
+	if n.Prod < 0 {
+		return
+	}
+	sb.WriteString("(")
+	for i, child := range n.Children {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		child.write(sb)
+	}
+	sb.WriteString(")")
+}
@@ -0,0 +1,48 @@
+package lexer
+
+import "fmt"
+
+// 诊断代码
+type DiagnosticCode string
+
+const (
+	ErrUnknownChar         DiagnosticCode = "unknown-char"
+	ErrUnterminatedComment DiagnosticCode = "unterminated-comment"
+	ErrUnterminatedChar    DiagnosticCode = "unterminated-char"
+	ErrUnterminatedString  DiagnosticCode = "unterminated-string"
+	ErrEmptyChar           DiagnosticCode = "empty-char"
+	ErrMultiChar           DiagnosticCode = "multi-char"
+	ErrInvalidEscape       DiagnosticCode = "invalid-escape"
+	ErrInvalidNumber       DiagnosticCode = "invalid-number"
+	ErrNumberOutOfRange    DiagnosticCode = "number-out-of-range"
+	ErrInvalidUTF8         DiagnosticCode = "invalid-utf8"
+	ErrInvalidUCN          DiagnosticCode = "invalid-ucn"
+	ErrNulChar             DiagnosticCode = "nul-char"
+)
+
+// 词法分析过程中发现的一条错误
+type Diagnostic struct {
+	Code    DiagnosticCode
+	Span    Span
+	Message string
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%s: 错误[%s]: %s", d.Span.Start, d.Code, d.Message)
+}
+
+// 记录一条诊断信息，返回消息文本以便同时写入 Token.Error
+func (l *Lexer) report(code DiagnosticCode, span Span, message string) string {
+	l.diagnostics = append(l.diagnostics, Diagnostic{Code: code, Span: span, Message: message})
+	return message
+}
+
+// 返回目前为止收集到的全部诊断信息
+func (l *Lexer) Diagnostics() []Diagnostic {
+	return l.diagnostics
+}
+
+// 是否发生过词法错误
+func (l *Lexer) HasErrors() bool {
+	return len(l.diagnostics) > 0
+}
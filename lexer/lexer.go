@@ -2,23 +2,103 @@ package lexer
 
 import (
 	"fmt"
-	"unicode"
+	"io"
+	"unicode/utf8"
 )
 
-func NewLexer(input string) *Lexer {
-	l := &Lexer{input: input}
+func NewLexer(input string, opts ...Option) *Lexer {
+	l := &Lexer{input: input, line: 1, atLineStart: true}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.keywords = keywordTables[l.dialect]
 	l.readChar()
+	// 跳过 UTF-8 BOM，不计入列号
+	if l.ch == '\uFEFF' {
+		l.readChar()
+		l.column = 1
+	}
 	return l
 }
 
+// 从 io.Reader 读取整个源文件并创建词法分析器
+func NewLexerFromReader(r io.Reader, opts ...Option) (*Lexer, error) {
+	input, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewLexer(string(input), opts...), nil
+}
+
+// 设置源文件名，用于诊断信息中的位置
+func WithFilename(filename string) Option {
+	return func(l *Lexer) {
+		l.filename = filename
+	}
+}
+
+// 选择语言方言，默认为 C11
+func WithDialect(dialect Dialect) Option {
+	return func(l *Lexer) {
+		l.dialect = dialect
+	}
+}
+
+// 关闭相邻字符串常量的自动连接，供预处理器在宏展开之后自行连接
+func WithoutStringConcat() Option {
+	return func(l *Lexer) {
+		l.noConcat = true
+	}
+}
+
+// 返回 file:line:column 形式的位置描述
+func (p Position) String() string {
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// 读取下一个 UTF-8 字符，列号按字符而不是字节计数。到达输入末尾后 ch 为 0，
+// 源代码中的 NUL 字符 ch 也为 0，两者用 atEOF 区分
 func (l *Lexer) readChar() {
-	if l.readPosition >= len(l.input) {
-		l.ch = 0
+	// 根据离开的字符更新行列号
+	if l.ch == '\n' {
+		l.line++
+		l.column = 1
 	} else {
-		l.ch = l.input[l.readPosition]
+		l.column++
 	}
 	l.position = l.readPosition
-	l.readPosition++
+	if l.readPosition >= len(l.input) {
+		l.ch = 0
+		l.readPosition++
+		return
+	}
+	r, width := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	l.ch = r
+	l.readPosition += width
+}
+
+// 是否已读完全部输入
+func (l *Lexer) atEOF() bool {
+	return l.position >= len(l.input)
+}
+
+// 报告当前位置的 NUL 字符
+func (l *Lexer) reportNul() string {
+	pos := l.currentPosition()
+	return l.report(ErrNulChar, Span{Start: pos, End: pos}, "源代码中有空字符 (NUL)")
+}
+
+// 当前字符是否为无效的 UTF-8 字节
+func (l *Lexer) atInvalidUTF8() bool {
+	return l.ch == utf8.RuneError && l.readPosition-l.position == 1
+}
+
+// 当前字符 ch 的位置
+func (l *Lexer) currentPosition() Position {
+	return Position{File: l.filename, Line: l.line, Column: l.column, Offset: l.position}
 }
 
 func (l *Lexer) NextToken() Token {
@@ -28,333 +108,235 @@ func (l *Lexer) NextToken() Token {
 		return tok
 	}
 
-	var tok Token
-	l.skipWhitespace()
+	before := l.position
+	tok, ok := l.skipWhitespaceAndComments()
+	// 相邻字符串拼接时会越过换行，要在此之前记录 Token 是否位于行首
+	lineStart := l.atLineStart
+	if !ok {
+		l.report(ErrUnterminatedComment, tok.Span, tok.Error)
+	} else {
+		start := l.currentPosition()
+		tok = l.scanToken()
+		tok.Span = Span{Start: start, End: l.currentPosition()}
+		if tok.Type == STRING && !l.noConcat {
+			tok = l.concatStrings(tok)
+		}
+	}
+	tok.SpaceBefore = tok.Span.Start.Offset > before
+	tok.LineStart = lineStart
+	l.atLineStart = false
+	return tok
+}
 
+func (l *Lexer) scanToken() Token {
+	var tok Token
+	if l.atEOF() {
+		return Token{Type: EOF, Value: ""}
+	}
 	if l.ch == 0 {
-		return Token{Type: UNKNOWN, Value: ""}
+		tok := Token{Type: UNKNOWN, Value: "\x00", Error: l.reportNul()}
+		l.readChar()
+		return tok
 	}
 
 	switch l.ch {
-	case '+':
-		if l.peekChar() == '=' {
-			l.readChar()
-			tok = Token{Type: PLUS_ASSIGN, Value: "+="}
-		} else {
-			tok = Token{Type: PLUS, Value: string(l.ch)}
-		}
-	case '-':
-		if l.peekChar() == '=' {
-			l.readChar()
-			tok = Token{Type: MINUS_ASSIGN, Value: "-="}
-		} else {
-			tok = Token{Type: MINUS, Value: string(l.ch)}
-		}
-	case '*':
-		if l.peekChar() == '=' {
-			l.readChar()
-			tok = Token{Type: ASTERISK_ASSIGN, Value: "*="}
-		} else {
-			tok = Token{Type: ASTERISK, Value: string(l.ch)}
-		}
-	case '/':
-		if l.peekChar() == '=' {
-			l.readChar()
-			tok = Token{Type: SLASH_ASSIGN, Value: "/="}
-		} else {
-			tok = Token{Type: SLASH, Value: string(l.ch)}
-		}
-	case '=':
-		if l.peekChar() == '=' {
-			l.readChar()
-			tok = Token{Type: EQ, Value: "=="}
-		} else {
-			tok = Token{Type: ASSIGN, Value: string(l.ch)}
-		}
-	case '!':
-		if l.peekChar() == '=' {
-			l.readChar()
-			tok = Token{Type: NEQ, Value: "!="}
-		} else {
-			tok = Token{Type: NOT, Value: string(l.ch)}
-		}
-	case '<':
-		if l.peekChar() == '=' {
-			l.readChar()
-			tok = Token{Type: LTE, Value: "<="}
-		} else {
-			tok = Token{Type: LT, Value: string(l.ch)}
-		}
-	case '>':
-		if l.peekChar() == '=' {
-			l.readChar()
-			tok = Token{Type: GTE, Value: ">="}
-		} else {
-			tok = Token{Type: GT, Value: string(l.ch)}
-		}
-	case '&':
-		if l.peekChar() == '&' {
-			l.readChar()
-			tok = Token{Type: AND, Value: "&&"}
-		} else {
-			tok = Token{Type: AND, Value: string(l.ch)}
-		}
-	case '|':
-		if l.peekChar() == '|' {
-			l.readChar()
-			tok = Token{Type: OR, Value: "||"}
-		} else {
-			tok = Token{Type: OR, Value: string(l.ch)}
-		}
-	case ';':
-		tok = Token{Type: SEMICOLON, Value: string(l.ch)}
-	case '{':
-		tok = Token{Type: LBRACE, Value: string(l.ch)}
-	case '}':
-		tok = Token{Type: RBRACE, Value: string(l.ch)}
-	case '(':
-		tok = Token{Type: LPAREN, Value: string(l.ch)}
-	case ')':
-		tok = Token{Type: RPAREN, Value: string(l.ch)}
 	case '\'':
-		tok = l.readCharConstant()
+		return l.readCharConstant(l.currentPosition(), false)
 	case '"':
-		tok = l.readString()
-	case '0':
-		peek := l.peekChar()
-		if peek == 'x' || peek == 'X' {
-			l.readChar()
-			l.readChar()
-			tok = l.readHex()
-			return tok
-		} else if peek == 'b' || peek == 'B' {
-			l.readChar()
-			l.readChar()
-			tok = l.readBinary()
-			return tok
-		} else if peek == 'o' || peek == 'O' {
-			l.readChar()
-			l.readChar()
-			tok = l.readOctal()
-			return tok
-		} else {
-			tok = l.readNumber()
-			return tok
-		}
+		return l.readString(l.currentPosition(), false)
 	case '.':
-		tok = l.readFloat()
+		if isDigit(l.peekChar()) {
+			return l.readNumber()
+		}
+		tok, _ = l.matchPunctuator()
 		return tok
 	default:
-		if isLetter(l.ch) {
-			ident := l.readIdentifier()
-			if tokType, ok := reservedWords[ident]; ok {
-				tok = Token{Type: tokType, Value: ident}
-			} else {
-				tok = Token{Type: IDENT, Value: ident}
-			}
-			return tok
+		if n := l.literalPrefixLen(); n > 0 {
+			return l.readPrefixedLiteral(n)
+		}
+		if l.isIdentStart(l.ch) || l.atUCN() {
+			return l.readIdentifier()
 		} else if isDigit(l.ch) {
-			tok = l.readNumber()
+			return l.readNumber()
+		} else if tok, ok := l.matchPunctuator(); ok {
 			return tok
 		} else {
-			tok = Token{Type: UNKNOWN, Value: string(l.ch), Error: fmt.Sprintf("未知字符: '%c'", l.ch)}
+			return l.readUnknown()
 		}
 	}
-
-	l.readChar()
-	return tok
 }
 
-func (l *Lexer) UnreadToken(tok Token) {
-	l.prevToken = &tok
-}
-
-func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+// 将连续的非法字符合并为一个 UNKNOWN Token，在下一个可能的 Token 开始处恢复
+func (l *Lexer) readUnknown() Token {
+	start := l.currentPosition()
+	for !l.atEOF() && l.ch != 0 && !l.canStartToken() {
 		l.readChar()
 	}
-}
-
-func (l *Lexer) readIdentifier() string {
-	position := l.position
-	for isLetter(l.ch) || isDigit(l.ch) {
-		l.readChar()
+	value := l.input[start.Offset:l.position]
+	tok := Token{Type: UNKNOWN, Value: value}
+	span := Span{Start: start, End: l.currentPosition()}
+	if !utf8.ValidString(value) {
+		tok.Error = l.report(ErrInvalidUTF8, span, fmt.Sprintf("无效的 UTF-8 编码: %q", value))
+	} else {
+		tok.Error = l.report(ErrUnknownChar, span, fmt.Sprintf("未知字符: '%s'", value))
 	}
-	return l.input[position:l.position]
+	return tok
 }
 
-func (l *Lexer) readNumber() Token {
-	position := l.position
-	for isDigit(l.ch) {
-		l.readChar()
-	}
-	// 检查是否为浮点数
-	if l.ch == '.' {
-		return l.readFloatFrom(position)
+// 判断当前字符是否可以作为一个合法 Token 的开始
+func (l *Lexer) canStartToken() bool {
+	switch {
+	case l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' || l.ch == '\v' || l.ch == '\f':
+		return true
+	case l.isIdentStart(l.ch) || isDigit(l.ch) || l.ch == '\'' || l.ch == '"' || l.atUCN():
+		return true
 	}
-	// 检查是否有紧跟的字母或下划线，表示无效的标识符
-	if isLetter(l.ch) || l.ch == '_' {
-		invalidPart := ""
-		for isLetter(l.ch) || isDigit(l.ch) || l.ch == '_' {
-			invalidPart += string(l.ch)
-			l.readChar()
-		}
-		return Token{
-			Type:  UNKNOWN,
-			Value: l.input[position:l.position],
-			Error: fmt.Sprintf("无效的标识符: %s", l.input[position:l.position]),
-		}
-	}
-	value := l.input[position:l.position]
-	return Token{Type: NUMBER, Value: value}
+	_, ok := punctuators[string(l.ch)]
+	return ok
 }
 
-func (l *Lexer) readHex() Token {
-	position := l.position
-	for isHexDigit(l.ch) {
-		l.readChar()
-	}
-	value := l.input[position:l.position]
-	// 检查后续字符是否为字母或数字，表示无效的十六进制数
-	if isLetter(l.ch) || isDigit(l.ch) {
-		invalidPart := ""
-		for isLetter(l.ch) || isDigit(l.ch) {
-			invalidPart += string(l.ch)
-			l.readChar()
+// 按最长匹配原则读取运算符或标点符号
+func (l *Lexer) matchPunctuator() (Token, bool) {
+	rest := l.input[l.position:]
+	for n := maxPunctuatorLen; n > 0; n-- {
+		if n > len(rest) {
+			continue
 		}
-		return Token{
-			Type:  UNKNOWN,
-			Value: "0x" + value + invalidPart,
-			Error: fmt.Sprintf("无效的十六进制数: 0x%s%s", value, invalidPart),
+		if tokType, ok := punctuators[rest[:n]]; ok {
+			for i := 0; i < n; i++ {
+				l.readChar()
+			}
+			return Token{Type: tokType, Value: rest[:n]}, true
 		}
 	}
-	return Token{Type: HEX, Value: "0x" + value}
+	return Token{}, false
 }
 
-func (l *Lexer) readOctal() Token {
-	position := l.position
-	for isOctalDigit(l.ch) {
-		l.readChar()
-	}
-	value := l.input[position:l.position]
-	// 检查后续字符是否为字母或数字，表示无效的八进制数
-	if isLetter(l.ch) || isDigit(l.ch) {
-		invalidPart := ""
-		for isLetter(l.ch) || isDigit(l.ch) {
-			invalidPart += string(l.ch)
-			l.readChar()
-		}
-		return Token{
-			Type:  UNKNOWN,
-			Value: "0o" + value + invalidPart,
-			Error: fmt.Sprintf("无效的八进制数: 0o%s%s", value, invalidPart),
-		}
-	}
-	return Token{Type: OCTAL, Value: "0o" + value}
+func (l *Lexer) UnreadToken(tok Token) {
+	l.prevToken = &tok
 }
 
-func (l *Lexer) readBinary() Token {
-	position := l.position
-	for isBinaryDigit(l.ch) {
-		l.readChar()
-	}
-	value := l.input[position:l.position]
-	// 检查后续字符是否为字母或数字，表示无效的二进制数
-	if isLetter(l.ch) || isDigit(l.ch) {
-		invalidPart := ""
-		for isLetter(l.ch) || isDigit(l.ch) {
-			invalidPart += string(l.ch)
+func (l *Lexer) skipWhitespace() {
+	for {
+		switch {
+		case l.ch == ' ' || l.ch == '\t' || l.ch == '\r' || l.ch == '\v' || l.ch == '\f':
 			l.readChar()
-		}
-		return Token{
-			Type:  UNKNOWN,
-			Value: "0b" + value + invalidPart,
-			Error: fmt.Sprintf("无效的二进制数: 0b%s%s", value, invalidPart),
+		case l.ch == '\n':
+			l.atLineStart = true
+			l.readChar()
+		case l.ch == '\\' && (l.peekChar() == '\n' || (l.peekChar() == '\r' && l.peekCharAt(2) == '\n')):
+			// 续行：反斜杠加换行视为空白，不结束逻辑行
+			l.readChar()
+			if l.ch == '\r' {
+				l.readChar()
+			}
+			l.readChar()
+		default:
+			return
 		}
 	}
-	return Token{Type: BINARY, Value: "0b" + value}
 }
 
-func (l *Lexer) readFloat() Token {
-	return l.readFloatFrom(l.position)
-}
-
-func (l *Lexer) readFloatFrom(start int) Token {
-	hasDot := false
-	hasExp := false
-
-	for isDigit(l.ch) || l.ch == '.' || l.ch == 'e' || l.ch == 'E' || l.ch == '+' || l.ch == '-' {
-		if l.ch == '.' {
-			if hasDot {
-				break
-			}
-			hasDot = true
+// 跳过空白与注释，块注释未闭合时返回错误 Token 和 false
+func (l *Lexer) skipWhitespaceAndComments() (Token, bool) {
+	for {
+		l.skipWhitespace()
+		if l.ch != '/' {
+			return Token{}, true
 		}
-		if l.ch == 'e' || l.ch == 'E' {
-			if hasExp {
-				break
+		switch l.peekChar() {
+		case '/':
+			l.skipLineComment()
+		case '*':
+			start := l.currentPosition()
+			if !l.skipBlockComment() {
+				return Token{
+					Type:  UNKNOWN,
+					Value: l.input[start.Offset:l.position],
+					Error: "块注释未闭合",
+					Span:  Span{Start: start, End: l.currentPosition()},
+				}, false
 			}
-			hasExp = true
+		default:
+			return Token{}, true
 		}
-		l.readChar()
 	}
-	value := l.input[start:l.position]
-	return Token{Type: FLOAT, Value: value}
 }
 
-func (l *Lexer) readCharConstant() Token {
-	l.readChar()
-	start := l.position
-	for l.ch != '\'' && l.ch != 0 {
+// 跳过 // 注释，停在换行符处
+func (l *Lexer) skipLineComment() {
+	for l.ch != '\n' && !l.atEOF() {
 		l.readChar()
 	}
-	if l.ch != '\'' {
-		return Token{Type: CHAR, Value: l.input[start:l.position], Error: "字符常量未闭合"}
-	}
-	value := l.input[start:l.position]
-	l.readChar()
-	return Token{Type: CHAR, Value: value}
 }
 
-func (l *Lexer) readString() Token {
+// 跳过 /* */ 注释，返回注释是否闭合
+func (l *Lexer) skipBlockComment() bool {
+	l.readChar()
 	l.readChar()
-	start := l.position
-	for l.ch != '"' && l.ch != 0 {
+	for !l.atEOF() {
+		if l.ch == '*' && l.peekChar() == '/' {
+			l.readChar()
+			l.readChar()
+			return true
+		}
 		l.readChar()
 	}
-	if l.ch != '"' {
-		return Token{Type: STRING, Value: l.input[start:l.position], Error: "字符串常量未闭合"}
-	}
-	value := l.input[start:l.position]
-	l.readChar()
-	return Token{Type: STRING, Value: value}
-}
-
-func isLetter(ch byte) bool {
-	return unicode.IsLetter(rune(ch)) || ch == '_'
+	return false
 }
 
-func isDigit(ch byte) bool {
-	return unicode.IsDigit(rune(ch))
+func isDigit(ch rune) bool {
+	return ch >= '0' && ch <= '9'
 }
 
-func isHexDigit(ch byte) bool {
+func isHexDigit(ch rune) bool {
 	return isDigit(ch) ||
 		(ch >= 'a' && ch <= 'f') ||
 		(ch >= 'A' && ch <= 'F')
 }
 
-func isOctalDigit(ch byte) bool {
+func isOctalDigit(ch rune) bool {
 	return ch >= '0' && ch <= '7'
 }
 
-func isBinaryDigit(ch byte) bool {
+func isBinaryDigit(ch rune) bool {
 	return ch == '0' || ch == '1'
 }
 
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
+func (l *Lexer) peekChar() rune {
+	return l.peekCharAt(1)
+}
+
+// 查看当前字符之后第 n 个字符
+func (l *Lexer) peekCharAt(n int) rune {
+	offset := l.readPosition
+	for ; n > 1 && offset < len(l.input); n-- {
+		_, width := utf8.DecodeRuneInString(l.input[offset:])
+		offset += width
+	}
+	if offset >= len(l.input) {
 		return 0
 	}
-	return l.input[l.readPosition]
+	r, _ := utf8.DecodeRuneInString(l.input[offset:])
+	return r
+}
+
+func (l *Lexer) saveState() lexerState {
+	return lexerState{
+		position:     l.position,
+		readPosition: l.readPosition,
+		ch:           l.ch,
+		line:         l.line,
+		column:       l.column,
+		atLineStart:  l.atLineStart,
+	}
+}
+
+func (l *Lexer) restoreState(state lexerState) {
+	l.position = state.position
+	l.readPosition = state.readPosition
+	l.ch = state.ch
+	l.line = state.line
+	l.column = state.column
+	l.atLineStart = state.atLineStart
 }
@@ -0,0 +1,124 @@
+package lexer
+
+import (
+	"strings"
+	"testing"
+)
+
+// 读取全部 Token，包括末尾的 EOF
+func lexAll(source string, opts ...Option) ([]Token, *Lexer) {
+	l := NewLexer(source, opts...)
+	var tokens []Token
+	for {
+		tok := l.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Type == EOF {
+			return tokens, l
+		}
+	}
+}
+
+func TestNulCharacter(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		types  []TokenType
+	}{
+		{"Token 之间", "a\x00b", []TokenType{IDENT, UNKNOWN, IDENT, EOF}},
+		{"末尾", "a;\x00", []TokenType{IDENT, SEMICOLON, UNKNOWN, EOF}},
+		{"与非法字符相邻", "@\x00@ x", []TokenType{UNKNOWN, UNKNOWN, UNKNOWN, IDENT, EOF}},
+		{"字符串中", "\"a\x00b\" c", []TokenType{STRING, IDENT, EOF}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, l := lexAll(tt.source)
+			if len(tokens) != len(tt.types) {
+				t.Fatalf("得到 %d 个 Token %v, 期望 %v", len(tokens), tokens, tt.types)
+			}
+			for i, tok := range tokens {
+				if tok.Type != tt.types[i] {
+					t.Errorf("第 %d 个 Token 类型为 %s, 期望 %s", i, tok.Type, tt.types[i])
+				}
+			}
+			found := false
+			for _, d := range l.Diagnostics() {
+				found = found || d.Code == ErrNulChar
+			}
+			if !found {
+				t.Errorf("没有报告 %s: %v", ErrNulChar, l.Diagnostics())
+			}
+		})
+	}
+
+	// 注释中的 NUL 不截断输入
+	tokens, _ := lexAll("a /* \x00 */ b // \x00\nc")
+	if len(tokens) != 4 || tokens[2].Value != "c" {
+		t.Errorf("注释中有 NUL 时得到 %v", tokens)
+	}
+}
+
+// 列号按字符计算
+func TestPositions(t *testing.T) {
+	tokens, _ := lexAll("int x;\n  /* 注释 */ y = \"é\" + 1;", WithFilename("a.c"))
+	tests := []struct {
+		index        int
+		value        string
+		line, column int
+	}{
+		{0, "int", 1, 1},
+		{1, "x", 1, 5},
+		{2, ";", 1, 6},
+		{3, "y", 2, 12},
+		{5, `"é"`, 2, 16},
+		{6, "+", 2, 20},
+	}
+	for _, tt := range tests {
+		tok := tokens[tt.index]
+		if tok.Value != tt.value || tok.Span.Start.Line != tt.line || tok.Span.Start.Column != tt.column {
+			t.Errorf("第 %d 个 Token %q 位于 %s, 期望 %q 位于 %d:%d", tt.index, tok.Value, tok.Span.Start, tt.value, tt.line, tt.column)
+		}
+	}
+	if !tokens[3].LineStart || tokens[4].LineStart {
+		t.Error("LineStart 不正确")
+	}
+	if eof := tokens[len(tokens)-1]; eof.Type != EOF || eof.Span.Start.File != "a.c" {
+		t.Errorf("EOF Token 为 %+v", eof)
+	}
+}
+
+// 拼接跨行的相邻字符串后，LineStart 取决于第一个字符串是否位于行首
+func TestLineStart(t *testing.T) {
+	tokens, _ := lexAll("x = \"a\"\n\"b\";\n\"c\"\n\"d\"\ny")
+	tests := []struct {
+		value     string
+		lineStart bool
+	}{
+		{"x", true},
+		{"=", false},
+		{"\"a\"\n\"b\"", false},
+		{";", false},
+		{"\"c\"\n\"d\"", true},
+		{"y", true},
+		{"", false},
+	}
+	if len(tokens) != len(tests) {
+		t.Fatalf("得到 %d 个 Token %v", len(tokens), tokens)
+	}
+	for i, tt := range tests {
+		if tokens[i].Value != tt.value || tokens[i].LineStart != tt.lineStart {
+			t.Errorf("第 %d 个 Token %q 的 LineStart 为 %v, 期望 %q 的 LineStart 为 %v", i, tokens[i].Value, tokens[i].LineStart, tt.value, tt.lineStart)
+		}
+	}
+}
+
+func TestGrammarSymbol(t *testing.T) {
+	tokens, _ := lexAll("x = a<:0:> + 1; <% %> %: %:%: 'c' \"s\" while", WithDialect(DialectCourse))
+	var got []string
+	for _, tok := range tokens {
+		got = append(got, tok.GrammarSymbol())
+	}
+	want := []string{"id", "=", "id", "[", "num", "]", "+", "num", ";", "{", "}", "#", "##", "char", "string", "while", "$"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("终结符为 %v, 期望 %v", got, want)
+	}
+}
@@ -0,0 +1,256 @@
+package lexer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// 简单转义序列
+var simpleEscapes = map[rune]byte{
+	'\'': '\'',
+	'"':  '"',
+	'?':  '?',
+	'\\': '\\',
+	'a':  '\a',
+	'b':  '\b',
+	'f':  '\f',
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
+	'v':  '\v',
+}
+
+// 若当前位置是带编码前缀（L、u、U、u8）的字符或字符串常量，返回前缀长度，否则返回0
+func (l *Lexer) literalPrefixLen() int {
+	rest := l.input[l.position:]
+	switch {
+	case strings.HasPrefix(rest, "u8\""):
+		return 2
+	case len(rest) >= 2 && (rest[0] == 'L' || rest[0] == 'u' || rest[0] == 'U') && (rest[1] == '\'' || rest[1] == '"'):
+		return 1
+	}
+	return 0
+}
+
+func (l *Lexer) readPrefixedLiteral(prefixLen int) Token {
+	start := l.currentPosition()
+	for i := 0; i < prefixLen; i++ {
+		l.readChar()
+	}
+	if l.ch == '\'' {
+		return l.readCharConstant(start, true)
+	}
+	return l.readString(start, true)
+}
+
+func (l *Lexer) readCharConstant(start Position, wide bool) Token {
+	decoded, errMsg, closed := l.readQuoted('\'', wide)
+	tok := Token{Type: CHAR, Value: l.input[start.Offset:l.position], Decoded: decoded, Error: errMsg}
+	span := Span{Start: start, End: l.currentPosition()}
+	if !closed {
+		tok.Error = l.report(ErrUnterminatedChar, span, "字符常量未闭合")
+		return tok
+	}
+	if tok.Error == "" {
+		switch utf8.RuneCountInString(decoded) {
+		case 0:
+			tok.Error = l.report(ErrEmptyChar, span, "空字符常量")
+		case 1:
+			tok.Number = charValue(decoded, l.input[start.Offset])
+		default:
+			tok.Error = l.report(ErrMultiChar, span, fmt.Sprintf("多字符常量: %s", tok.Value))
+		}
+	}
+	return tok
+}
+
+// 字符常量的值与类型（按 LP64 模型，wchar_t 为 int）。单个字节（包括 \xff 等转义得到的码元）取 0~255，
+// 普通字符常量转换为 int 时的符号由 char 是否有符号决定，交给使用者处理
+func charValue(decoded string, prefix byte) *NumberValue {
+	value := &NumberValue{CType: "int"}
+	switch prefix {
+	case 'u':
+		value.CType = "unsigned short"
+	case 'U':
+		value.CType = "unsigned int"
+	}
+	if len(decoded) == 1 {
+		value.Int = uint64(decoded[0])
+	} else {
+		r, _ := utf8.DecodeRuneInString(decoded)
+		value.Int = uint64(r)
+	}
+	return value
+}
+
+func (l *Lexer) readString(start Position, wide bool) Token {
+	decoded, errMsg, closed := l.readQuoted('"', wide)
+	tok := Token{Type: STRING, Value: l.input[start.Offset:l.position], Decoded: decoded, Error: errMsg}
+	if !closed {
+		tok.Error = l.report(ErrUnterminatedString, Span{Start: start, End: l.currentPosition()}, "字符串常量未闭合")
+	}
+	return tok
+}
+
+// 读取以 quote 包围的内容并处理转义序列，返回解码结果、第一个错误以及是否闭合。
+// 每个错误的转义序列都会单独记录诊断信息
+func (l *Lexer) readQuoted(quote rune, wide bool) (string, string, bool) {
+	var decoded strings.Builder
+	errMsg := ""
+	l.readChar()
+	for l.ch != quote {
+		if l.atEOF() || l.ch == '\n' {
+			return decoded.String(), errMsg, false
+		}
+		if l.ch == 0 {
+			if e := l.reportNul(); errMsg == "" {
+				errMsg = e
+			}
+		}
+		if l.atInvalidUTF8() {
+			// 保留原始字节
+			l.report(ErrInvalidUTF8, Span{Start: l.currentPosition(), End: l.currentPosition()}, "无效的 UTF-8 编码")
+			decoded.WriteByte(l.input[l.position])
+			l.readChar()
+			continue
+		}
+		if l.ch != '\\' {
+			decoded.WriteRune(l.ch)
+			l.readChar()
+			continue
+		}
+		// 续行：反斜杠紧跟换行
+		if l.peekChar() == '\n' || (l.peekChar() == '\r' && l.peekCharAt(2) == '\n') {
+			l.readChar()
+			if l.ch == '\r' {
+				l.readChar()
+			}
+			l.readChar()
+			continue
+		}
+		escStart := l.currentPosition()
+		if e := l.readEscape(&decoded, wide); e != "" {
+			l.report(ErrInvalidEscape, Span{Start: escStart, End: l.currentPosition()}, e)
+			if errMsg == "" {
+				errMsg = e
+			}
+		}
+	}
+	l.readChar()
+	return decoded.String(), errMsg, true
+}
+
+// 读取以反斜杠开头的转义序列，将结果写入 decoded，返回错误信息
+func (l *Lexer) readEscape(decoded *strings.Builder, wide bool) string {
+	l.readChar()
+	if c, ok := simpleEscapes[l.ch]; ok {
+		decoded.WriteByte(c)
+		l.readChar()
+		return ""
+	}
+
+	switch {
+	case isOctalDigit(l.ch):
+		value := 0
+		for i := 0; i < 3 && isOctalDigit(l.ch); i++ {
+			value = value*8 + int(l.ch-'0')
+			l.readChar()
+		}
+		return writeCodeUnit(decoded, value, wide, "八进制")
+	case l.ch == 'x':
+		l.readChar()
+		if !isHexDigit(l.ch) {
+			return "\\x 后缺少十六进制数字"
+		}
+		value := 0
+		overflow := false
+		for isHexDigit(l.ch) {
+			value = value*16 + hexValue(l.ch)
+			if value > utf8.MaxRune {
+				overflow = true
+			}
+			l.readChar()
+		}
+		if overflow {
+			return "十六进制转义序列超出范围"
+		}
+		return writeCodeUnit(decoded, value, wide, "十六进制")
+	case l.ch == 'u' || l.ch == 'U':
+		value, e := l.readUCN()
+		if e != "" {
+			return e
+		}
+		decoded.WriteRune(value)
+		return ""
+	case l.atEOF() || l.ch == '\n':
+		return "转义序列不完整"
+	default:
+		c := l.ch
+		decoded.WriteRune(c)
+		l.readChar()
+		return fmt.Sprintf("无效的转义序列: \\%c", c)
+	}
+}
+
+// 写入八进制/十六进制转义得到的码元，普通字面量限制在一个字节内
+func writeCodeUnit(decoded *strings.Builder, value int, wide bool, kind string) string {
+	if value <= 0xFF {
+		decoded.WriteByte(byte(value))
+		return ""
+	}
+	if wide && value <= utf8.MaxRune {
+		decoded.WriteRune(rune(value))
+		return ""
+	}
+	return fmt.Sprintf("%s转义序列超出范围", kind)
+}
+
+// C11 6.4.3：通用字符名不能表示代理区字符，也不能表示 U+00A0 以下除 $ @ ` 之外的字符
+func isValidUCN(value int) bool {
+	if value < 0xA0 {
+		return value == '$' || value == '@' || value == '`'
+	}
+	if value >= 0xD800 && value <= 0xDFFF {
+		return false
+	}
+	return value <= utf8.MaxRune
+}
+
+func hexValue(ch rune) int {
+	switch {
+	case ch >= '0' && ch <= '9':
+		return int(ch - '0')
+	case ch >= 'a' && ch <= 'f':
+		return int(ch-'a') + 10
+	default:
+		return int(ch-'A') + 10
+	}
+}
+
+// 判断当前位置是否为字符串常量的开始（含编码前缀）
+func (l *Lexer) atStringLiteral() bool {
+	if l.ch == '"' {
+		return true
+	}
+	n := l.literalPrefixLen()
+	return n > 0 && l.peekCharAt(n) == '"'
+}
+
+// 将相邻的字符串常量连接为一个 Token
+func (l *Lexer) concatStrings(tok Token) Token {
+	for {
+		saved := l.saveState()
+		if _, ok := l.skipWhitespaceAndComments(); !ok || !l.atStringLiteral() {
+			l.restoreState(saved)
+			return tok
+		}
+		next := l.scanToken()
+		tok.Decoded += next.Decoded
+		if tok.Error == "" {
+			tok.Error = next.Error
+		}
+		tok.Span.End = l.currentPosition()
+		tok.Value = l.input[tok.Span.Start.Offset:tok.Span.End.Offset]
+	}
+}
@@ -0,0 +1,113 @@
+package lexer
+
+import (
+	"slices"
+	"testing"
+)
+
+// 诊断代码列表
+func diagnosticCodes(l *Lexer) []DiagnosticCode {
+	var codes []DiagnosticCode
+	for _, d := range l.Diagnostics() {
+		codes = append(codes, d.Code)
+	}
+	return codes
+}
+
+func TestLiterals(t *testing.T) {
+	tests := []struct {
+		source  string
+		tokType TokenType
+		decoded string
+		codes   []DiagnosticCode
+	}{
+		// 简单转义、八进制与十六进制转义
+		{`"a\tb\n\\\"\?"`, STRING, "a\tb\n\\\"?", nil},
+		{`"\0\101\1012"`, STRING, "\x00AA2", nil},
+		{`"\x41\x7e"`, STRING, "A~", nil},
+		{`'\''`, CHAR, "'", nil},
+		{`"\377"`, STRING, "\xff", nil},
+		{`"\400"`, STRING, "", []DiagnosticCode{ErrInvalidEscape}},
+		{`"\x100"`, STRING, "", []DiagnosticCode{ErrInvalidEscape}},
+		{`L"\x100"`, STRING, "Ā", nil},
+		{`"\xg"`, STRING, "g", []DiagnosticCode{ErrInvalidEscape}},
+		{`"\q"`, STRING, "q", []DiagnosticCode{ErrInvalidEscape}},
+		{`"\x110000"`, STRING, "", []DiagnosticCode{ErrInvalidEscape}},
+
+		// 字面量中的通用字符名
+		{`"\u00e9\U0001F600"`, STRING, "é😀", nil},
+		{`u8"\u4e2d"`, STRING, "中", nil},
+		{`"\u12"`, STRING, "", []DiagnosticCode{ErrInvalidEscape}},
+		{`"\uD800"`, STRING, "", []DiagnosticCode{ErrInvalidEscape}},
+		{`"\u0041"`, STRING, "", []DiagnosticCode{ErrInvalidEscape}},
+		{`"\u0024"`, STRING, "$", nil},
+		{`L'\u00e9'`, CHAR, "é", nil},
+
+		// 字符常量
+		{`'a'`, CHAR, "a", nil},
+		{`''`, CHAR, "", []DiagnosticCode{ErrEmptyChar}},
+		{`'ab'`, CHAR, "ab", []DiagnosticCode{ErrMultiChar}},
+		{"'a", CHAR, "a", []DiagnosticCode{ErrUnterminatedChar}},
+		{"\"ab\nc", STRING, "ab", []DiagnosticCode{ErrUnterminatedString}},
+
+		// 续行与相邻字符串连接
+		{"\"ab\\\ncd\"", STRING, "abcd", nil},
+		{`"ab" /* */ "cd"`, STRING, "abcd", nil},
+
+		// 标识符中的通用字符名
+		{`caf\u00e9`, IDENT, "café", nil},
+		{`\u0031a`, IDENT, "a", []DiagnosticCode{ErrInvalidUCN}},
+	}
+	for _, tt := range tests {
+		tokens, l := lexAll(tt.source)
+		tok := tokens[0]
+		if tok.Type != tt.tokType || tok.Decoded != tt.decoded {
+			t.Errorf("%s: 得到 %s %q, 期望 %s %q", tt.source, tok.Type, tok.Decoded, tt.tokType, tt.decoded)
+		}
+		if codes := diagnosticCodes(l); !slices.Equal(codes, tt.codes) {
+			t.Errorf("%s: 诊断 %v, 期望 %v", tt.source, codes, tt.codes)
+		}
+		if (tok.Error != "") != (len(tt.codes) > 0) {
+			t.Errorf("%s: Token 的错误为 %q", tt.source, tok.Error)
+		}
+	}
+}
+
+// 每个错误的转义序列都单独报告，Token 只保留第一个错误
+func TestMultipleInvalidEscapes(t *testing.T) {
+	tokens, l := lexAll(`"\q\w" x`)
+	if got := diagnosticCodes(l); !slices.Equal(got, []DiagnosticCode{ErrInvalidEscape, ErrInvalidEscape}) {
+		t.Errorf("诊断 %v", got)
+	}
+	if tokens[0].Error != "无效的转义序列: \\q" || tokens[1].Value != "x" {
+		t.Errorf("得到 %v", tokens)
+	}
+}
+
+// 字符常量的值按码元计算，单个字节不经过 UTF-8 解码
+func TestCharValues(t *testing.T) {
+	tests := []struct {
+		source string
+		value  uint64
+		ctype  string
+	}{
+		{`'a'`, 'a', "int"},
+		{`'\xff'`, 0xFF, "int"},
+		{`'\0'`, 0, "int"},
+		{`'é'`, 0xE9, "int"},
+		{`L'\xff'`, 0xFF, "int"},
+		{`L'中'`, 0x4E2D, "int"},
+		{`u'\xff'`, 0xFF, "unsigned short"},
+		{`U'\U0001F600'`, 0x1F600, "unsigned int"},
+	}
+	for _, tt := range tests {
+		tokens, _ := lexAll(tt.source)
+		n := tokens[0].Number
+		if n == nil || n.Int != tt.value || n.CType != tt.ctype {
+			t.Errorf("%s: 值为 %+v, 期望 %#x (%s)", tt.source, n, tt.value, tt.ctype)
+		}
+	}
+	if tokens, _ := lexAll(`'ab'`); tokens[0].Number != nil {
+		t.Errorf("多字符常量的值为 %+v", tokens[0].Number)
+	}
+}
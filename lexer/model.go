@@ -4,63 +4,180 @@ package lexer
 type TokenType string
 
 const (
-	IDENT           TokenType = "IDENTIFIER"
-	NUMBER          TokenType = "NUMBER"
-	HEX             TokenType = "HEX_NUMBER"
-	OCTAL           TokenType = "OCTAL_NUMBER"
-	BINARY          TokenType = "BINARY_NUMBER"
-	FLOAT           TokenType = "FLOAT_NUMBER"
-	CHAR            TokenType = "CHAR_CONSTANT"
-	STRING          TokenType = "STRING_CONSTANT"
-	LPAREN          TokenType = "LPAREN"          // (
-	RPAREN          TokenType = "RPAREN"          // )
-	LBRACE          TokenType = "LBRACE"          // {
-	RBRACE          TokenType = "RBRACE"          // }
-	SEMICOLON       TokenType = "SEMICOLON"       // ;
-	ASSIGN          TokenType = "ASSIGN"          // =
-	PLUS            TokenType = "PLUS"            // +
-	MINUS           TokenType = "MINUS"           // -
-	ASTERISK        TokenType = "ASTERISK"        // *
-	SLASH           TokenType = "SLASH"           // /
-	LT              TokenType = "LT"              // <
-	GT              TokenType = "GT"              // >
-	LTE             TokenType = "LTE"             // <=
-	GTE             TokenType = "GTE"             // >=
-	EQ              TokenType = "EQ"              // ==
-	NEQ             TokenType = "NEQ"             // !=
-	AND             TokenType = "AND"             // &&
-	OR              TokenType = "OR"              // ||
-	NOT             TokenType = "NOT"             // !
-	PLUS_ASSIGN     TokenType = "PLUS_ASSIGN"     // +=
-	MINUS_ASSIGN    TokenType = "MINUS_ASSIGN"    // -=
-	ASTERISK_ASSIGN TokenType = "ASTERISK_ASSIGN" // *=
-	SLASH_ASSIGN    TokenType = "SLASH_ASSIGN"    // /=
-	UNKNOWN         TokenType = "UNKNOWN"
-	IF              TokenType = "IF"
-	ELSE            TokenType = "ELSE"
-	WHILE           TokenType = "WHILE"
-	DO              TokenType = "DO"
-	MAIN            TokenType = "MAIN"
-	INT             TokenType = "INT"
-	FLOAT_TYPE      TokenType = "FLOAT"
-	DOUBLE          TokenType = "DOUBLE"
-	RETURN          TokenType = "RETURN"
-	CONST           TokenType = "CONST"
-	VOID            TokenType = "VOID"
-	CONTINUE        TokenType = "CONTINUE"
-	BREAK           TokenType = "BREAK"
-	CHAR_TYPE       TokenType = "CHAR"
-	UNSIGNED        TokenType = "UNSIGNED"
-	ENUM            TokenType = "ENUM"
-	LONG            TokenType = "LONG"
-	SWITCH          TokenType = "SWITCH"
-	CASE            TokenType = "CASE"
-	AUTO            TokenType = "AUTO"
-	STATIC          TokenType = "STATIC"
+	IDENT            TokenType = "IDENTIFIER"
+	NUMBER           TokenType = "NUMBER"
+	HEX              TokenType = "HEX_NUMBER"
+	OCTAL            TokenType = "OCTAL_NUMBER"
+	BINARY           TokenType = "BINARY_NUMBER"
+	FLOAT            TokenType = "FLOAT_NUMBER"
+	CHAR             TokenType = "CHAR_CONSTANT"
+	STRING           TokenType = "STRING_CONSTANT"
+	LPAREN           TokenType = "LPAREN"           // (
+	RPAREN           TokenType = "RPAREN"           // )
+	LBRACE           TokenType = "LBRACE"           // {
+	RBRACE           TokenType = "RBRACE"           // }
+	LBRACKET         TokenType = "LBRACKET"         // [
+	RBRACKET         TokenType = "RBRACKET"         // ]
+	SEMICOLON        TokenType = "SEMICOLON"        // ;
+	COMMA            TokenType = "COMMA"            // ,
+	DOT              TokenType = "DOT"              // .
+	ARROW            TokenType = "ARROW"            // ->
+	ELLIPSIS         TokenType = "ELLIPSIS"         // ...
+	QUESTION         TokenType = "QUESTION"         // ?
+	COLON            TokenType = "COLON"            // :
+	HASH             TokenType = "HASH"             // #
+	HASH_HASH        TokenType = "HASH_HASH"        // ##
+	ASSIGN           TokenType = "ASSIGN"           // =
+	PLUS             TokenType = "PLUS"             // +
+	MINUS            TokenType = "MINUS"            // -
+	ASTERISK         TokenType = "ASTERISK"         // *
+	SLASH            TokenType = "SLASH"            // /
+	PERCENT          TokenType = "PERCENT"          // %
+	INCREMENT        TokenType = "INCREMENT"        // ++
+	DECREMENT        TokenType = "DECREMENT"        // --
+	LT               TokenType = "LT"               // <
+	GT               TokenType = "GT"               // >
+	LTE              TokenType = "LTE"              // <=
+	GTE              TokenType = "GTE"              // >=
+	EQ               TokenType = "EQ"               // ==
+	NEQ              TokenType = "NEQ"              // !=
+	AND              TokenType = "AND"              // &&
+	OR               TokenType = "OR"               // ||
+	NOT              TokenType = "NOT"              // !
+	AMPERSAND        TokenType = "AMPERSAND"        // &
+	PIPE             TokenType = "PIPE"             // |
+	CARET            TokenType = "CARET"            // ^
+	TILDE            TokenType = "TILDE"            // ~
+	SHL              TokenType = "SHL"              // <<
+	SHR              TokenType = "SHR"              // >>
+	PLUS_ASSIGN      TokenType = "PLUS_ASSIGN"      // +=
+	MINUS_ASSIGN     TokenType = "MINUS_ASSIGN"     // -=
+	ASTERISK_ASSIGN  TokenType = "ASTERISK_ASSIGN"  // *=
+	SLASH_ASSIGN     TokenType = "SLASH_ASSIGN"     // /=
+	PERCENT_ASSIGN   TokenType = "PERCENT_ASSIGN"   // %=
+	AMPERSAND_ASSIGN TokenType = "AMPERSAND_ASSIGN" // &=
+	PIPE_ASSIGN      TokenType = "PIPE_ASSIGN"      // |=
+	CARET_ASSIGN     TokenType = "CARET_ASSIGN"     // ^=
+	SHL_ASSIGN       TokenType = "SHL_ASSIGN"       // <<=
+	SHR_ASSIGN       TokenType = "SHR_ASSIGN"       // >>=
+	UNKNOWN          TokenType = "UNKNOWN"
+	EOF              TokenType = "EOF"
+	IF               TokenType = "IF"
+	ELSE             TokenType = "ELSE"
+	WHILE            TokenType = "WHILE"
+	DO               TokenType = "DO"
+	MAIN             TokenType = "MAIN"
+	INT              TokenType = "INT"
+	FLOAT_TYPE       TokenType = "FLOAT"
+	DOUBLE           TokenType = "DOUBLE"
+	RETURN           TokenType = "RETURN"
+	CONST            TokenType = "CONST"
+	VOID             TokenType = "VOID"
+	CONTINUE         TokenType = "CONTINUE"
+	BREAK            TokenType = "BREAK"
+	CHAR_TYPE        TokenType = "CHAR"
+	UNSIGNED         TokenType = "UNSIGNED"
+	ENUM             TokenType = "ENUM"
+	LONG             TokenType = "LONG"
+	SWITCH           TokenType = "SWITCH"
+	CASE             TokenType = "CASE"
+	AUTO             TokenType = "AUTO"
+	STATIC           TokenType = "STATIC"
+	FOR              TokenType = "FOR"
+	DEFAULT          TokenType = "DEFAULT"
+	GOTO             TokenType = "GOTO"
+	STRUCT           TokenType = "STRUCT"
+	UNION            TokenType = "UNION"
+	TYPEDEF          TokenType = "TYPEDEF"
+	SIZEOF           TokenType = "SIZEOF"
+	SHORT            TokenType = "SHORT"
+	SIGNED           TokenType = "SIGNED"
+	EXTERN           TokenType = "EXTERN"
+	REGISTER         TokenType = "REGISTER"
+	VOLATILE         TokenType = "VOLATILE"
+	INLINE           TokenType = "INLINE"
+	RESTRICT         TokenType = "RESTRICT"
+	BOOL             TokenType = "_BOOL"
+	COMPLEX          TokenType = "_COMPLEX"
+	IMAGINARY        TokenType = "_IMAGINARY"
+	ALIGNAS          TokenType = "_ALIGNAS"
+	ALIGNOF          TokenType = "_ALIGNOF"
+	ATOMIC           TokenType = "_ATOMIC"
+	GENERIC          TokenType = "_GENERIC"
+	NORETURN         TokenType = "_NORETURN"
+	STATIC_ASSERT    TokenType = "_STATIC_ASSERT"
+	THREAD_LOCAL     TokenType = "_THREAD_LOCAL"
 )
 
-// 保留字表
-var reservedWords = map[string]TokenType{
+// 语言方言，决定使用哪张保留字表以及允许哪些扩展
+type Dialect int
+
+const (
+	DialectC11    Dialect = iota // 默认方言
+	DialectC99                   // C99
+	DialectC89                   // C89/C90
+	DialectCourse                // 课程实验使用的 C 子集，main 视为保留字
+	DialectGNU11                 // C11 加 GNU 扩展
+)
+
+// C89 保留字表
+var c89Keywords = map[string]TokenType{
+	"auto":     AUTO,
+	"break":    BREAK,
+	"case":     CASE,
+	"char":     CHAR_TYPE,
+	"const":    CONST,
+	"continue": CONTINUE,
+	"default":  DEFAULT,
+	"do":       DO,
+	"double":   DOUBLE,
+	"else":     ELSE,
+	"enum":     ENUM,
+	"extern":   EXTERN,
+	"float":    FLOAT_TYPE,
+	"for":      FOR,
+	"goto":     GOTO,
+	"if":       IF,
+	"int":      INT,
+	"long":     LONG,
+	"register": REGISTER,
+	"return":   RETURN,
+	"short":    SHORT,
+	"signed":   SIGNED,
+	"sizeof":   SIZEOF,
+	"static":   STATIC,
+	"struct":   STRUCT,
+	"switch":   SWITCH,
+	"typedef":  TYPEDEF,
+	"union":    UNION,
+	"unsigned": UNSIGNED,
+	"void":     VOID,
+	"volatile": VOLATILE,
+	"while":    WHILE,
+}
+
+// C99 相对 C89 新增的保留字
+var c99Keywords = map[string]TokenType{
+	"inline":     INLINE,
+	"restrict":   RESTRICT,
+	"_Bool":      BOOL,
+	"_Complex":   COMPLEX,
+	"_Imaginary": IMAGINARY,
+}
+
+// C11 相对 C99 新增的保留字
+var c11Keywords = map[string]TokenType{
+	"_Alignas":       ALIGNAS,
+	"_Alignof":       ALIGNOF,
+	"_Atomic":        ATOMIC,
+	"_Generic":       GENERIC,
+	"_Noreturn":      NORETURN,
+	"_Static_assert": STATIC_ASSERT,
+	"_Thread_local":  THREAD_LOCAL,
+}
+
+// 课程 C 子集的保留字表
+var courseKeywords = map[string]TokenType{
 	"if":       IF,
 	"else":     ELSE,
 	"while":    WHILE,
@@ -82,24 +199,153 @@ var reservedWords = map[string]TokenType{
 	"case":     CASE,
 	"auto":     AUTO,
 	"static":   STATIC,
-	"+=":       PLUS_ASSIGN,
-	"-=":       MINUS_ASSIGN,
-	"*=":       ASTERISK_ASSIGN,
-	"/=":       SLASH_ASSIGN,
+}
+
+// 各方言对应的完整保留字表
+var keywordTables = map[Dialect]map[string]TokenType{
+	DialectC89:    c89Keywords,
+	DialectC99:    mergeKeywords(c89Keywords, c99Keywords),
+	DialectC11:    mergeKeywords(c89Keywords, c99Keywords, c11Keywords),
+	DialectCourse: courseKeywords,
+	DialectGNU11:  mergeKeywords(c89Keywords, c99Keywords, c11Keywords),
+}
+
+// 允许 0b 开头的二进制整数常量的方言，二进制常量是 GNU 扩展，不属于 C11
+var binaryLiteralDialects = map[Dialect]bool{
+	DialectGNU11: true,
+}
+
+func mergeKeywords(tables ...map[string]TokenType) map[string]TokenType {
+	result := make(map[string]TokenType)
+	for _, table := range tables {
+		for word, tokType := range table {
+			result[word] = tokType
+		}
+	}
+	return result
+}
+
+// C11 运算符与标点符号表（含二合字符 <: :> <% %> %: %:%:）
+var punctuators = map[string]TokenType{
+	"(":    LPAREN,
+	")":    RPAREN,
+	"{":    LBRACE,
+	"}":    RBRACE,
+	"[":    LBRACKET,
+	"]":    RBRACKET,
+	";":    SEMICOLON,
+	",":    COMMA,
+	".":    DOT,
+	"->":   ARROW,
+	"...":  ELLIPSIS,
+	"?":    QUESTION,
+	":":    COLON,
+	"#":    HASH,
+	"##":   HASH_HASH,
+	"=":    ASSIGN,
+	"+":    PLUS,
+	"-":    MINUS,
+	"*":    ASTERISK,
+	"/":    SLASH,
+	"%":    PERCENT,
+	"++":   INCREMENT,
+	"--":   DECREMENT,
+	"<":    LT,
+	">":    GT,
+	"<=":   LTE,
+	">=":   GTE,
+	"==":   EQ,
+	"!=":   NEQ,
+	"&&":   AND,
+	"||":   OR,
+	"!":    NOT,
+	"&":    AMPERSAND,
+	"|":    PIPE,
+	"^":    CARET,
+	"~":    TILDE,
+	"<<":   SHL,
+	">>":   SHR,
+	"+=":   PLUS_ASSIGN,
+	"-=":   MINUS_ASSIGN,
+	"*=":   ASTERISK_ASSIGN,
+	"/=":   SLASH_ASSIGN,
+	"%=":   PERCENT_ASSIGN,
+	"&=":   AMPERSAND_ASSIGN,
+	"|=":   PIPE_ASSIGN,
+	"^=":   CARET_ASSIGN,
+	"<<=":  SHL_ASSIGN,
+	">>=":  SHR_ASSIGN,
+	"<:":   LBRACKET,
+	":>":   RBRACKET,
+	"<%":   LBRACE,
+	"%>":   RBRACE,
+	"%:":   HASH,
+	"%:%:": HASH_HASH,
+}
+
+// 最长的标点符号长度
+const maxPunctuatorLen = 4
+
+// 源代码位置
+type Position struct {
+	File   string // 文件名，可为空
+	Line   int    // 行号，从1开始
+	Column int    // 列号，从1开始
+	Offset int    // 字节偏移，从0开始
+}
+
+// 源代码区间，End 指向最后一个字符之后的位置
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// 数值常量与字符常量的解析结果
+type NumberValue struct {
+	Int   uint64  // 整数常量的值
+	Float float64 // 浮点常量的值
+	CType string  // 推断出的 C 类型，如 "int"、"unsigned long"、"double"
 }
 
 // Token结构
 type Token struct {
-	Type  TokenType
-	Value string
-	Error string
+	Type    TokenType
+	Value   string       // 源代码中的原始拼写
+	Decoded string       // 字符/字符串常量处理转义序列后的内容；含通用字符名的标识符为解码后的名字
+	Number  *NumberValue // 数值常量与字符常量的值与类型，其他 Token 为 nil
+	Error   string
+	Span    Span
+
+	LineStart   bool // 是否为逻辑行的第一个 Token
+	SpaceBefore bool // 前面是否有空白或注释
+}
+
+// 词法分析器选项
+type Option func(*Lexer)
+
+// 词法分析器状态快照，用于向前试探后回退
+type lexerState struct {
+	position     int
+	readPosition int
+	ch           rune
+	line         int
+	column       int
+	atLineStart  bool
 }
 
 // Lexer结构
 type Lexer struct {
 	input        string
+	filename     string
+	dialect      Dialect
+	keywords     map[string]TokenType
 	position     int
 	readPosition int
-	ch           byte
+	ch           rune
+	line         int // 当前字符 ch 所在行
+	column       int // 当前字符 ch 所在列
+	noConcat     bool
+	atLineStart  bool // 自上一个 Token 以来是否遇到过换行
 	prevToken    *Token
+	diagnostics  []Diagnostic
 }
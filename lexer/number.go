@@ -0,0 +1,167 @@
+package lexer
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	intLiteralRegex   = regexp.MustCompile(`^(0[xX][0-9a-fA-F]+|0[bB][01]+|[0-9]+)([a-zA-Z_]*)$`)
+	intSuffixRegex    = regexp.MustCompile(`^(?:[uU](?:ll|LL|[lL])?|(?:ll|LL|[lL])[uU]?)?$`)
+	decimalFloatRegex = regexp.MustCompile(`^(?:(?:[0-9]*\.[0-9]+|[0-9]+\.)(?:[eE][+-]?[0-9]+)?|[0-9]+[eE][+-]?[0-9]+)([fFlL]?)$`)
+	hexFloatRegex     = regexp.MustCompile(`^0[xX](?:[0-9a-fA-F]*\.[0-9a-fA-F]+|[0-9a-fA-F]+\.?)[pP][+-]?[0-9]+([fFlL]?)$`)
+)
+
+// 整数类型的取值上限（按 LP64 模型）
+var intTypeMax = map[string]uint64{
+	"int":                math.MaxInt32,
+	"unsigned int":       math.MaxUint32,
+	"long":               math.MaxInt64,
+	"unsigned long":      math.MaxUint64,
+	"long long":          math.MaxInt64,
+	"unsigned long long": math.MaxUint64,
+}
+
+// 先按预处理数的规则读取完整拼写，再检查其是否为合法的 C 数值常量
+func (l *Lexer) readNumber() Token {
+	start := l.currentPosition()
+	for isDigit(l.ch) || l.isIdentContinue(l.ch) || l.atUCN() || l.ch == '.' ||
+		((l.ch == '+' || l.ch == '-') && isExponentChar(l.input[l.position-1])) {
+		l.readChar()
+	}
+	tok := parseNumber(l.input[start.Offset:l.position])
+	if tok.Type == BINARY && !binaryLiteralDialects[l.dialect] {
+		tok = Token{Type: UNKNOWN, Value: tok.Value, Error: fmt.Sprintf("二进制整数常量是 GNU 扩展，当前方言不支持: %s", tok.Value)}
+	}
+	if tok.Error != "" {
+		code := ErrNumberOutOfRange
+		if tok.Type == UNKNOWN {
+			code = ErrInvalidNumber
+		}
+		l.report(code, Span{Start: start, End: l.currentPosition()}, tok.Error)
+	}
+	return tok
+}
+
+func isExponentChar(ch byte) bool {
+	return ch == 'e' || ch == 'E' || ch == 'p' || ch == 'P'
+}
+
+func parseNumber(text string) Token {
+	isHex := strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0X")
+	if isHex && strings.ContainsAny(text, ".pP") {
+		return parseFloat(text, hexFloatRegex)
+	}
+	if !isHex && strings.ContainsAny(text, ".eE") {
+		return parseFloat(text, decimalFloatRegex)
+	}
+	return parseInteger(text)
+}
+
+func parseFloat(text string, regex *regexp.Regexp) Token {
+	matches := regex.FindStringSubmatch(text)
+	if matches == nil {
+		return Token{Type: UNKNOWN, Value: text, Error: fmt.Sprintf("无效的浮点数: %s", text)}
+	}
+	suffix := matches[1]
+	ctype := "double"
+	switch suffix {
+	case "f", "F":
+		ctype = "float"
+	case "l", "L":
+		ctype = "long double"
+	}
+
+	tok := Token{Type: FLOAT, Value: text}
+	value, err := strconv.ParseFloat(text[:len(text)-len(suffix)], 64)
+	if err != nil || (ctype == "float" && math.Abs(value) > math.MaxFloat32) {
+		tok.Error = fmt.Sprintf("浮点常量超出 %s 的范围: %s", ctype, text)
+	}
+	tok.Number = &NumberValue{Float: value, CType: ctype}
+	return tok
+}
+
+func parseInteger(text string) Token {
+	lower := strings.ToLower(text)
+	if strings.HasPrefix(lower, "0x") && (len(text) == 2 || !isHexDigit(rune(text[2]))) {
+		return Token{Type: UNKNOWN, Value: text, Error: fmt.Sprintf("无效的十六进制数: %s", text)}
+	}
+	if strings.HasPrefix(lower, "0b") && (len(text) == 2 || !isBinaryDigit(rune(text[2]))) {
+		return Token{Type: UNKNOWN, Value: text, Error: fmt.Sprintf("无效的二进制数: %s", text)}
+	}
+	matches := intLiteralRegex.FindStringSubmatch(text)
+	if matches == nil {
+		return Token{Type: UNKNOWN, Value: text, Error: fmt.Sprintf("无效的数值常量: %s", text)}
+	}
+	digits, suffix := matches[1], matches[2]
+	if !intSuffixRegex.MatchString(suffix) {
+		return Token{Type: UNKNOWN, Value: text, Error: fmt.Sprintf("无效的整数后缀 \"%s\": %s", suffix, text)}
+	}
+
+	tokType := NUMBER
+	base := 10
+	switch {
+	case len(digits) > 1 && (digits[1] == 'x' || digits[1] == 'X'):
+		tokType, base, digits = HEX, 16, digits[2:]
+	case len(digits) > 1 && (digits[1] == 'b' || digits[1] == 'B'):
+		tokType, base, digits = BINARY, 2, digits[2:]
+	case len(digits) > 1 && digits[0] == '0':
+		tokType, base, digits = OCTAL, 8, digits[1:]
+		if strings.ContainsAny(digits, "89") {
+			return Token{Type: UNKNOWN, Value: text, Error: fmt.Sprintf("无效的八进制数: %s", text)}
+		}
+	}
+
+	tok := Token{Type: tokType, Value: text}
+	value, err := strconv.ParseUint(digits, base, 64)
+	if err != nil {
+		tok.Error = fmt.Sprintf("整数常量超出范围: %s", text)
+		tok.Number = &NumberValue{Int: math.MaxUint64, CType: "unsigned long long"}
+		return tok
+	}
+	ctype, ok := inferIntType(value, base == 10, suffix)
+	if !ok {
+		tok.Error = fmt.Sprintf("整数常量超出 %s 的范围: %s", ctype, text)
+	}
+	tok.Number = &NumberValue{Int: value, CType: ctype}
+	return tok
+}
+
+// 按 C11 6.4.4.1 的候选类型列表推断整数常量的类型，返回能容纳该值的第一个类型
+func inferIntType(value uint64, decimal bool, suffix string) (string, bool) {
+	lower := strings.ToLower(suffix)
+	unsigned := strings.Contains(lower, "u")
+	longness := strings.Count(lower, "l")
+
+	var candidates []string
+	switch {
+	case unsigned && longness == 0:
+		candidates = []string{"unsigned int", "unsigned long", "unsigned long long"}
+	case unsigned && longness == 1:
+		candidates = []string{"unsigned long", "unsigned long long"}
+	case unsigned:
+		candidates = []string{"unsigned long long"}
+	case decimal && longness == 0:
+		candidates = []string{"int", "long", "long long"}
+	case decimal && longness == 1:
+		candidates = []string{"long", "long long"}
+	case decimal:
+		candidates = []string{"long long"}
+	case longness == 0:
+		candidates = []string{"int", "unsigned int", "long", "unsigned long", "long long", "unsigned long long"}
+	case longness == 1:
+		candidates = []string{"long", "unsigned long", "long long", "unsigned long long"}
+	default:
+		candidates = []string{"long long", "unsigned long long"}
+	}
+
+	for _, ctype := range candidates {
+		if value <= intTypeMax[ctype] {
+			return ctype, true
+		}
+	}
+	return candidates[len(candidates)-1], false
+}
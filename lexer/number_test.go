@@ -0,0 +1,170 @@
+package lexer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIntegerLiterals(t *testing.T) {
+	tests := []struct {
+		source  string
+		tokType TokenType
+		value   uint64
+		ctype   string
+		code    DiagnosticCode
+	}{
+		{"0", NUMBER, 0, "int", ""},
+		{"42", NUMBER, 42, "int", ""},
+		{"017", OCTAL, 15, "int", ""},
+		{"0x1F", HEX, 31, "int", ""},
+		{"10u", NUMBER, 10, "unsigned int", ""},
+		{"10l", NUMBER, 10, "long", ""},
+		{"10LL", NUMBER, 10, "long long", ""},
+		{"10uL", NUMBER, 10, "unsigned long", ""},
+		{"10llu", NUMBER, 10, "unsigned long long", ""},
+
+		// 十进制常量不会成为无符号类型，八进制与十六进制常量可以
+		{"2147483647", NUMBER, 2147483647, "int", ""},
+		{"2147483648", NUMBER, 2147483648, "long", ""},
+		{"0x7FFFFFFF", HEX, 0x7FFFFFFF, "int", ""},
+		{"0x80000000", HEX, 0x80000000, "unsigned int", ""},
+		{"020000000000", OCTAL, 0x80000000, "unsigned int", ""},
+		{"0x100000000", HEX, 0x100000000, "long", ""},
+		{"0xFFFFFFFFFFFFFFFF", HEX, 0xFFFFFFFFFFFFFFFF, "unsigned long", ""},
+		{"0xFFFFFFFFFFFFFFFFll", HEX, 0xFFFFFFFFFFFFFFFF, "unsigned long long", ""},
+		{"4294967295u", NUMBER, 4294967295, "unsigned int", ""},
+		{"4294967296u", NUMBER, 4294967296, "unsigned long", ""},
+
+		// 超出范围
+		{"9223372036854775808", NUMBER, 9223372036854775808, "long long", ErrNumberOutOfRange},
+		{"18446744073709551616", NUMBER, 18446744073709551615, "unsigned long long", ErrNumberOutOfRange},
+
+		// 无效的常量
+		{"08", UNKNOWN, 0, "", ErrInvalidNumber},
+		{"0x", UNKNOWN, 0, "", ErrInvalidNumber},
+		{"0b2", UNKNOWN, 0, "", ErrInvalidNumber},
+		{"0b101", UNKNOWN, 0, "", ErrInvalidNumber},
+		{"10lul", UNKNOWN, 0, "", ErrInvalidNumber},
+		{"10uu", UNKNOWN, 0, "", ErrInvalidNumber},
+		{"10lL", UNKNOWN, 0, "", ErrInvalidNumber},
+		{"0o17", UNKNOWN, 0, "", ErrInvalidNumber},
+		{"123abc", UNKNOWN, 0, "", ErrInvalidNumber},
+	}
+	for _, tt := range tests {
+		checkNumber(t, tt.source, tt.tokType, tt.ctype, tt.code, func(n *NumberValue) bool { return n.Int == tt.value })
+	}
+}
+
+// 二进制常量只在 GNU 方言中可用
+func TestBinaryLiterals(t *testing.T) {
+	tests := []struct {
+		source  string
+		tokType TokenType
+		value   uint64
+		ctype   string
+		code    DiagnosticCode
+	}{
+		{"0b101", BINARY, 5, "int", ""},
+		{"0B1u", BINARY, 1, "unsigned int", ""},
+		{"0b10000000000000000000000000000000", BINARY, 0x80000000, "unsigned int", ""},
+		{"0b2", UNKNOWN, 0, "", ErrInvalidNumber},
+		{"0b", UNKNOWN, 0, "", ErrInvalidNumber},
+	}
+	for _, tt := range tests {
+		tokens, l := lexAll(tt.source, WithDialect(DialectGNU11))
+		tok := tokens[0]
+		if len(tokens) != 2 || tok.Type != tt.tokType || !slices.Equal(diagnosticCodes(l), codes(tt.code)) {
+			t.Errorf("%s: 得到 %v, 诊断 %v", tt.source, tokens, diagnosticCodes(l))
+			continue
+		}
+		if tok.Type == BINARY && (tok.Number.Int != tt.value || tok.Number.CType != tt.ctype) {
+			t.Errorf("%s: 值为 %d (%s), 期望 %d (%s)", tt.source, tok.Number.Int, tok.Number.CType, tt.value, tt.ctype)
+		}
+	}
+	for _, dialect := range []Dialect{DialectC11, DialectC99, DialectC89, DialectCourse} {
+		tokens, l := lexAll("0b101", WithDialect(dialect))
+		if tokens[0].Type != UNKNOWN || !slices.Equal(diagnosticCodes(l), codes(ErrInvalidNumber)) {
+			t.Errorf("方言 %d 接受了二进制常量: %v", dialect, tokens)
+		}
+	}
+}
+
+func codes(code DiagnosticCode) []DiagnosticCode {
+	if code == "" {
+		return nil
+	}
+	return []DiagnosticCode{code}
+}
+
+func TestFloatLiterals(t *testing.T) {
+	tests := []struct {
+		source string
+		value  float64
+		ctype  string
+		code   DiagnosticCode
+	}{
+		{"1.5", 1.5, "double", ""},
+		{".5", 0.5, "double", ""},
+		{"1.", 1, "double", ""},
+		{"1e3", 1000, "double", ""},
+		{"1.5e-2", 0.015, "double", ""},
+		{"2.5f", 2.5, "float", ""},
+		{"2.5L", 2.5, "long double", ""},
+		{"0x1.8p3", 12, "double", ""},
+		{"0x.8p1f", 1, "float", ""},
+		{"0x10p-4", 1, "double", ""},
+		{"1e40f", 1e40, "float", ErrNumberOutOfRange},
+		{"1e400", 0, "", ErrNumberOutOfRange},
+		{"0x1.8", 0, "", ErrInvalidNumber},
+		{"1.5u", 0, "", ErrInvalidNumber},
+		{"1.5ff", 0, "", ErrInvalidNumber},
+	}
+	for _, tt := range tests {
+		tokType := FLOAT
+		if tt.code == ErrInvalidNumber {
+			tokType = UNKNOWN
+		}
+		checkNumber(t, tt.source, tokType, tt.ctype, tt.code, func(n *NumberValue) bool { return tt.ctype == "" || n.Float == tt.value })
+	}
+}
+
+func checkNumber(t *testing.T, source string, tokType TokenType, ctype string, code DiagnosticCode, valueOK func(*NumberValue) bool) {
+	t.Helper()
+	tokens, l := lexAll(source)
+	tok := tokens[0]
+	if len(tokens) != 2 || tok.Type != tokType || tok.Value != source {
+		t.Errorf("%s: 得到 %v, 期望一个 %s", source, tokens, tokType)
+		return
+	}
+	if got, want := diagnosticCodes(l), codes(code); !slices.Equal(got, want) {
+		t.Errorf("%s: 诊断 %v, 期望 %v", source, got, want)
+	}
+	if tokType == UNKNOWN {
+		return
+	}
+	if tok.Number == nil {
+		t.Errorf("%s: 没有数值", source)
+		return
+	}
+	if ctype != "" && tok.Number.CType != ctype {
+		t.Errorf("%s: 类型为 %s, 期望 %s", source, tok.Number.CType, ctype)
+	}
+	if !valueOK(tok.Number) {
+		t.Errorf("%s: 数值为 %+v", source, *tok.Number)
+	}
+}
+
+// 减号不属于数值常量，指数字母后的一个正负号属于
+func TestNumberBoundaries(t *testing.T) {
+	tokens, l := lexAll("1-2 1e-2+x 1e+-3")
+	var values []string
+	for _, tok := range tokens[:len(tokens)-1] {
+		values = append(values, tok.Value)
+	}
+	if want := []string{"1", "-", "2", "1e-2", "+", "x", "1e+", "-", "3"}; !slices.Equal(values, want) {
+		t.Errorf("得到 %q, 期望 %q", values, want)
+	}
+	if codes := diagnosticCodes(l); !slices.Equal(codes, []DiagnosticCode{ErrInvalidNumber}) {
+		t.Errorf("诊断 %v", codes)
+	}
+}
@@ -0,0 +1,118 @@
+package lexer
+
+// Token 的来源，Lexer 与预处理器的输出都可以作为来源
+type TokenSource interface {
+	NextToken() Token
+}
+
+// 带缓冲的 Token 流，支持任意长度的向前查看与标记回溯
+type TokenStream struct {
+	source TokenSource
+	buf    []Token // 已读取但尚未丢弃的 Token
+	base   int     // buf[0] 在整个 Token 序列中的下标
+	pos    int     // 下一个 Token 在整个 Token 序列中的下标
+	marks  []int   // 尚未释放的标记，按设置顺序排列
+	eof    *Token  // 来源结束后重复返回的 EOF Token
+}
+
+// 从 Token 来源创建 Token 流
+func NewTokenStream(source TokenSource) *TokenStream {
+	return &TokenStream{source: source}
+}
+
+// 从已完成词法分析的 Token 切片创建 Token 流，切片末尾没有 EOF 时自动补上
+func NewSliceStream(tokens []Token) *TokenStream {
+	return NewTokenStream(&sliceSource{tokens: tokens})
+}
+
+type sliceSource struct {
+	tokens []Token
+	next   int
+}
+
+func (s *sliceSource) NextToken() Token {
+	if s.next < len(s.tokens) {
+		tok := s.tokens[s.next]
+		s.next++
+		return tok
+	}
+	tok := Token{Type: EOF}
+	if len(s.tokens) > 0 {
+		end := s.tokens[len(s.tokens)-1].Span.End
+		tok.Span = Span{Start: end, End: end}
+	}
+	return tok
+}
+
+// 读取并消耗下一个 Token
+func (s *TokenStream) Next() Token {
+	tok := s.Peek(1)
+	if tok.Type != EOF {
+		s.pos++
+		s.discard()
+	}
+	return tok
+}
+
+// 查看之后第 n 个 Token 而不消耗，Peek(1) 为下一个 Token。超出输入末尾时返回 EOF
+func (s *TokenStream) Peek(n int) Token {
+	if n < 1 {
+		panic("TokenStream.Peek: n 必须大于 0")
+	}
+	index := s.pos - s.base + n - 1
+	for len(s.buf) <= index {
+		if s.eof != nil {
+			return *s.eof
+		}
+		tok := s.source.NextToken()
+		if tok.Type == EOF {
+			s.eof = &tok
+			return tok
+		}
+		s.buf = append(s.buf, tok)
+	}
+	return s.buf[index]
+}
+
+// 下一个 Token 的类型是否为 tokenType
+func (s *TokenStream) At(tokenType TokenType) bool {
+	return s.Peek(1).Type == tokenType
+}
+
+// 标记当前位置，之后可以用 Reset 回到这里，或用 Release 放弃标记
+func (s *TokenStream) Mark() int {
+	s.marks = append(s.marks, s.pos)
+	return s.pos
+}
+
+// 回到标记 mark 处，并释放该标记及其后设置的标记
+func (s *TokenStream) Reset(mark int) {
+	s.removeMark(mark)
+	s.pos = mark
+	s.discard()
+}
+
+// 释放标记 mark 及其后设置的标记，不改变当前位置
+func (s *TokenStream) Release(mark int) {
+	s.removeMark(mark)
+	s.discard()
+}
+
+func (s *TokenStream) removeMark(mark int) {
+	for i := len(s.marks) - 1; i >= 0; i-- {
+		if s.marks[i] == mark {
+			s.marks = s.marks[:i]
+			return
+		}
+	}
+	panic("TokenStream: 无效的标记")
+}
+
+// 没有标记时丢弃已消耗的 Token
+func (s *TokenStream) discard() {
+	if len(s.marks) > 0 || s.pos == s.base {
+		return
+	}
+	s.buf = s.buf[s.pos-s.base:]
+	s.base = s.pos
+}
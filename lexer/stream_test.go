@@ -0,0 +1,104 @@
+package lexer
+
+import (
+	"slices"
+	"testing"
+)
+
+// 记录读取次数的 Token 来源
+type countingSource struct {
+	lexer *Lexer
+	reads int
+}
+
+func (s *countingSource) NextToken() Token {
+	s.reads++
+	return s.lexer.NextToken()
+}
+
+func values(tokens ...Token) []string {
+	var result []string
+	for _, tok := range tokens {
+		result = append(result, tok.Value)
+	}
+	return result
+}
+
+func TestTokenStreamPeek(t *testing.T) {
+	source := &countingSource{lexer: NewLexer("a b c")}
+	s := NewTokenStream(source)
+	if got := values(s.Peek(3), s.Peek(1), s.Peek(2)); !slices.Equal(got, []string{"c", "a", "b"}) {
+		t.Errorf("Peek 得到 %q", got)
+	}
+	if source.reads != 3 {
+		t.Errorf("读取了 %d 个 Token, 期望 3", source.reads)
+	}
+	if got := values(s.Next(), s.Next()); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Next 得到 %q", got)
+	}
+	if !s.At(IDENT) || s.Peek(2).Type != EOF || s.Peek(10).Type != EOF {
+		t.Error("超出输入末尾时没有返回 EOF")
+	}
+	s.Next()
+	for i := 0; i < 3; i++ {
+		if tok := s.Next(); tok.Type != EOF {
+			t.Errorf("输入结束后得到 %v", tok)
+		}
+	}
+	if source.reads != 4 {
+		t.Errorf("读取了 %d 个 Token, 期望 4", source.reads)
+	}
+}
+
+func TestTokenStreamMarkReset(t *testing.T) {
+	s := NewTokenStream(NewLexer("a b c d"))
+	outer := s.Mark()
+	s.Next()
+	inner := s.Mark()
+	s.Next()
+	s.Next()
+	s.Reset(inner)
+	if got := s.Next().Value; got != "b" {
+		t.Errorf("回到内层标记后得到 %s, 期望 b", got)
+	}
+	s.Reset(outer)
+	if got := values(s.Next(), s.Next(), s.Next(), s.Next()); !slices.Equal(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("回到外层标记后得到 %q", got)
+	}
+
+	// 释放外层标记时一并释放内层标记，之后不能再回到内层标记
+	s = NewTokenStream(NewLexer("a b c"))
+	outer = s.Mark()
+	s.Next()
+	inner = s.Mark()
+	s.Next()
+	s.Release(outer)
+	if len(s.buf) != 0 || s.Peek(1).Value != "c" {
+		t.Errorf("释放标记后缓冲区为 %v", s.buf)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("回到已释放的标记没有 panic")
+		}
+	}()
+	s.Reset(inner)
+}
+
+func TestSliceStream(t *testing.T) {
+	tokens, _ := lexAll("x = 1;")
+	s := NewSliceStream(tokens[:len(tokens)-1])
+	var got []Token
+	for !s.At(EOF) {
+		got = append(got, s.Next())
+	}
+	if !slices.Equal(values(got...), []string{"x", "=", "1", ";"}) {
+		t.Errorf("得到 %q", values(got...))
+	}
+	eof := s.Next()
+	if eof.Type != EOF || eof.Span.Start != tokens[3].Span.End {
+		t.Errorf("补上的 EOF 为 %+v", eof)
+	}
+	if tok := NewSliceStream(nil).Next(); tok.Type != EOF {
+		t.Errorf("空切片得到 %v", tok)
+	}
+}
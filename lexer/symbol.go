@@ -0,0 +1,36 @@
+package lexer
+
+// Token 在 LL 与 LR 分析器的文法中对应的终结符：标识符为 id，各种数值常量为 num，
+// 字符与字符串常量为 char 与 string，输入结束为 $，关键字使用原始拼写。
+// 标点符号按类型对应拼写，二合字符 <: :> <% %> %: %:%: 与对应的普通拼写是同一个终结符
+func (tok Token) GrammarSymbol() string {
+	switch tok.Type {
+	case IDENT:
+		return "id"
+	case NUMBER, HEX, OCTAL, BINARY, FLOAT:
+		return "num"
+	case CHAR:
+		return "char"
+	case STRING:
+		return "string"
+	case EOF:
+		return "$"
+	default:
+		if spelling, ok := punctuatorSpellings[tok.Type]; ok {
+			return spelling
+		}
+		return tok.Value
+	}
+}
+
+// 标点符号类型对应的普通拼写
+var punctuatorSpellings = func() map[TokenType]string {
+	digraphs := map[string]bool{"<:": true, ":>": true, "<%": true, "%>": true, "%:": true, "%:%:": true}
+	result := make(map[TokenType]string, len(punctuators))
+	for spelling, tokType := range punctuators {
+		if !digraphs[spelling] {
+			result[tokType] = spelling
+		}
+	}
+	return result
+}()
@@ -0,0 +1,123 @@
+package lexer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 标识符的首字符：ASCII 字母、下划线，以及（C99 起）UAX #31 中的 XID_Start 字符
+func (l *Lexer) isIdentStart(ch rune) bool {
+	if ch < utf8.RuneSelf {
+		return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+	}
+	return l.dialect != DialectC89 && isXIDStart(ch)
+}
+
+// 标识符的后续字符：首字符、数字，以及（C99 起）UAX #31 中的 XID_Continue 字符
+func (l *Lexer) isIdentContinue(ch rune) bool {
+	if ch < utf8.RuneSelf {
+		return l.isIdentStart(ch) || isDigit(ch)
+	}
+	return l.dialect != DialectC89 && isXIDContinue(ch)
+}
+
+// UAX #31 的 XID_Start：字母、字母型数字以及兼容用的 Other_ID_Start，排除模式语法字符
+func isXIDStart(ch rune) bool {
+	return unicode.In(ch, unicode.L, unicode.Nl, unicode.Other_ID_Start) &&
+		!unicode.In(ch, unicode.Pattern_Syntax, unicode.Pattern_White_Space)
+}
+
+// UAX #31 的 XID_Continue：在 XID_Start 基础上加入组合标记、数字与连接标点
+func isXIDContinue(ch rune) bool {
+	return isXIDStart(ch) ||
+		unicode.In(ch, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue) &&
+			!unicode.In(ch, unicode.Pattern_Syntax, unicode.Pattern_White_Space)
+}
+
+// 当前位置是否为 \u 或 \U 开头的通用字符名（十六进制数字位数足够）
+func (l *Lexer) atUCN() bool {
+	if l.ch != '\\' || l.dialect == DialectC89 {
+		return false
+	}
+	digits := 0
+	switch l.peekChar() {
+	case 'u':
+		digits = 4
+	case 'U':
+		digits = 8
+	default:
+		return false
+	}
+	for i := 2; i <= digits+1; i++ {
+		if !isHexDigit(l.peekCharAt(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// 读取 \u 或 \U 之后的十六进制数字，当前字符为 u 或 U。返回字符和错误信息
+func (l *Lexer) readUCN() (rune, string) {
+	digits := 4
+	if l.ch == 'U' {
+		digits = 8
+	}
+	l.readChar()
+	value := 0
+	for i := 0; i < digits; i++ {
+		if !isHexDigit(l.ch) {
+			return 0, fmt.Sprintf("通用字符名需要%d位十六进制数字", digits)
+		}
+		value = value*16 + hexValue(l.ch)
+		l.readChar()
+	}
+	if !isValidUCN(value) {
+		return 0, fmt.Sprintf("无效的通用字符名: U+%04X", value)
+	}
+	return rune(value), ""
+}
+
+// 读取标识符或关键字。标识符中可以出现通用字符名，此时 Decoded 为解码后的名字
+func (l *Lexer) readIdentifier() Token {
+	start := l.currentPosition()
+	var name strings.Builder
+	hasUCN := false
+	errMsg := ""
+	for {
+		if l.atUCN() {
+			ucnStart := l.currentPosition()
+			hasUCN = true
+			l.readChar()
+			ch, e := l.readUCN()
+			first := name.Len() == 0
+			if e == "" && (!l.isIdentContinue(ch) || (first && !l.isIdentStart(ch))) {
+				e = fmt.Sprintf("通用字符名 U+%04X 不能出现在标识符中", ch)
+			}
+			if e != "" {
+				l.report(ErrInvalidUCN, Span{Start: ucnStart, End: l.currentPosition()}, e)
+				if errMsg == "" {
+					errMsg = e
+				}
+				continue
+			}
+			name.WriteRune(ch)
+			continue
+		}
+		if !l.isIdentContinue(l.ch) {
+			break
+		}
+		name.WriteRune(l.ch)
+		l.readChar()
+	}
+
+	value := l.input[start.Offset:l.position]
+	tok := Token{Type: IDENT, Value: value, Error: errMsg}
+	if hasUCN {
+		tok.Decoded = name.String()
+	} else if tokType, ok := l.keywords[value]; ok {
+		tok.Type = tokType
+	}
+	return tok
+}
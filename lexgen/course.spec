@@ -0,0 +1,66 @@
+# 课程 C 子集的词法说明，格式为 "名称 优先级 正则表达式"
+# 名称与 lexer 包中的 TokenType 一致，名称为 - 的规则匹配后丢弃
+# 长度相同的匹配中优先级高者胜出，因此关键字的优先级高于标识符
+
+# 空白与注释
+-                0   [ \t\r\n]+
+-                0   //[^\n]*
+-                0   /\*([^*]|\*+[^*/])*\*+/
+
+# 关键字
+IF               10  if
+ELSE             10  else
+WHILE            10  while
+DO               10  do
+MAIN             10  main
+INT              10  int
+FLOAT            10  float
+DOUBLE           10  double
+CHAR             10  char
+VOID             10  void
+RETURN           10  return
+CONST            10  const
+CONTINUE         10  continue
+BREAK            10  break
+SWITCH           10  switch
+CASE             10  case
+
+# 标识符与常量
+IDENTIFIER       1   [a-zA-Z_][a-zA-Z0-9_]*
+NUMBER           1   [1-9][0-9]*|0
+OCTAL_NUMBER     1   0[0-7]+
+HEX_NUMBER       1   0[xX][0-9a-fA-F]+
+FLOAT_NUMBER     1   ([0-9]+\.[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?|[0-9]+[eE][+-]?[0-9]+
+CHAR_CONSTANT    1   '([^'\\\n]|\\.)+'
+STRING_CONSTANT  1   "([^"\\\n]|\\.)*"
+
+# 运算符与标点符号
+LPAREN           1   \(
+RPAREN           1   \)
+LBRACE           1   {
+RBRACE           1   }
+LBRACKET         1   \[
+RBRACKET         1   ]
+SEMICOLON        1   ;
+COMMA            1   ,
+ASSIGN           1   =
+PLUS             1   \+
+MINUS            1   -
+ASTERISK         1   \*
+SLASH            1   /
+PERCENT          1   %
+INCREMENT        1   \+\+
+DECREMENT        1   --
+LT               1   <
+GT               1   >
+LTE              1   <=
+GTE              1   >=
+EQ               1   ==
+NEQ              1   !=
+AND              1   &&
+OR               1   \|\|
+NOT              1   !
+PLUS_ASSIGN      1   \+=
+MINUS_ASSIGN     1   -=
+ASTERISK_ASSIGN  1   \*=
+SLASH_ASSIGN     1   /=
@@ -0,0 +1,264 @@
+package lexgen
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 由词法规则构建最小化的 DFA
+func Build(rules []Rule) (*DFA, error) {
+	nfa, err := BuildNFA(rules)
+	if err != nil {
+		return nil, err
+	}
+	return nfa.ToDFA(rules).Minimize(), nil
+}
+
+// 计算状态集合的 ε 闭包，结果按状态号排序
+func (n *NFA) epsilonClosure(states []int) []int {
+	visited := make(map[int]bool)
+	stack := append([]int(nil), states...)
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if visited[s] {
+			continue
+		}
+		visited[s] = true
+		stack = append(stack, n.states[s].eps...)
+	}
+	result := make([]int, 0, len(visited))
+	for s := range visited {
+		result = append(result, s)
+	}
+	sort.Ints(result)
+	return result
+}
+
+func stateSetKey(states []int) string {
+	parts := make([]string, len(states))
+	for i, s := range states {
+		parts[i] = strconv.Itoa(s)
+	}
+	return strings.Join(parts, ",")
+}
+
+// 子集构造法：NFA 转换为 DFA
+func (n *NFA) ToDFA(rules []Rule) *DFA {
+	d := &DFA{Rules: rules}
+	index := make(map[string]int)
+	var sets [][]int
+
+	addState := func(set []int) int {
+		key := stateSetKey(set)
+		if i, ok := index[key]; ok {
+			return i
+		}
+		index[key] = len(sets)
+		sets = append(sets, set)
+
+		// 接受的规则：优先级最高者，相同时取先出现的规则
+		accept := -1
+		for _, s := range set {
+			r := n.states[s].accept
+			if r < 0 {
+				continue
+			}
+			if accept < 0 || rules[r].Priority > rules[accept].Priority ||
+				(rules[r].Priority == rules[accept].Priority && r < accept) {
+				accept = r
+			}
+		}
+		var trans [256]int
+		for c := range trans {
+			trans[c] = -1
+		}
+		d.Trans = append(d.Trans, trans)
+		d.Accept = append(d.Accept, accept)
+		return len(sets) - 1
+	}
+
+	d.Start = addState(n.epsilonClosure([]int{n.start}))
+	for i := 0; i < len(sets); i++ {
+		for c := 0; c < 256; c++ {
+			var moved []int
+			for _, s := range sets[i] {
+				st := n.states[s]
+				if st.next >= 0 && st.set.has(byte(c)) {
+					moved = append(moved, st.next)
+				}
+			}
+			if len(moved) == 0 {
+				continue
+			}
+			d.Trans[i][c] = addState(n.epsilonClosure(moved))
+		}
+	}
+	return d
+}
+
+// 返回 DFA 的状态数
+func (d *DFA) StateCount() int {
+	return len(d.Trans)
+}
+
+// 用划分细化法最小化 DFA：接受不同规则的状态初始即属于不同的块
+func (d *DFA) Minimize() *DFA {
+	block := make([]int, len(d.Trans))
+	for i, accept := range d.Accept {
+		block[i] = accept + 1
+	}
+
+	for {
+		// 以 (当前块, 各字符转移到的块) 作为签名重新划分
+		signatures := make(map[string]int)
+		newBlock := make([]int, len(d.Trans))
+		for s := range d.Trans {
+			var sig strings.Builder
+			sig.WriteString(strconv.Itoa(block[s]))
+			for c := 0; c < 256; c++ {
+				sig.WriteByte(',')
+				if t := d.Trans[s][c]; t >= 0 {
+					sig.WriteString(strconv.Itoa(block[t]))
+				}
+			}
+			key := sig.String()
+			if _, ok := signatures[key]; !ok {
+				signatures[key] = len(signatures)
+			}
+			newBlock[s] = signatures[key]
+		}
+		stable := len(signatures) == countBlocks(block)
+		block = newBlock
+		if stable {
+			break
+		}
+	}
+
+	// 按原状态顺序为每个块编号，保证开始状态的块编号最小
+	number := make(map[int]int)
+	order := []int{d.Start}
+	for s := range d.Trans {
+		order = append(order, s)
+	}
+	for _, s := range order {
+		if _, ok := number[block[s]]; !ok {
+			number[block[s]] = len(number)
+		}
+	}
+
+	m := &DFA{Rules: d.Rules, Start: number[block[d.Start]]}
+	m.Trans = make([][256]int, len(number))
+	m.Accept = make([]int, len(number))
+	for s := range d.Trans {
+		b := number[block[s]]
+		m.Accept[b] = d.Accept[s]
+		for c := 0; c < 256; c++ {
+			if t := d.Trans[s][c]; t >= 0 {
+				m.Trans[b][c] = number[block[t]]
+			} else {
+				m.Trans[b][c] = -1
+			}
+		}
+	}
+	return m
+}
+
+func countBlocks(block []int) int {
+	seen := make(map[int]bool)
+	for _, b := range block {
+		seen[b] = true
+	}
+	return len(seen)
+}
+
+// 将 DFA 输出为 .dot 文件，接受状态为双圈并标注规则名
+func (d *DFA) PrintDOT(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fmt.Fprintln(file, "digraph DFA {")
+	fmt.Fprintln(file, "    rankdir=LR;")
+	fmt.Fprintln(file, "    node [shape=circle];")
+	fmt.Fprintln(file, "    start [shape=point];")
+	fmt.Fprintf(file, "    start -> D%d;\n", d.Start)
+
+	for s, accept := range d.Accept {
+		if accept >= 0 {
+			fmt.Fprintf(file, "    D%d [shape=doublecircle, label=\"D%d\\n%s\"];\n", s, s, dotEscape(d.Rules[accept].Name))
+		}
+	}
+
+	// 同一对状态之间的字符合并为一条边，用范围表示
+	for s := range d.Trans {
+		targets := make(map[int][]byte)
+		var order []int
+		for c := 0; c < 256; c++ {
+			t := d.Trans[s][c]
+			if t < 0 {
+				continue
+			}
+			if _, ok := targets[t]; !ok {
+				order = append(order, t)
+			}
+			targets[t] = append(targets[t], byte(c))
+		}
+		for _, t := range order {
+			fmt.Fprintf(file, "    D%d -> D%d [label=\"%s\"];\n", s, t, dotEscape(describeBytes(targets[t])))
+		}
+	}
+
+	fmt.Fprintln(file, "}")
+	return nil
+}
+
+// 将有序字节列表描述为字符范围，如 "a-z_"
+func describeBytes(bytes []byte) string {
+	var sb strings.Builder
+	if len(bytes) > 128 {
+		// 大多数字节都可转移时，描述其补集更易读
+		present := make(map[byte]bool)
+		for _, b := range bytes {
+			present[b] = true
+		}
+		var missing []byte
+		for c := 0; c < 256; c++ {
+			if !present[byte(c)] {
+				missing = append(missing, byte(c))
+			}
+		}
+		return "[^" + describeBytes(missing) + "]"
+	}
+	for i := 0; i < len(bytes); {
+		j := i
+		for j+1 < len(bytes) && bytes[j+1] == bytes[j]+1 {
+			j++
+		}
+		sb.WriteString(describeByte(bytes[i]))
+		if j > i+1 {
+			sb.WriteString("-")
+		}
+		if j > i {
+			sb.WriteString(describeByte(bytes[j]))
+		}
+		i = j + 1
+	}
+	return sb.String()
+}
+
+func describeByte(b byte) string {
+	if b > ' ' && b < 0x7f {
+		return string(b)
+	}
+	return fmt.Sprintf("\\x%02x", b)
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(s)
+}
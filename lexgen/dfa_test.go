@@ -0,0 +1,153 @@
+package lexgen
+
+import (
+	"os"
+	"testing"
+)
+
+func rulesOf(patterns ...string) []Rule {
+	var rules []Rule
+	for i, pattern := range patterns {
+		rules = append(rules, Rule{Name: string(rune('A' + i)), Pattern: pattern, Line: i + 1})
+	}
+	return rules
+}
+
+// 在 DFA 上运行整个输入，返回接受的规则下标，不接受时返回 -1
+func (d *DFA) match(input string) int {
+	state := d.Start
+	for i := 0; i < len(input); i++ {
+		if state = d.Trans[state][input[i]]; state < 0 {
+			return -1
+		}
+	}
+	return d.Accept[state]
+}
+
+// 由字母表 alphabet 组成、长度不超过 n 的所有字符串
+func allStrings(alphabet string, n int) []string {
+	result := []string{""}
+	last := []string{""}
+	for i := 0; i < n; i++ {
+		var next []string
+		for _, s := range last {
+			for j := 0; j < len(alphabet); j++ {
+				next = append(next, s+alphabet[j:j+1])
+			}
+		}
+		result = append(result, next...)
+		last = next
+	}
+	return result
+}
+
+func TestMinimize(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		dfa      int // 子集构造得到的状态数
+		min      int // 最小化后的状态数
+	}{
+		// 龙书 3.7 节的例子
+		{[]string{"(a|b)*abb"}, 5, 4},
+		{[]string{"a|b"}, 3, 2},
+		{[]string{"ab|cb"}, 5, 3},
+		{[]string{"a*"}, 2, 1},
+		{[]string{"(a|b)*"}, 3, 1},
+		// 接受不同规则的状态不能合并
+		{[]string{"a", "b"}, 3, 3},
+		{[]string{"if", "[a-z]+"}, 4, 4},
+	}
+	for _, tt := range tests {
+		rules := rulesOf(tt.patterns...)
+		nfa, err := BuildNFA(rules)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.patterns, err)
+		}
+		dfa := nfa.ToDFA(rules)
+		min := dfa.Minimize()
+		if dfa.StateCount() != tt.dfa || min.StateCount() != tt.min {
+			t.Errorf("%v: 状态数 %d -> %d, 期望 %d -> %d", tt.patterns, dfa.StateCount(), min.StateCount(), tt.dfa, tt.min)
+		}
+		if min.Start != 0 {
+			t.Errorf("%v: 开始状态为 %d", tt.patterns, min.Start)
+		}
+		// 最小化前后接受的语言相同
+		for _, s := range allStrings("abcfi", 5) {
+			if dfa.match(s) != min.match(s) {
+				t.Errorf("%v: 最小化前后 %q 的结果不同: %d, %d", tt.patterns, s, dfa.match(s), min.match(s))
+			}
+		}
+	}
+}
+
+func TestRulePriority(t *testing.T) {
+	tests := []struct {
+		rules []Rule
+		input string
+		want  int
+	}{
+		{[]Rule{{Name: "ID", Pattern: "[a-z]+", Priority: 1}, {Name: "IF", Pattern: "if", Priority: 10}}, "if", 1},
+		{[]Rule{{Name: "ID", Pattern: "[a-z]+", Priority: 1}, {Name: "IF", Pattern: "if", Priority: 10}}, "iff", 0},
+		// 优先级相同时先出现的规则胜出
+		{[]Rule{{Name: "A", Pattern: "x"}, {Name: "B", Pattern: "x"}}, "x", 0},
+	}
+	for _, tt := range tests {
+		d, err := Build(tt.rules)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := d.match(tt.input); got != tt.want {
+			t.Errorf("%q 匹配规则 %d, 期望 %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRegexErrors(t *testing.T) {
+	for _, pattern := range []string{"(ab", "ab)", "*a", "[ab", "[z-a]", `a\`, "a|+"} {
+		if _, err := BuildNFA(rulesOf(pattern)); err == nil {
+			t.Errorf("%q: 没有返回错误", pattern)
+		}
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	rules, err := ParseSpec("# 注释\n\nID 1 [a-z]+\n- 0 [ \\t]+\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Rule{{"ID", "[a-z]+", 1, 3}, {"-", `[ \t]+`, 0, 4}}
+	if len(rules) != len(want) || rules[0] != want[0] || rules[1] != want[1] {
+		t.Errorf("得到 %+v, 期望 %+v", rules, want)
+	}
+
+	for _, spec := range []string{"", "# 只有注释", "ID [a-z]+", "ID x [a-z]+"} {
+		if _, err := ParseSpec(spec); err == nil {
+			t.Errorf("%q: 没有返回错误", spec)
+		}
+	}
+}
+
+func TestCourseSpec(t *testing.T) {
+	spec, err := os.ReadFile("course.spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := ParseSpec(string(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nfa, err := BuildNFA(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dfa := nfa.ToDFA(rules)
+	min := dfa.Minimize()
+	if min.StateCount() >= dfa.StateCount() {
+		t.Errorf("最小化没有减少状态: %d -> %d", dfa.StateCount(), min.StateCount())
+	}
+	for _, s := range []string{"if", "iff", "main", "0x1F", "017", "1.5e3", "'a'", `"s\n"`, "<=", "/* c */", "// c"} {
+		if dfa.match(s) != min.match(s) {
+			t.Errorf("最小化前后 %q 的结果不同", s)
+		}
+	}
+}
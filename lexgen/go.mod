@@ -0,0 +1,6 @@
+module lexgen
+
+go 1.23.2
+
+require mygo_c_compiler/lexer v0.0.0
+replace mygo_c_compiler/lexer => ../lexer
@@ -0,0 +1,126 @@
+package lexgen
+
+import (
+	"fmt"
+	"mygo_c_compiler/lexer"
+	"unicode/utf8"
+)
+
+// 创建由 DFA 驱动的词法分析器
+func NewLexer(dfa *DFA, input string, opts ...Option) *Lexer {
+	l := &Lexer{dfa: dfa, input: input, line: 1, column: 1, lineStart: true}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// 设置源文件名，用于诊断信息中的位置
+func WithFilename(filename string) Option {
+	return func(l *Lexer) {
+		l.filename = filename
+	}
+}
+
+func (l *Lexer) currentPosition() lexer.Position {
+	return lexer.Position{File: l.filename, Line: l.line, Column: l.column, Offset: l.position}
+}
+
+// 前进到 offset 处并更新行列号，列号按 UTF-8 字符计数
+func (l *Lexer) advanceTo(offset int) {
+	for ; l.position < offset; l.position++ {
+		if l.input[l.position] == '\n' {
+			l.line++
+			l.column = 1
+			l.lineStart = true
+		} else if !utf8.RuneStart(l.input[l.position]) {
+			continue
+		} else {
+			l.column++
+		}
+	}
+}
+
+// 从 offset 处运行 DFA，返回最后一次到达的接受状态对应的规则与匹配的结束位置，没有匹配时规则为 -1
+func (l *Lexer) longestMatch(offset int) (int, int) {
+	state := l.dfa.Start
+	lastAccept, lastEnd := -1, -1
+	for i := offset; i < len(l.input); i++ {
+		state = l.dfa.Trans[state][l.input[i]]
+		if state < 0 {
+			break
+		}
+		if l.dfa.Accept[state] >= 0 {
+			lastAccept, lastEnd = l.dfa.Accept[state], i+1
+		}
+	}
+	return lastAccept, lastEnd
+}
+
+// 按最长匹配原则返回下一个 Token，丢弃规则名为 "-" 的匹配
+func (l *Lexer) NextToken() lexer.Token {
+	if l.prevToken != nil {
+		tok := *l.prevToken
+		l.prevToken = nil
+		return tok
+	}
+
+	spaceBefore := false
+	for {
+		start := l.currentPosition()
+		lineStart := l.lineStart
+		if l.position >= len(l.input) {
+			return lexer.Token{Type: lexer.EOF, Span: lexer.Span{Start: start, End: start}, LineStart: lineStart}
+		}
+
+		rule, end := l.longestMatch(l.position)
+		if rule < 0 {
+			tok := l.readUnknown()
+			tok.SpaceBefore, tok.LineStart = spaceBefore, lineStart
+			return tok
+		}
+
+		value := l.input[l.position:end]
+		l.advanceTo(end)
+		if l.dfa.Rules[rule].Name == SkipName {
+			spaceBefore = true
+			continue
+		}
+		// Token 内部的换行不影响下一个 Token 的 LineStart
+		l.lineStart = false
+		return lexer.Token{
+			Type:        lexer.TokenType(l.dfa.Rules[rule].Name),
+			Value:       value,
+			Span:        lexer.Span{Start: start, End: l.currentPosition()},
+			SpaceBefore: spaceBefore,
+			LineStart:   lineStart,
+		}
+	}
+}
+
+// 把连续的无法匹配的字符合并为一个 UNKNOWN Token，按 UTF-8 字符前进，不会拆开多字节字符
+func (l *Lexer) readUnknown() lexer.Token {
+	start := l.currentPosition()
+	end := l.position
+	for end < len(l.input) {
+		if rule, _ := l.longestMatch(end); rule >= 0 {
+			break
+		}
+		_, size := utf8.DecodeRuneInString(l.input[end:])
+		end += size
+	}
+	value := l.input[l.position:end]
+	l.advanceTo(end)
+	l.lineStart = false
+	tok := lexer.Token{Type: lexer.UNKNOWN, Value: value, Span: lexer.Span{Start: start, End: l.currentPosition()}}
+	if !utf8.ValidString(value) {
+		tok.Error = fmt.Sprintf("无效的 UTF-8 编码: %q", value)
+	} else {
+		tok.Error = fmt.Sprintf("未知字符: '%s'", value)
+	}
+	return tok
+}
+
+func (l *Lexer) UnreadToken(tok lexer.Token) {
+	l.prevToken = &tok
+}
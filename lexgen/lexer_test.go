@@ -0,0 +1,71 @@
+package lexgen
+
+import (
+	"mygo_c_compiler/lexer"
+	"os"
+	"testing"
+)
+
+// 由课程词法说明生成的词法分析器与手写的 lexer.Lexer 产生相同的 Token
+func TestLexerMatchesHandWritten(t *testing.T) {
+	spec, err := os.ReadFile("course.spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := ParseSpec(string(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dfa, err := Build(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const source = `main {
+	int a = 0x1F, b = 017; /* 注释 */
+	while (a <= 10 && !b) { a += 1; b--; }  // 行注释
+	c = 'x'; s = "é\n"; f = 1.5e3 + .5;
+}`
+	got := NewLexer(dfa, source, WithFilename("a.c"))
+	want := lexer.NewLexer(source, lexer.WithFilename("a.c"), lexer.WithDialect(lexer.DialectCourse))
+	for {
+		g, w := got.NextToken(), want.NextToken()
+		if g.Type != w.Type || g.Value != w.Value || g.Span != w.Span || g.SpaceBefore != w.SpaceBefore || g.LineStart != w.LineStart {
+			t.Fatalf("得到 %s %q %s, 期望 %s %q %s", g.Type, g.Value, g.Span.Start, w.Type, w.Value, w.Span.Start)
+		}
+		if g.Type == lexer.EOF {
+			break
+		}
+	}
+}
+
+func TestLexerUnknownAndUnread(t *testing.T) {
+	dfa, err := Build([]Rule{{Name: "IDENTIFIER", Pattern: "[a-z]+"}, {Name: SkipName, Pattern: "[ \\n]+"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLexer(dfa, "ab @ cd\n@@é\xff x")
+	first := l.NextToken()
+	l.UnreadToken(first)
+	if tok := l.NextToken(); tok != first {
+		t.Errorf("UnreadToken 后得到 %v", tok)
+	}
+	tok := l.NextToken()
+	if tok.Type != lexer.UNKNOWN || tok.Value != "@" || tok.Error == "" || !tok.SpaceBefore {
+		t.Errorf("未知字符得到 %+v", tok)
+	}
+	if tok := l.NextToken(); tok.Value != "cd" || tok.Span.Start.Column != 6 || tok.LineStart {
+		t.Errorf("得到 %+v", tok)
+	}
+	// 连续的未知字符合并为一个 Token，多字节字符不会被拆开
+	tok = l.NextToken()
+	if tok.Type != lexer.UNKNOWN || tok.Value != "@@é\xff" || !tok.LineStart || tok.Span.End.Column != 5 {
+		t.Errorf("连续的未知字符得到 %+v", tok)
+	}
+	if tok := l.NextToken(); tok.Value != "x" || tok.LineStart || !tok.SpaceBefore {
+		t.Errorf("得到 %+v", tok)
+	}
+	if tok := l.NextToken(); tok.Type != lexer.EOF {
+		t.Errorf("输入结束后得到 %v", tok)
+	}
+}
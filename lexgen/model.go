@@ -0,0 +1,56 @@
+package lexgen
+
+import (
+	"mygo_c_compiler/lexer"
+)
+
+// 词法规则：Token 类型名、正则表达式与优先级
+type Rule struct {
+	Name     string // Token 类型名，"-" 表示匹配后丢弃（空白、注释）
+	Pattern  string // 正则表达式
+	Priority int    // 匹配长度相同时优先级高者胜出，优先级相同时先出现者胜出
+	Line     int    // 规则在说明文件中的行号
+}
+
+// 丢弃匹配内容的规则名
+const SkipName = "-"
+
+// 256 位的字节集合
+type charSet [4]uint64
+
+// NFA 状态，Thompson 构造中每个状态至多有一条字符边
+type nfaState struct {
+	eps    []int   // ε 边
+	set    charSet // 字符边上的字节集合
+	next   int     // 字符边的目标状态，-1 表示没有字符边
+	accept int     // 接受的规则下标，-1 表示非接受状态
+}
+
+// 非确定有限自动机
+type NFA struct {
+	states []nfaState
+	start  int
+}
+
+// 确定有限自动机
+type DFA struct {
+	Rules  []Rule
+	Trans  [][256]int // 状态转移表，-1 表示无转移
+	Accept []int      // 每个状态接受的规则下标，-1 表示非接受状态
+	Start  int
+}
+
+// 表驱动词法分析器选项
+type Option func(*Lexer)
+
+// 表驱动词法分析器，产生与 lexer.Lexer 相同的 Token
+type Lexer struct {
+	dfa       *DFA
+	input     string
+	filename  string
+	position  int
+	line      int
+	column    int
+	lineStart bool // 自上一个 Token 以来是否遇到过换行
+	prevToken *lexer.Token
+}
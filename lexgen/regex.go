@@ -0,0 +1,268 @@
+package lexgen
+
+import (
+	"fmt"
+)
+
+// NFA 片段，start 为入口状态，end 为唯一的出口状态
+type fragment struct {
+	start int
+	end   int
+}
+
+// 正则表达式解析器，边解析边用 Thompson 构造法生成 NFA
+type regexParser struct {
+	nfa     *NFA
+	pattern string
+	pos     int
+}
+
+func (s *charSet) add(c byte) {
+	s[c/64] |= 1 << (c % 64)
+}
+
+func (s *charSet) addRange(lo, hi byte) {
+	for c := int(lo); c <= int(hi); c++ {
+		s.add(byte(c))
+	}
+}
+
+func (s *charSet) has(c byte) bool {
+	return s[c/64]&(1<<(c%64)) != 0
+}
+
+func (s *charSet) negate() {
+	for i := range s {
+		s[i] = ^s[i]
+	}
+}
+
+func (n *NFA) newState() int {
+	n.states = append(n.states, nfaState{next: -1, accept: -1})
+	return len(n.states) - 1
+}
+
+func (n *NFA) addEpsilon(from, to int) {
+	n.states[from].eps = append(n.states[from].eps, to)
+}
+
+// 用 Thompson 构造法为全部规则生成一个 NFA，新的开始状态经 ε 边连接到各规则的 NFA
+func BuildNFA(rules []Rule) (*NFA, error) {
+	n := &NFA{}
+	n.start = n.newState()
+	for i, rule := range rules {
+		p := &regexParser{nfa: n, pattern: rule.Pattern}
+		frag, err := p.parse()
+		if err != nil {
+			return nil, fmt.Errorf("第%d行规则 %s: %v", rule.Line, rule.Name, err)
+		}
+		n.states[frag.end].accept = i
+		n.addEpsilon(n.start, frag.start)
+	}
+	return n, nil
+}
+
+// 返回 NFA 的状态数
+func (n *NFA) StateCount() int {
+	return len(n.states)
+}
+
+func (p *regexParser) parse() (fragment, error) {
+	if p.pattern == "" {
+		return fragment{}, fmt.Errorf("正则表达式为空")
+	}
+	frag, err := p.alternation()
+	if err != nil {
+		return fragment{}, err
+	}
+	if p.pos < len(p.pattern) {
+		return fragment{}, fmt.Errorf("位置%d: 多余的 %q", p.pos, p.pattern[p.pos])
+	}
+	return frag, nil
+}
+
+func (p *regexParser) peek() byte {
+	if p.pos >= len(p.pattern) {
+		return 0
+	}
+	return p.pattern[p.pos]
+}
+
+// alternation -> concat ( | concat )*
+func (p *regexParser) alternation() (fragment, error) {
+	left, err := p.concat()
+	if err != nil {
+		return fragment{}, err
+	}
+	for p.pos < len(p.pattern) && p.peek() == '|' {
+		p.pos++
+		right, err := p.concat()
+		if err != nil {
+			return fragment{}, err
+		}
+		start, end := p.nfa.newState(), p.nfa.newState()
+		p.nfa.addEpsilon(start, left.start)
+		p.nfa.addEpsilon(start, right.start)
+		p.nfa.addEpsilon(left.end, end)
+		p.nfa.addEpsilon(right.end, end)
+		left = fragment{start, end}
+	}
+	return left, nil
+}
+
+// concat -> repeat*，为空时得到只含 ε 边的片段
+func (p *regexParser) concat() (fragment, error) {
+	start := p.nfa.newState()
+	frag := fragment{start, start}
+	for p.pos < len(p.pattern) && p.peek() != '|' && p.peek() != ')' {
+		next, err := p.repeat()
+		if err != nil {
+			return fragment{}, err
+		}
+		p.nfa.addEpsilon(frag.end, next.start)
+		frag.end = next.end
+	}
+	return frag, nil
+}
+
+// repeat -> atom ( * | + | ? )*
+func (p *regexParser) repeat() (fragment, error) {
+	frag, err := p.atom()
+	if err != nil {
+		return fragment{}, err
+	}
+	for p.pos < len(p.pattern) {
+		op := p.peek()
+		if op != '*' && op != '+' && op != '?' {
+			break
+		}
+		p.pos++
+		start, end := p.nfa.newState(), p.nfa.newState()
+		p.nfa.addEpsilon(start, frag.start)
+		p.nfa.addEpsilon(frag.end, end)
+		if op != '+' {
+			p.nfa.addEpsilon(start, end)
+		}
+		if op != '?' {
+			p.nfa.addEpsilon(frag.end, frag.start)
+		}
+		frag = fragment{start, end}
+	}
+	return frag, nil
+}
+
+// atom -> ( alternation ) | [ 字符类 ] | . | \ 转义 | 普通字符
+func (p *regexParser) atom() (fragment, error) {
+	c := p.peek()
+	switch c {
+	case '(':
+		p.pos++
+		frag, err := p.alternation()
+		if err != nil {
+			return fragment{}, err
+		}
+		if p.peek() != ')' {
+			return fragment{}, fmt.Errorf("位置%d: 缺少 )", p.pos)
+		}
+		p.pos++
+		return frag, nil
+	case '[':
+		p.pos++
+		set, err := p.charClass()
+		if err != nil {
+			return fragment{}, err
+		}
+		return p.charFragment(set), nil
+	case '.':
+		p.pos++
+		var set charSet
+		set.add('\n')
+		set.negate()
+		return p.charFragment(set), nil
+	case '*', '+', '?':
+		return fragment{}, fmt.Errorf("位置%d: %q 前面缺少操作数", p.pos, c)
+	}
+	b, err := p.literal()
+	if err != nil {
+		return fragment{}, err
+	}
+	var set charSet
+	set.add(b)
+	return p.charFragment(set), nil
+}
+
+func (p *regexParser) charFragment(set charSet) fragment {
+	start, end := p.nfa.newState(), p.nfa.newState()
+	p.nfa.states[start].set = set
+	p.nfa.states[start].next = end
+	return fragment{start, end}
+}
+
+// 读取一个普通字符或转义字符
+func (p *regexParser) literal() (byte, error) {
+	c := p.pattern[p.pos]
+	p.pos++
+	if c != '\\' {
+		return c, nil
+	}
+	if p.pos >= len(p.pattern) {
+		return 0, fmt.Errorf("正则表达式以 \\ 结尾")
+	}
+	c = p.pattern[p.pos]
+	p.pos++
+	switch c {
+	case 'n':
+		return '\n', nil
+	case 't':
+		return '\t', nil
+	case 'r':
+		return '\r', nil
+	case 'f':
+		return '\f', nil
+	case 'v':
+		return '\v', nil
+	}
+	return c, nil
+}
+
+// 解析 [...] 字符类，支持范围 a-z 与取反 ^
+func (p *regexParser) charClass() (charSet, error) {
+	var set charSet
+	negate := false
+	if p.peek() == '^' {
+		negate = true
+		p.pos++
+	}
+	first := true
+	for {
+		if p.pos >= len(p.pattern) {
+			return set, fmt.Errorf("字符类缺少 ]")
+		}
+		if p.peek() == ']' && !first {
+			p.pos++
+			break
+		}
+		first = false
+		lo, err := p.literal()
+		if err != nil {
+			return set, err
+		}
+		if p.peek() == '-' && p.pos+1 < len(p.pattern) && p.pattern[p.pos+1] != ']' {
+			p.pos++
+			hi, err := p.literal()
+			if err != nil {
+				return set, err
+			}
+			if hi < lo {
+				return set, fmt.Errorf("字符类中的范围 %c-%c 无效", lo, hi)
+			}
+			set.addRange(lo, hi)
+		} else {
+			set.add(lo)
+		}
+	}
+	if negate {
+		set.negate()
+	}
+	return set, nil
+}
@@ -0,0 +1,35 @@
+package lexgen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 解析词法说明文件。每行格式为 "名称 优先级 正则表达式"，以 # 开头的行为注释
+func ParseSpec(spec string) ([]Rule, error) {
+	var rules []Rule
+	for i, line := range strings.Split(spec, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("第%d行: 规则格式应为 \"名称 优先级 正则表达式\"", i+1)
+		}
+		priority, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("第%d行: 无效的优先级 %q", i+1, fields[1])
+		}
+		// 正则表达式为第二个字段之后的剩余部分，可以包含空格
+		afterName := line[len(fields[0]):]
+		rest := strings.TrimSpace(afterName[strings.Index(afterName, fields[1])+len(fields[1]):])
+		rules = append(rules, Rule{Name: fields[0], Pattern: rest, Priority: priority, Line: i + 1})
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("词法说明中没有规则")
+	}
+	return rules, nil
+}
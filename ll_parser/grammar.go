@@ -0,0 +1,88 @@
+package ll_parser
+
+import (
+	_ "embed"
+	"mygo_c_compiler/grammar"
+	"strings"
+)
+
+// 默认文法，与 lr_parser/grammar.md 描述同一语言，已消除左递归
+//
+//go:embed grammar.md
+var defaultGrammar string
+
+// 产生式结构
+type Production struct {
+	Left  string   // 左部
+	Right []string // 右部，空产生式为空切片
+}
+
+func (prod Production) String() string {
+	if len(prod.Right) == 0 {
+		return prod.Left + " -> ε"
+	}
+	return prod.Left + " -> " + strings.Join(prod.Right, " ")
+}
+
+type Parser struct {
+	Productions  []Production
+	Start        string                     // 开始符号，由 %start 指定，默认为第一条产生式的左部
+	Terminals    []string                   // 按出现顺序排列，包含结束符 $
+	NonTerminals []string                   // 按出现顺序排列
+	Analysis     *grammar.Analysis          // 文法的可空性、FIRST/FOLLOW 集与发现的问题
+	First        map[string]map[string]bool // 即 Analysis.First
+	Follow       map[string]map[string]bool // 即 Analysis.Follow
+	Nullable     map[string]bool            // 即 Analysis.Nullable
+	Table        ParsingTable
+	Conflicts    []Conflict
+
+	nonTerminals map[string]bool
+}
+
+// 使用内置文法创建解析器
+func New() (*Parser, error) {
+	return NewFromGrammar(defaultGrammar)
+}
+
+// 使用给定的文法文本创建解析器，文法格式见 grammar 包。
+// 文法不是 LL(1) 时仍然返回解析器，冲突记录在 Conflicts 中
+func NewFromGrammar(grammar string) (*Parser, error) {
+	parser := &Parser{}
+	if err := parser.ParseGrammar(grammar); err != nil {
+		return nil, err
+	}
+	parser.BuildParsingTable()
+	return parser, nil
+}
+
+// 解析文法文件，格式见 grammar 包。
+// 会替换之前解析的文法，并清空由之前的文法得到的集合与分析表，需要重新调用 BuildParsingTable
+func (p *Parser) ParseGrammar(text string) error {
+	*p = Parser{}
+	g, err := grammar.Parse(text)
+	if err != nil {
+		return err
+	}
+	for _, prod := range g.Productions {
+		p.Productions = append(p.Productions, Production{Left: prod.Left, Right: prod.Right})
+	}
+
+	p.Start = g.Start()
+	p.NonTerminals = g.NonTerminals()
+	p.nonTerminals = make(map[string]bool)
+	for _, nt := range p.NonTerminals {
+		p.nonTerminals[nt] = true
+	}
+
+	p.Analysis = grammar.Analyze(g)
+	p.Terminals = p.Analysis.Terminals
+	p.First = p.Analysis.First
+	p.Follow = p.Analysis.Follow
+	p.Nullable = p.Analysis.Nullable
+	return nil
+}
+
+// 判断是否为非终结符
+func (p *Parser) isNonTerminal(symbol string) bool {
+	return p.nonTerminals[symbol]
+}
@@ -0,0 +1,105 @@
+package ll_parser
+
+import (
+	"fmt"
+	"io"
+	"mygo_c_compiler/lexer"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// 分析过程中的一步，记录执行动作之前的分析栈与当前输入符号
+type Step struct {
+	Stack  []string // 分析栈，栈顶在末尾
+	Input  string   // 当前输入符号
+	Action string
+}
+
+// 分析过程
+type Trace []Step
+
+// 以表格形式打印分析过程
+func (t Trace) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "步骤\t分析栈\t输入\t动作")
+	for i, step := range t {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", i+1, strings.Join(step.Stack, " "), step.Input, step.Action)
+	}
+	tw.Flush()
+}
+
+// 语法错误
+type SyntaxError struct {
+	Span     lexer.Span
+	Got      lexer.Token
+	Expected []string // 期望的语法符号
+	Message  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s: 语法错误: %s", e.Span.Start, e.Message)
+}
+
+// 执行语法分析，返回分析过程；出错时分析过程截止到出错的一步
+func (p *Parser) Parse(tokens []lexer.Token) (Trace, error) {
+	return p.ParseStream(lexer.NewSliceStream(tokens))
+}
+
+// 从 Token 流中读取输入并进行预测分析。文法不是 LL(1) 文法时不分析，直接返回错误
+func (p *Parser) ParseStream(tokens *lexer.TokenStream) (Trace, error) {
+	if !p.IsLL1() {
+		return nil, fmt.Errorf("文法不是 LL(1) 文法，分析表中有 %d 处冲突", len(p.Conflicts))
+	}
+	stack := []string{"$", p.Start}
+	var trace Trace
+	record := func(input, action string) {
+		trace = append(trace, Step{Stack: slices.Clone(stack), Input: input, Action: action})
+	}
+
+	for {
+		top := stack[len(stack)-1]
+		tok := tokens.Peek(1)
+		symbol := tok.GrammarSymbol()
+
+		if !p.isNonTerminal(top) {
+			if top != symbol {
+				record(symbol, "错误")
+				return trace, p.syntaxError(tok, symbol, []string{top})
+			}
+			if top == "$" {
+				record(symbol, "接受")
+				return trace, nil
+			}
+			record(symbol, "匹配 "+symbol)
+			stack = stack[:len(stack)-1]
+			tokens.Next()
+			continue
+		}
+
+		prods := p.Table[top][symbol]
+		if len(prods) == 0 {
+			record(symbol, "错误")
+			expected := make(map[string]bool)
+			for t := range p.Table[top] {
+				expected[t] = true
+			}
+			return trace, p.syntaxError(tok, symbol, p.sortedTerminals(expected))
+		}
+		prod := p.Productions[prods[0]]
+		record(symbol, prod.String())
+		stack = stack[:len(stack)-1]
+		for i := len(prod.Right) - 1; i >= 0; i-- {
+			stack = append(stack, prod.Right[i])
+		}
+	}
+}
+
+func (p *Parser) syntaxError(tok lexer.Token, symbol string, expected []string) *SyntaxError {
+	return &SyntaxError{
+		Span:     tok.Span,
+		Got:      tok,
+		Expected: expected,
+		Message:  fmt.Sprintf("期望 %s，实际为 %s", strings.Join(expected, " "), symbol),
+	}
+}
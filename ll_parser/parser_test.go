@@ -0,0 +1,144 @@
+package ll_parser
+
+import (
+	"mygo_c_compiler/lexer"
+	"slices"
+	"testing"
+)
+
+func tokens(source string) *lexer.TokenStream {
+	return lexer.NewTokenStream(lexer.NewLexer(source, lexer.WithDialect(lexer.DialectCourse)))
+}
+
+func TestDefaultGrammarTable(t *testing.T) {
+	p, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsLL1() {
+		t.Fatalf("默认文法不是 LL(1) 文法: %v", p.Conflicts)
+	}
+
+	tests := []struct {
+		nonTerminal, terminal string
+		want                  string
+	}{
+		{"program", "main", "program -> main block"},
+		{"stmts", "id", "stmts -> stmt stmts"},
+		{"stmts", "}", "stmts -> ε"},
+		{"E'", "+", "E' -> + F E'"},
+		{"E'", ")", "E' -> ε"},
+		{"E'", ";", "E' -> ε"},
+		{"F'", "+", "F' -> ε"},
+		{"bool'", "<=", "bool' -> <= T"},
+		{"bool'", ")", "bool' -> ε"},
+		{"T", "num", "T -> num"},
+		{"E", "}", ""},
+	}
+	for _, tt := range tests {
+		if got := p.cellString(p.Table[tt.nonTerminal][tt.terminal]); got != tt.want {
+			t.Errorf("M[%s, %s] = %q, 期望 %q", tt.nonTerminal, tt.terminal, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultGrammarSets(t *testing.T) {
+	p, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		symbol   string
+		first    []string
+		follow   []string
+		nullable bool
+	}{
+		{"program", []string{"main"}, []string{"$"}, false},
+		{"stmts", []string{"{", "id", "while"}, []string{"}"}, true},
+		{"E", []string{"id", "num", "("}, []string{";", ")"}, false},
+		{"F'", []string{"*"}, []string{";", ")", "+"}, true},
+		{"T", []string{"id", "num"}, []string{";", ")", "+", "*", "<", "<=", ">", ">=", "==", "!="}, false},
+	}
+	for _, tt := range tests {
+		first := p.sortedTerminals(p.First[tt.symbol])
+		follow := p.sortedTerminals(p.Follow[tt.symbol])
+		slices.Sort(first)
+		slices.Sort(follow)
+		slices.Sort(tt.first)
+		slices.Sort(tt.follow)
+		if !slices.Equal(first, tt.first) {
+			t.Errorf("FIRST(%s) = %v, 期望 %v", tt.symbol, first, tt.first)
+		}
+		if !slices.Equal(follow, tt.follow) {
+			t.Errorf("FOLLOW(%s) = %v, 期望 %v", tt.symbol, follow, tt.follow)
+		}
+		if p.Nullable[tt.symbol] != tt.nullable {
+			t.Errorf("%s 可空 = %v, 期望 %v", tt.symbol, p.Nullable[tt.symbol], tt.nullable)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	p, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		source string
+		ok     bool
+	}{
+		{"main { }", true},
+		{"main { a = 1 + b * (c + 2); while (a <= 10) { a = a + 1; } }", true},
+		{"main { x = 0x10 + 010 + 1.5; }", true},
+		{"main { a = ; }", false},
+		{"main { a = 1 }", false},
+		{"main", false},
+	}
+	for _, tt := range tests {
+		trace, err := p.ParseStream(tokens(tt.source))
+		if (err == nil) != tt.ok {
+			t.Errorf("分析 %q: 错误 = %v, 期望成功 = %v", tt.source, err, tt.ok)
+		}
+		if len(trace) == 0 {
+			t.Errorf("分析 %q: 没有分析过程", tt.source)
+		}
+	}
+}
+
+// 有冲突的分析表不能用于分析，左递归文法在猜测产生式时会无限展开
+func TestParseRejectsConflictingTable(t *testing.T) {
+	p, err := NewFromGrammar("E -> E '+' id | id\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.IsLL1() {
+		t.Fatal("左递归文法被判定为 LL(1) 文法")
+	}
+	if _, err := p.ParseStream(tokens("a")); err == nil {
+		t.Error("有冲突的分析表分析成功")
+	}
+}
+
+// 重新解析文法时替换之前的产生式与集合
+func TestParseGrammarReplacesGrammar(t *testing.T) {
+	p, err := NewFromGrammar("E -> E '+' id | id\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ParseGrammar("S -> id S | num\n"); err != nil {
+		t.Fatal(err)
+	}
+	p.BuildParsingTable()
+	if len(p.Productions) != 2 || p.Productions[0].Left != "S" {
+		t.Errorf("产生式为 %v", p.Productions)
+	}
+	if !slices.Equal(p.NonTerminals, []string{"S"}) || p.First["E"] != nil {
+		t.Errorf("非终结符为 %v, FIRST 集为 %v", p.NonTerminals, p.First)
+	}
+	if !p.IsLL1() {
+		t.Errorf("分析表仍有冲突 %v", p.Conflicts)
+	}
+	if _, err := p.ParseStream(tokens("a b 1")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,33 @@
+package ll_parser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 按终结符的出现顺序排列集合中的元素
+func (p *Parser) sortedTerminals(set map[string]bool) []string {
+	var result []string
+	for _, t := range p.Terminals {
+		if set[t] {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// 打印每个非终结符的 FIRST 集与 FOLLOW 集
+func (p *Parser) PrintSets(w io.Writer) {
+	for _, nt := range p.NonTerminals {
+		first := p.sortedTerminals(p.First[nt])
+		if p.Nullable[nt] {
+			first = append(first, "ε")
+		}
+		fmt.Fprintf(w, "FIRST(%s) = { %s }\n", nt, strings.Join(first, ", "))
+	}
+	fmt.Fprintln(w)
+	for _, nt := range p.NonTerminals {
+		fmt.Fprintf(w, "FOLLOW(%s) = { %s }\n", nt, strings.Join(p.sortedTerminals(p.Follow[nt]), ", "))
+	}
+}
@@ -0,0 +1,112 @@
+package ll_parser
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// 预测分析表 M[A][a]，表项为产生式下标。LL(1) 文法的每个表项至多一条产生式
+type ParsingTable map[string]map[string][]int
+
+// LL(1) 冲突：M[NonTerminal][Terminal] 中有多条产生式
+type Conflict struct {
+	NonTerminal string
+	Terminal    string
+	Productions []int
+}
+
+// 构建预测分析表：对 A -> α，FIRST(α) 中的每个终结符 a 令 M[A][a] 含该产生式；
+// α 可空时 FOLLOW(A) 中的每个终结符同样如此
+func (p *Parser) BuildParsingTable() {
+	p.Table = make(ParsingTable)
+	p.Conflicts = nil
+	for _, nt := range p.NonTerminals {
+		p.Table[nt] = make(map[string][]int)
+	}
+
+	for i, prod := range p.Productions {
+		first, nullable := p.Analysis.FirstOf(prod.Right)
+		if nullable {
+			for t := range p.Follow[prod.Left] {
+				first[t] = true
+			}
+		}
+		for _, t := range p.sortedTerminals(first) {
+			p.Table[prod.Left][t] = append(p.Table[prod.Left][t], i)
+		}
+	}
+
+	for _, nt := range p.NonTerminals {
+		for _, t := range p.Terminals {
+			if prods := p.Table[nt][t]; len(prods) > 1 {
+				p.Conflicts = append(p.Conflicts, Conflict{NonTerminal: nt, Terminal: t, Productions: prods})
+			}
+		}
+	}
+}
+
+// 文法是否为 LL(1) 文法
+func (p *Parser) IsLL1() bool {
+	return len(p.Conflicts) == 0
+}
+
+// 打印所有 LL(1) 冲突及涉及的产生式
+func (p *Parser) PrintConflicts(w io.Writer) {
+	if p.IsLL1() {
+		fmt.Fprintln(w, "文法是 LL(1) 文法，分析表中没有冲突")
+		return
+	}
+	fmt.Fprintf(w, "文法不是 LL(1) 文法，共 %d 处冲突:\n", len(p.Conflicts))
+	for _, c := range p.Conflicts {
+		fmt.Fprintf(w, "M[%s, %s]:\n", c.NonTerminal, c.Terminal)
+		for _, i := range c.Productions {
+			fmt.Fprintf(w, "    (%d) %s\n", i, p.Productions[i])
+		}
+	}
+}
+
+// 打印分析表（用于调试）
+func (p *Parser) PrintParsingTable() {
+	fmt.Println("LL(1) PARSING TABLE:")
+	for _, nt := range p.NonTerminals {
+		fmt.Printf("%s:\n", nt)
+		for _, t := range p.Terminals {
+			if prods, exists := p.Table[nt][t]; exists {
+				fmt.Printf("    %s -> %s\n", t, p.cellString(prods))
+			}
+		}
+	}
+}
+
+// 打印分析表为CSV格式，行为非终结符，列为终结符
+func (p *Parser) PrintParsingTableCSV(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write(append([]string{"NonTerminal"}, p.Terminals...))
+	for _, nt := range p.NonTerminals {
+		row := []string{nt}
+		for _, t := range p.Terminals {
+			row = append(row, p.cellString(p.Table[nt][t]))
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// 表项的文本形式，冲突的表项以 | 分隔
+func (p *Parser) cellString(prods []int) string {
+	parts := make([]string, len(prods))
+	for i, prod := range prods {
+		parts[i] = p.Productions[prod].String()
+	}
+	return strings.Join(parts, " | ")
+}
@@ -0,0 +1,268 @@
+package lr_parser
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sort"
+)
+
+// 构造项目集规范族使用的紧凑表示：符号与产生式都用整数编号，一个项目编码为一个 uint64，
+// 项目集按核心项目的编码放入哈希表查找，闭包中的展望符由预先计算的 FIRST 集得到
+
+// 终结符集合，第 i 位表示编号为 i 的终结符
+type bitset []uint64
+
+func newBitset(n int) bitset {
+	return make(bitset, (n+63)/64)
+}
+
+func (b bitset) add(i int) {
+	b[i/64] |= 1 << (i % 64)
+}
+
+// 把 other 并入 b
+func (b bitset) union(other bitset) {
+	for i, word := range other {
+		b[i] |= word
+	}
+}
+
+// 依次访问集合中的元素
+func (b bitset) each(f func(int)) {
+	for i, word := range b {
+		for word != 0 {
+			bit := bits.TrailingZeros64(word)
+			f(i*64 + bit)
+			word &^= 1 << bit
+		}
+	}
+}
+
+// 项目：产生式编号、点的位置与展望符编号
+type item uint64
+
+// LR(0) 项目的展望符编号
+const noLookahead = 0xFFFF
+
+func makeItem(prod, dot, lookahead int) item {
+	return item(uint64(prod)<<32 | uint64(dot)<<16 | uint64(lookahead))
+}
+
+func (it item) prod() int      { return int(it >> 32) }
+func (it item) dot() int       { return int(it >> 16 & 0xFFFF) }
+func (it item) lookahead() int { return int(it & 0xFFFF) }
+
+// 项目集：核心项目按编码排序，闭包以核心项目开头
+type state struct {
+	kernel []item
+	items  []item
+}
+
+type builder struct {
+	p         *Parser
+	lr0       bool
+	symbols   []string       // 编号 -> 符号，终结符在前（0 为 $），非终结符在后
+	ids       map[string]int // 符号 -> 编号
+	terminals int            // 终结符的数量
+	rank      []int          // 符号按名字排序后的名次，决定转换的处理顺序与项目集的编号
+	right     [][]int        // 产生式右部
+	prodsOf   [][]int        // 非终结符（编号减去 terminals）的产生式
+	first     [][]bitset     // first[prod][k] 为 FIRST(右部[k:])
+	nullable  [][]bool       // nullable[prod][k] 表示右部[k:] 可以推导出空串
+
+	states []state
+	index  map[string]int // 核心项目的编码 -> 项目集编号
+	goTo   []map[int]int  // 项目集之间的转换，符号编号 -> 目标项目集
+	marks  []int32        // 闭包中已经展开的 (非终结符, 展望符)，值等于 mark 时表示已展开
+	mark   int32
+}
+
+func newBuilder(p *Parser) *builder {
+	b := &builder{p: p, lr0: p.usesLR0Items(), ids: make(map[string]int)}
+	intern := func(symbol string) {
+		if _, ok := b.ids[symbol]; !ok {
+			b.ids[symbol] = len(b.symbols)
+			b.symbols = append(b.symbols, symbol)
+		}
+	}
+	intern("$")
+	for _, prod := range p.Productions {
+		for _, symbol := range prod.Right {
+			if p.isTerminal(symbol) {
+				intern(symbol)
+			}
+		}
+	}
+	b.terminals = len(b.symbols)
+	for _, prod := range p.Productions {
+		intern(prod.Left)
+	}
+
+	order := make([]int, len(b.symbols))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return b.symbols[order[i]] < b.symbols[order[j]] })
+	b.rank = make([]int, len(b.symbols))
+	for r, id := range order {
+		b.rank[id] = r
+	}
+
+	b.prodsOf = make([][]int, len(b.symbols)-b.terminals)
+	for i, prod := range p.Productions {
+		right := make([]int, len(prod.Right))
+		for k, symbol := range prod.Right {
+			right[k] = b.ids[symbol]
+		}
+		b.right = append(b.right, right)
+		nt := b.ids[prod.Left] - b.terminals
+		b.prodsOf[nt] = append(b.prodsOf[nt], i)
+	}
+	b.computeFirst()
+	b.marks = make([]int32, (len(b.symbols)-b.terminals)*(b.terminals+1))
+	return b
+}
+
+// 由文法分析得到的 FIRST 集计算每条产生式每个后缀的 FIRST 集与可空性
+func (b *builder) computeFirst() {
+	analysis := b.p.Analysis
+	symbolFirst := make([]bitset, len(b.symbols))
+	for id, symbol := range b.symbols {
+		symbolFirst[id] = newBitset(b.terminals)
+		if id < b.terminals {
+			symbolFirst[id].add(id)
+			continue
+		}
+		for t := range analysis.First[symbol] {
+			symbolFirst[id].add(b.ids[t])
+		}
+	}
+
+	b.first = make([][]bitset, len(b.right))
+	b.nullable = make([][]bool, len(b.right))
+	for i, right := range b.right {
+		b.first[i] = make([]bitset, len(right)+1)
+		b.nullable[i] = make([]bool, len(right)+1)
+		b.first[i][len(right)] = newBitset(b.terminals)
+		b.nullable[i][len(right)] = true
+		for k := len(right) - 1; k >= 0; k-- {
+			set := newBitset(b.terminals)
+			set.union(symbolFirst[right[k]])
+			nullable := right[k] >= b.terminals && analysis.Nullable[b.symbols[right[k]]]
+			if nullable {
+				set.union(b.first[i][k+1])
+			}
+			b.first[i][k] = set
+			b.nullable[i][k] = nullable && b.nullable[i][k+1]
+		}
+	}
+}
+
+// 核心项目集的闭包：对 [A -> α · B β, a]，把 B 的产生式以 FIRST(β a) 中的每个符号为展望符加入。
+// 同一个 (B, b) 只展开一次，因此闭包中没有重复的项目
+func (b *builder) closure(kernel []item) []item {
+	b.mark++
+	items := append([]item(nil), kernel...)
+	expand := func(nt, lookahead int) {
+		slot := (nt-b.terminals)*(b.terminals+1) + lookahead
+		if lookahead == noLookahead {
+			slot = (nt-b.terminals)*(b.terminals+1) + b.terminals
+		}
+		if b.marks[slot] == b.mark {
+			return
+		}
+		b.marks[slot] = b.mark
+		for _, prod := range b.prodsOf[nt-b.terminals] {
+			items = append(items, makeItem(prod, 0, lookahead))
+		}
+	}
+
+	for i := 0; i < len(items); i++ {
+		it := items[i]
+		prod, dot := it.prod(), it.dot()
+		right := b.right[prod]
+		if dot >= len(right) || right[dot] < b.terminals {
+			continue
+		}
+		nt := right[dot]
+		if b.lr0 {
+			expand(nt, noLookahead)
+			continue
+		}
+		b.first[prod][dot+1].each(func(lookahead int) { expand(nt, lookahead) })
+		if b.nullable[prod][dot+1] {
+			expand(nt, it.lookahead())
+		}
+	}
+	return items
+}
+
+// 查找核心项目为 kernel 的项目集，不存在时新建
+func (b *builder) addState(kernel []item) int {
+	sort.Slice(kernel, func(i, j int) bool { return kernel[i] < kernel[j] })
+	key := make([]byte, 8*len(kernel))
+	for i, it := range kernel {
+		binary.LittleEndian.PutUint64(key[8*i:], uint64(it))
+	}
+	if index, ok := b.index[string(key)]; ok {
+		return index
+	}
+	index := len(b.states)
+	b.index[string(key)] = index
+	b.states = append(b.states, state{kernel: kernel, items: b.closure(kernel)})
+	b.goTo = append(b.goTo, make(map[int]int))
+	return index
+}
+
+// 从初始项目集出发依次求出所有项目集。每个项目集的转换按符号名的顺序处理，
+// 因此项目集的编号是确定的
+func (b *builder) build() {
+	b.index = make(map[string]int)
+	lookahead := b.ids["$"]
+	if b.lr0 {
+		lookahead = noLookahead
+	}
+	b.addState([]item{makeItem(0, 0, lookahead)})
+
+	next := make([][]item, len(b.symbols))
+	for i := 0; i < len(b.states); i++ {
+		var symbols []int
+		for _, it := range b.states[i].items {
+			right := b.right[it.prod()]
+			if it.dot() >= len(right) {
+				continue
+			}
+			symbol := right[it.dot()]
+			if len(next[symbol]) == 0 {
+				symbols = append(symbols, symbol)
+			}
+			next[symbol] = append(next[symbol], makeItem(it.prod(), it.dot()+1, it.lookahead()))
+		}
+		sort.Slice(symbols, func(x, y int) bool { return b.rank[symbols[x]] < b.rank[symbols[y]] })
+		for _, symbol := range symbols {
+			b.goTo[i][symbol] = b.addState(next[symbol])
+			next[symbol] = nil
+		}
+	}
+}
+
+// 把紧凑表示的项目集与转换写回 Parser
+func (b *builder) export() {
+	p := b.p
+	p.ItemSets = make([]ItemSet, len(b.states))
+	p.Transitions = make(map[int]map[string]int, len(b.states))
+	for i, s := range b.states {
+		items := make([]Item, len(s.items))
+		for k, it := range s.items {
+			items[k] = Item{Prod: &p.Productions[it.prod()], Dot: it.dot()}
+			if la := it.lookahead(); la != noLookahead {
+				items[k].Lookahead = b.symbols[la]
+			}
+		}
+		p.ItemSets[i] = ItemSet{Items: items}
+		p.Transitions[i] = make(map[string]int, len(b.goTo[i]))
+		for symbol, to := range b.goTo[i] {
+			p.Transitions[i][b.symbols[symbol]] = to
+		}
+	}
+}
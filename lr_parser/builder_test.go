@@ -0,0 +1,61 @@
+package lr_parser
+
+import (
+	"os"
+	"testing"
+)
+
+func readC11(t testing.TB) string {
+	t.Helper()
+	text, err := os.ReadFile("grammars/c11.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(text)
+}
+
+func TestC11Tables(t *testing.T) {
+	if testing.Short() {
+		t.Skip("构造 C11 的 LR(1) 分析表较慢")
+	}
+	text := readC11(t)
+	tests := []struct {
+		mode      Mode
+		states    int
+		conflicts int // 都是 ATOMIC 后遇到 ( 的移进/归约冲突，LR(1) 中出现在多个同心项目集中
+	}{
+		{ModeLR1, 2623, 5},
+		{ModeLALR1, 479, 1},
+	}
+	for _, tt := range tests {
+		p := build(t, text, tt.mode)
+		if len(p.ItemSets) != tt.states {
+			t.Errorf("%s: 项目集数 = %d, 期望 %d", tt.mode, len(p.ItemSets), tt.states)
+		}
+		if len(p.Conflicts) != tt.conflicts {
+			t.Errorf("%s: 冲突数 = %d, 期望 %d", tt.mode, len(p.Conflicts), tt.conflicts)
+		}
+		for _, c := range p.Conflicts {
+			if c.Lookahead != "(" {
+				t.Errorf("%s: I%d 在输入 %s 下有意外的冲突", tt.mode, c.State, c.Lookahead)
+			}
+		}
+	}
+}
+
+func benchmarkBuildC11(b *testing.B, mode Mode) {
+	text := readC11(b)
+	var p *Parser
+	for i := 0; i < b.N; i++ {
+		p = build(b, text, mode)
+	}
+	b.ReportMetric(float64(len(p.ItemSets)), "states")
+}
+
+func BenchmarkBuildC11LR1(b *testing.B) {
+	benchmarkBuildC11(b, ModeLR1)
+}
+
+func BenchmarkBuildC11LALR1(b *testing.B) {
+	benchmarkBuildC11(b, ModeLALR1)
+}
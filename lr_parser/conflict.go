@@ -0,0 +1,144 @@
+package lr_parser
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 分析表中的冲突：项目集 State 在输入 Lookahead 下有多个可选动作
+type Conflict struct {
+	State     int
+	Lookahead string
+	Actions   []string // 相互竞争的动作，表中保留第一个
+	Items     []Item   // 产生这些动作的项目
+}
+
+// 冲突的类型
+func (c Conflict) Kind() string {
+	for _, action := range c.Actions {
+		if action[0] == 's' {
+			return "移进/归约冲突"
+		}
+	}
+	return "归约/归约冲突"
+}
+
+// 按默认规则排列候选动作：移进优先，其次是编号较小的产生式的归约（accept 视为归约第 0 条产生式）
+func sortActions(actions []string) []string {
+	rank := func(action string) int {
+		switch {
+		case action[0] == 's':
+			return -1
+		case action == "accept":
+			return 0
+		}
+		n, _ := strconv.Atoi(action[1:])
+		return n
+	}
+	sorted := append([]string(nil), actions...)
+	sort.SliceStable(sorted, func(i, j int) bool { return rank(sorted[i]) < rank(sorted[j]) })
+	return sorted
+}
+
+// 打印分析表中的全部冲突，包括涉及的项目与能到达冲突的示例输入前缀
+func (p *Parser) PrintConflicts(w io.Writer) {
+	if len(p.Conflicts) == 0 {
+		fmt.Fprintf(w, "%s 分析表中没有冲突\n", p.Mode)
+		return
+	}
+	fmt.Fprintf(w, "%s 分析表中有 %d 处冲突:\n", p.Mode, len(p.Conflicts))
+	for _, c := range p.Conflicts {
+		fmt.Fprintf(w, "I%d 在输入 %s 下有%s: %s\n", c.State, c.Lookahead, c.Kind(), strings.Join(c.Actions, ", "))
+		for _, item := range c.Items {
+			fmt.Fprintf(w, "    %s\n", item)
+		}
+		prefix := strings.Join(p.ExamplePrefix(c.State), " ")
+		if prefix == "" {
+			prefix = "(空)"
+		}
+		fmt.Fprintf(w, "    示例: 读入 %s 后遇到 %s\n", prefix, c.Lookahead)
+		if !p.Strict {
+			fmt.Fprintf(w, "    表中保留 %s\n", c.Actions[0])
+		}
+	}
+}
+
+// 能从初始项目集到达项目集 state 的最短终结符串：
+// 先沿转换找到最短的文法符号串，再把其中的非终结符替换为它能推导出的最短终结符串
+func (p *Parser) ExamplePrefix(state int) []string {
+	type edge struct {
+		from   int
+		symbol string
+	}
+	parent := map[int]edge{0: {from: -1}}
+	visited := func(s int) bool {
+		_, ok := parent[s]
+		return ok
+	}
+	queue := []int{0}
+	for len(queue) > 0 && !visited(state) {
+		from := queue[0]
+		queue = queue[1:]
+		symbols := make([]string, 0, len(p.Transitions[from]))
+		for symbol := range p.Transitions[from] {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+		for _, symbol := range symbols {
+			to := p.Transitions[from][symbol]
+			if !visited(to) {
+				parent[to] = edge{from: from, symbol: symbol}
+				queue = append(queue, to)
+			}
+		}
+	}
+	if !visited(state) {
+		return nil
+	}
+
+	var path []string
+	for s := state; parent[s].from >= 0; s = parent[s].from {
+		path = append([]string{parent[s].symbol}, path...)
+	}
+	shortest := p.shortestDerivations()
+	var result []string
+	for _, symbol := range path {
+		if p.isTerminal(symbol) {
+			result = append(result, symbol)
+		} else {
+			result = append(result, shortest[symbol]...)
+		}
+	}
+	return result
+}
+
+// 每个非终结符能推导出的最短终结符串
+func (p *Parser) shortestDerivations() map[string][]string {
+	shortest := make(map[string][]string)
+	changed := true
+	for changed {
+		changed = false
+		for _, prod := range p.Productions {
+			var derived []string
+			complete := true
+			for _, symbol := range prod.Right {
+				if p.isTerminal(symbol) {
+					derived = append(derived, symbol)
+				} else if s, ok := shortest[symbol]; ok {
+					derived = append(derived, s...)
+				} else {
+					complete = false
+					break
+				}
+			}
+			if old, ok := shortest[prod.Left]; complete && (!ok || len(derived) < len(old)) {
+				shortest[prod.Left] = derived
+				changed = true
+			}
+		}
+	}
+	return shortest
+}
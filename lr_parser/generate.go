@@ -0,0 +1,256 @@
+package lr_parser
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// 生成的分析器的源代码模板。动作编码为整数：大于 0 表示移进到状态 动作-1，
+// 小于 0 表示按产生式 -动作 归约，0 表示接受。增广产生式不会被归约，因此编码互不重叠
+var generatedTemplate = template.Must(template.New("parser").Parse(`// Code generated by lrgen from {{.Source}}; DO NOT EDIT.
+
+// Package {{.Package}} 是由 {{.Source}} 生成的 {{.Mode}} 语法分析器，分析表嵌入在源代码中
+package {{.Package}}
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 文法文本与构造方法的哈希，lrgen 据此与 GeneratorFlags 判断是否需要重新生成
+const GrammarHash = "{{.Hash}}"
+
+// 生成时使用的参数
+const GeneratorFlags = {{printf "%q" .Flags}}
+
+// 产生式，第 0 条为增广产生式
+type Production struct {
+	Left  string
+	Right []string
+}
+
+var Productions = []Production{
+{{- range .Productions}}
+	{ {{- printf "%q" .Left}}, []string{ {{- range $i, $s := .Right}}{{if $i}}, {{end}}{{printf "%q" $s}}{{end}}}},
+{{- end}}
+}
+
+// 文法符号，前 terminals 个为终结符，第 0 个为结束符 $
+var symbols = []string{ {{- range $i, $s := .Symbols}}{{if $i}}, {{end}}{{printf "%q" $s}}{{end}}}
+
+const terminals = {{.Terminals}}
+
+// 第 i 个状态的动作为 actionData[actionIndex[i]:actionIndex[i+1]]，每两个数为 (终结符, 动作)。
+// 动作大于 0 表示移进到状态 动作-1，小于 0 表示按产生式 -动作 归约，等于 accept 表示接受
+const accept = 0
+
+var actionIndex = []int32{ {{- .ActionIndex}}}
+
+var actionData = []int32{ {{- .ActionData}}}
+
+// 第 i 个状态的转移为 gotoData[gotoIndex[i]:gotoIndex[i+1]]，每两个数为 (非终结符, 目标状态)
+var gotoIndex = []int32{ {{- .GotoIndex}}}
+
+var gotoData = []int32{ {{- .GotoData}}}
+
+var symbolIndex = make(map[string]int, len(symbols))
+
+func init() {
+	for i, symbol := range symbols {
+		symbolIndex[symbol] = i
+	}
+}
+
+// 语法错误
+type SyntaxError struct {
+	Pos      int      // 出错的输入符号的下标，等于输入长度时表示输入提前结束
+	Symbol   string   // 出错的输入符号
+	Expected []string // 该状态下可以接受的终结符
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("第 %d 个输入符号 %s 处语法错误，期望 %s", e.Pos+1, e.Symbol, strings.Join(e.Expected, " "))
+}
+
+// 在 data[index[state]:index[state+1]] 中查找符号 symbol 对应的值
+func lookup(index, data []int32, state, symbol int) (int, bool) {
+	for i := index[state]; i < index[state+1]; i += 2 {
+		if int(data[i]) == symbol {
+			return int(data[i+1]), true
+		}
+	}
+	return 0, false
+}
+
+// 状态 state 下可以接受的终结符
+func expected(state int) []string {
+	var result []string
+	for i := actionIndex[state]; i < actionIndex[state+1]; i += 2 {
+		result = append(result, symbols[actionData[i]])
+	}
+	return result
+}
+
+// 分析终结符序列 input（不含结束符 $）。每次归约时以产生式编号调用 reduce，接受时不调用，reduce 可以为 nil
+func Parse(input []string, reduce func(prod int)) error {
+	stack := []int{0}
+	pos := 0
+	for {
+		symbol := "$"
+		if pos < len(input) {
+			symbol = input[pos]
+		}
+		state := stack[len(stack)-1]
+		id, ok := symbolIndex[symbol]
+		action := 0
+		if ok && id < terminals {
+			action, ok = lookup(actionIndex, actionData, state, id)
+		} else {
+			ok = false
+		}
+		if !ok {
+			return &SyntaxError{Pos: pos, Symbol: symbol, Expected: expected(state)}
+		}
+
+		switch {
+		case action > 0:
+			stack = append(stack, action-1)
+			pos++
+		case action == accept:
+			return nil
+		default:
+			prod := -action
+			if reduce != nil {
+				reduce(prod)
+			}
+			stack = stack[:len(stack)-len(Productions[prod].Right)]
+			next, ok := lookup(gotoIndex, gotoData, stack[len(stack)-1], symbolIndex[Productions[prod].Left])
+			if !ok {
+				return &SyntaxError{Pos: pos, Symbol: symbol, Expected: expected(state)}
+			}
+			stack = append(stack, next)
+		}
+	}
+}
+`))
+
+// 生成独立的 Go 语法分析器包的源代码：分析表以整数数组嵌入，不依赖本包与 lexer。
+// pkg 为包名，source 为文法文件名，只用于注释；flags 为生成时使用的参数，记录在 GeneratorFlags 中
+func (p *Parser) GenerateGo(w io.Writer, pkg, source, flags string) error {
+	if p.Action == nil {
+		return fmt.Errorf("分析表尚未构造")
+	}
+
+	// 终结符按出现顺序排在前面，$ 为第 0 个，非终结符在后
+	ids := map[string]int{"$": 0}
+	symbols := []string{"$"}
+	intern := func(symbol string) {
+		if _, ok := ids[symbol]; !ok {
+			ids[symbol] = len(symbols)
+			symbols = append(symbols, symbol)
+		}
+	}
+	for _, prod := range p.Productions {
+		for _, symbol := range prod.Right {
+			if p.isTerminal(symbol) {
+				intern(symbol)
+			}
+		}
+	}
+	terminals := len(symbols)
+	for _, prod := range p.Productions {
+		intern(prod.Left)
+	}
+
+	states := len(p.Action)
+	var actionIndex, actionData, gotoIndex, gotoData []int
+	for state := 0; state < states; state++ {
+		actionIndex = append(actionIndex, len(actionData))
+		for _, symbol := range sortedByID(p.Action[state], ids) {
+			action := p.Action[state][symbol]
+			code := 0
+			switch {
+			case action == "accept":
+				code = 0
+			case action[0] == 's':
+				fmt.Sscanf(action, "s%d", &code)
+				code++
+			default:
+				fmt.Sscanf(action, "r%d", &code)
+				if code == 0 {
+					return fmt.Errorf("状态 %d 在输入 %s 下归约增广产生式", state, symbol)
+				}
+				code = -code
+			}
+			actionData = append(actionData, ids[symbol], code)
+		}
+
+		gotoIndex = append(gotoIndex, len(gotoData))
+		symbols := make(map[string]string, len(p.Goto[state]))
+		for symbol := range p.Goto[state] {
+			symbols[symbol] = ""
+		}
+		for _, symbol := range sortedByID(symbols, ids) {
+			gotoData = append(gotoData, ids[symbol], p.Goto[state][symbol])
+		}
+	}
+	actionIndex = append(actionIndex, len(actionData))
+	gotoIndex = append(gotoIndex, len(gotoData))
+
+	var buf bytes.Buffer
+	err := generatedTemplate.Execute(&buf, map[string]any{
+		"Source":      source,
+		"Package":     pkg,
+		"Mode":        p.Mode,
+		"Hash":        p.hash,
+		"Flags":       flags,
+		"Productions": p.Productions,
+		"Symbols":     symbols,
+		"Terminals":   terminals,
+		"ActionIndex": intList(actionIndex),
+		"ActionData":  intList(actionData),
+		"GotoIndex":   intList(gotoIndex),
+		"GotoData":    intList(gotoData),
+	})
+	if err != nil {
+		return err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// 按符号编号排列表中一行的符号
+func sortedByID(row map[string]string, ids map[string]int) []string {
+	result := make([]string, 0, len(row))
+	for symbol := range row {
+		result = append(result, symbol)
+	}
+	sort.Slice(result, func(i, j int) bool { return ids[result[i]] < ids[result[j]] })
+	return result
+}
+
+// 每行 16 个整数的数组字面量内容
+func intList(values []int) string {
+	var sb strings.Builder
+	for i, v := range values {
+		if i%16 == 0 {
+			sb.WriteString("\n\t")
+		} else {
+			sb.WriteString(" ")
+		}
+		fmt.Fprintf(&sb, "%d,", v)
+	}
+	if len(values) > 0 {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
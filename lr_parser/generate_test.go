@@ -0,0 +1,109 @@
+package lr_parser
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateGo(t *testing.T) {
+	p := build(t, defaultGrammar, ModeLALR1)
+	var buf bytes.Buffer
+	if err := p.GenerateGo(&buf, "calc", "grammar.md", "-package calc"); err != nil {
+		t.Fatal(err)
+	}
+	src := buf.String()
+	for _, want := range []string{
+		"package calc",
+		`const GrammarHash = "` + GrammarHash(defaultGrammar, ModeLALR1) + `"`,
+		`const GeneratorFlags = "-package calc"`,
+		"const accept = 0",
+		"func Parse(input []string, reduce func(prod int)) error",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("生成的代码中没有 %q", want)
+		}
+	}
+}
+
+// 生成的代码能够编译，并且与分析表一样接受合法输入、拒绝非法输入
+func TestGeneratedParserRuns(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("没有找到 go 命令")
+	}
+	p := build(t, defaultGrammar, ModeLALR1)
+	var buf bytes.Buffer
+	if err := p.GenerateGo(&buf, "calc", "grammar.md", "-package calc"); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":         "module gentest\n\ngo 1.23\n",
+		"calc/parser.go": buf.String(),
+		// 输出每次归约的产生式左部，出错时输出错误并以状态 1 退出
+		"main.go": `package main
+
+import (
+	"fmt"
+	"gentest/calc"
+	"os"
+)
+
+func main() {
+	err := calc.Parse(os.Args[1:], func(prod int) {
+		fmt.Println(calc.Productions[prod].Left)
+	})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Println("accept")
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func(input string) (string, error) {
+		cmd := exec.Command(goTool, append([]string{"run", "."}, strings.Fields(input)...)...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=")
+		out, err := cmd.CombinedOutput()
+		return strings.TrimSpace(string(out)), err
+	}
+
+	out, err := run("main { id = num + id * ( num ) ; while ( id != num ) { } }")
+	if err != nil {
+		t.Fatalf("合法的输入分析失败: %v\n%s", err, out)
+	}
+	if lines := strings.Split(out, "\n"); len(lines) < 2 || lines[len(lines)-2] != "program" || lines[len(lines)-1] != "accept" {
+		t.Errorf("合法的输入的输出为\n%s", out)
+	}
+
+	out, err = run("main { id = ; }")
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Fatalf("非法的输入: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "第 5 个输入符号 ; 处语法错误，期望 id ( num") {
+		t.Errorf("非法的输入的输出为\n%s", out)
+	}
+}
+
+// 归约增广产生式与接受无法区分，生成代码时应当报错
+func TestGenerateGoRejectsReduceByAugmentedProduction(t *testing.T) {
+	p := build(t, "S -> S '+' id | id\n", ModeLR0)
+	p.Action[1]["id"] = "r0"
+	if err := p.GenerateGo(&bytes.Buffer{}, "p", "test.md", ""); err == nil {
+		t.Error("分析表中有 r0 时没有返回错误")
+	}
+}
@@ -4,3 +4,6 @@ go 1.23.2
 
 require mygo_c_compiler/lexer v0.0.0
 replace mygo_c_compiler/lexer => ../lexer
+
+require mygo_c_compiler/grammar v0.0.0
+replace mygo_c_compiler/grammar => ../grammar
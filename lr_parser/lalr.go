@@ -0,0 +1,169 @@
+package lr_parser
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// 合并同心项目集引入的归约/归约冲突：合并前的各个项目集在 Lookahead 上都没有冲突
+type MergeConflict struct {
+	State       int    // 合并后的项目集
+	Lookahead   string // 展望符
+	Productions []int  // 可以归约的产生式
+	From        []int  // 合并前的 LR(1) 项目集，编号与 ModeLR1 下相同
+}
+
+// 项目集的心：去掉展望符后的 LR(0) 项目，编码为排好序的 (产生式编号, 点的位置)
+func (p *Parser) core(set ItemSet) string {
+	seen := make(map[[2]int]bool)
+	var items [][2]int
+	for _, item := range set.Items {
+		key := [2]int{item.Prod.ID, item.Dot}
+		if !seen[key] {
+			seen[key] = true
+			items = append(items, key)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i][0] < items[j][0] || items[i][0] == items[j][0] && items[i][1] < items[j][1]
+	})
+	var sb strings.Builder
+	for _, item := range items {
+		fmt.Fprintf(&sb, "%d.%d ", item[0], item[1])
+	}
+	return sb.String()
+}
+
+// 把心相同的 LR(1) 项目集合并为一个项目集，展望符取并集，转换随之合并
+func (p *Parser) MergeCores() {
+	merged := make([]int, len(p.ItemSets)) // LR(1) 项目集 -> 合并后的项目集
+	from := make(map[int][]int)            // 合并后的项目集 -> LR(1) 项目集
+	cores := make(map[string]int)
+	var sets []ItemSet
+	var contains []map[Item]bool
+
+	for i, set := range p.ItemSets {
+		key := p.core(set)
+		index, exists := cores[key]
+		if !exists {
+			index = len(sets)
+			cores[key] = index
+			sets = append(sets, ItemSet{})
+			contains = append(contains, make(map[Item]bool))
+		}
+		merged[i] = index
+		from[index] = append(from[index], i)
+		for _, item := range set.Items {
+			if !contains[index][item] {
+				contains[index][item] = true
+				sets[index].Items = append(sets[index].Items, item)
+			}
+		}
+	}
+
+	transitions := make(map[int]map[string]int)
+	for i := range sets {
+		transitions[i] = make(map[string]int)
+	}
+	for i, symbolMap := range p.Transitions {
+		for symbol, to := range symbolMap {
+			transitions[merged[i]][symbol] = merged[to]
+		}
+	}
+
+	p.MergeConflicts = nil
+	for index, set := range sets {
+		for _, lookahead := range sortedLookaheads(set) {
+			prods := p.reductions(set, lookahead)
+			if len(prods) < 2 {
+				continue
+			}
+			introduced := true
+			for _, i := range from[index] {
+				if len(p.reductions(p.ItemSets[i], lookahead)) > 1 {
+					introduced = false
+					break
+				}
+			}
+			if introduced {
+				p.MergeConflicts = append(p.MergeConflicts, MergeConflict{
+					State:       index,
+					Lookahead:   lookahead,
+					Productions: prods,
+					From:        from[index],
+				})
+			}
+		}
+	}
+
+	p.ItemSets = sets
+	p.Transitions = transitions
+}
+
+// 项目集中所有归约项目的展望符
+func sortedLookaheads(set ItemSet) []string {
+	seen := make(map[string]bool)
+	for _, item := range set.Items {
+		if item.Dot >= len(item.Prod.Right) {
+			seen[item.Lookahead] = true
+		}
+	}
+	result := make([]string, 0, len(seen))
+	for lookahead := range seen {
+		result = append(result, lookahead)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// 项目集在展望符 lookahead 下可以归约的产生式
+func (p *Parser) reductions(set ItemSet, lookahead string) []int {
+	var result []int
+	seen := make(map[int]bool)
+	for _, item := range set.Items {
+		if item.Dot < len(item.Prod.Right) || item.Lookahead != lookahead {
+			continue
+		}
+		index := item.Prod.ID
+		if !seen[index] {
+			seen[index] = true
+			result = append(result, index)
+		}
+	}
+	sort.Ints(result)
+	return result
+}
+
+// 打印项目集数量。LALR(1) 模式下比较规范 LR(1) 与 LALR(1) 的项目集数量，并列出合并引入的归约/归约冲突
+func (p *Parser) PrintStateReport(w io.Writer) {
+	if p.Cached {
+		fmt.Fprintf(w, "%s 分析表从缓存加载，共 %d 个项目集\n", p.Mode, len(p.Action))
+		return
+	}
+	if p.usesLR0Items() {
+		fmt.Fprintf(w, "LR(0) 项目集数: %d\n", len(p.ItemSets))
+		return
+	}
+	fmt.Fprintf(w, "规范 LR(1) 项目集数: %d\n", p.canonicalStates)
+	if p.Mode != ModeLALR1 {
+		return
+	}
+	fmt.Fprintf(w, "LALR(1) 项目集数: %d\n", len(p.ItemSets))
+	if len(p.MergeConflicts) == 0 {
+		fmt.Fprintln(w, "合并同心项目集没有引入归约/归约冲突")
+		return
+	}
+	fmt.Fprintf(w, "合并同心项目集引入了 %d 处归约/归约冲突:\n", len(p.MergeConflicts))
+	for _, c := range p.MergeConflicts {
+		var from []string
+		for _, i := range c.From {
+			from = append(from, fmt.Sprintf("I%d", i))
+		}
+		fmt.Fprintf(w, "I%d（由 LR(1) 项目集 %s 合并）在展望符 %s 下:\n", c.State, strings.Join(from, ", "), c.Lookahead)
+		for _, prod := range c.Productions {
+			fmt.Fprintf(w, "    r%d: %s -> %s\n", prod, p.Productions[prod].Left, strings.Join(p.Productions[prod].Right, " "))
+		}
+	}
+}
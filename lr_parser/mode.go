@@ -0,0 +1,49 @@
+package lr_parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 分析表的构造方法
+type Mode int
+
+const (
+	ModeLR1   Mode = iota // 规范 LR(1)
+	ModeLALR1             // LALR(1)：合并 LR(1) 项目集规范族中的同心项目集
+	ModeLR0               // LR(0)：归约项目在所有输入符号下归约
+	ModeSLR1              // SLR(1)：LR(0) 项目集，归约项目只在左部的 FOLLOW 集中的符号下归约
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeLALR1:
+		return "LALR(1)"
+	case ModeLR0:
+		return "LR(0)"
+	case ModeSLR1:
+		return "SLR(1)"
+	default:
+		return "LR(1)"
+	}
+}
+
+// 解析命令行中的构造方法名：lr0、slr1、lr1 或 lalr1
+func ParseMode(name string) (Mode, error) {
+	switch strings.ToLower(name) {
+	case "lr1", "lr(1)":
+		return ModeLR1, nil
+	case "lalr1", "lalr(1)":
+		return ModeLALR1, nil
+	case "lr0", "lr(0)":
+		return ModeLR0, nil
+	case "slr1", "slr(1)", "slr":
+		return ModeSLR1, nil
+	}
+	return ModeLR1, fmt.Errorf("未知的分析表构造方法: %s", name)
+}
+
+// 项目集是否为不带展望符的 LR(0) 项目集
+func (p *Parser) usesLR0Items() bool {
+	return p.Mode == ModeLR0 || p.Mode == ModeSLR1
+}
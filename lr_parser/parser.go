@@ -1,11 +1,12 @@
 package lr_parser
 
 import (
-	"bufio"
+	_ "embed"
 	"fmt"
+	"mygo_c_compiler/grammar"
 	"mygo_c_compiler/lexer"
 	"os"
-	"regexp"
+	"slices"
 	"sort"
 	"strings"
 )
@@ -14,13 +15,31 @@ import (
 type Production struct {
 	Left  string   // 左部
 	Right []string // 右部
+	Prec  string   // %prec 指定的优先级符号，为空时使用右部最后一个有优先级的终结符
+	ID    int      // 产生式编号，即在 Productions 中的下标
 }
 
 // LR(1)项目
 type Item struct {
-	Prod      Production // 产生式
-	Dot       int        // 点的位置
-	Lookahead string     // 展望符
+	Prod      *Production // 产生式
+	Dot       int         // 点的位置
+	Lookahead string      // 展望符，LR(0) 项目为空
+}
+
+// 带点的产生式，如 E -> E · + F
+func (item Item) dotted() string {
+	rightPart := make([]string, len(item.Prod.Right)+1)
+	copy(rightPart, item.Prod.Right[:item.Dot])
+	rightPart[item.Dot] = "·"
+	copy(rightPart[item.Dot+1:], item.Prod.Right[item.Dot:])
+	return fmt.Sprintf("%s -> %s", item.Prod.Left, strings.Join(rightPart, " "))
+}
+
+func (item Item) String() string {
+	if item.Lookahead == "" {
+		return item.dotted()
+	}
+	return fmt.Sprintf("%s, 《%s》", item.dotted(), item.Lookahead)
 }
 
 // ��目集
@@ -33,229 +52,279 @@ type ActionTable map[int]map[string]string
 type GotoTable map[int]map[string]int
 
 type Parser struct {
+	Mode        Mode
 	Productions []Production
 	ItemSets    []ItemSet
+	Transitions map[int]map[string]int // 项目集之间的转换
 	Action      ActionTable
 	Goto        GotoTable
+
+	canonicalStates int             // 合并前规范 LR(1) 项目集的数量
+	MergeConflicts  []MergeConflict // 合并同心项目集引入的归约/归约冲突
+
+	Strict     bool                  // 分析表有冲突时拒绝构建
+	Conflicts  []Conflict            // 构建分析表时发现的冲突
+	Precedence map[string]Precedence // 终结符的优先级与结合性
+	Resolved   []ResolvedConflict    // 按优先级解决的移进/归约冲突
+
+	Analysis     *grammar.Analysis // 文法的可空性、FIRST/FOLLOW 集与发现的问题
+	nonTerminals map[string]bool   // 出现在产生式左部的符号
+
+	hash      string // 文法文本与构造方法的哈希
+	cachePath string // 分析表缓存文件
+	Cached    bool   // 分析表从缓存加载，没有项目集
 }
 
-// 创建新的解析器
-func New() *Parser {
-	parser := &Parser{}
-	// load grammar.md
-	file, err := os.Open("./lr_parser/grammar.md")
-	if err != nil {
-		fmt.Println("无法打开文件:", err)
-		return nil
+// 解析器选项
+type Option func(*Parser)
+
+// 选择分析表的构造方法，默认为规范 LR(1)
+func WithMode(mode Mode) Option {
+	return func(p *Parser) {
+		p.Mode = mode
 	}
-	defer file.Close()
+}
 
-	scanner := bufio.NewScanner(file)
-	var grammar strings.Builder
+// 严格模式：分析表有冲突时 New 返回 nil，BuildParsingTable 返回错误
+func WithStrict() Option {
+	return func(p *Parser) {
+		p.Strict = true
+	}
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		grammar.WriteString(line + "\n")
+// 使用分析表缓存文件，扩展名为 .json 时使用 JSON 格式，否则使用二进制格式
+func WithCache(path string) Option {
+	return func(p *Parser) {
+		p.cachePath = path
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Println("读取文件错误:", err)
-		return nil
+// 默认文法，编译时嵌入，不依赖当前工作目录
+//
+//go:embed grammar.md
+var defaultGrammar string
+
+// 使用内置文法创建新的解析器。指定了 WithCache 时，缓存中的分析表与文法、构造方法一致就直接加载，
+// 否则重新构造并写入缓存
+func New(opts ...Option) *Parser {
+	parser := &Parser{}
+	for _, opt := range opts {
+		opt(parser)
+	}
+	if parser.cachePath != "" && parser.loadCache(defaultGrammar) {
+		return parser
 	}
 
-	if err := parser.ParseGrammar(grammar.String()); err != nil {
+	if err := parser.ParseGrammar(defaultGrammar); err != nil {
 		fmt.Println("解析文法错误:", err)
 		return nil
 	}
+	for _, problem := range parser.Analysis.Problems() {
+		fmt.Println("文法警告:", problem)
+	}
 	parser.GenerateCanonicalCollection()
-	parser.BuildParsingTable()
+	if parser.Mode == ModeLALR1 {
+		parser.MergeCores()
+	}
+	if err := parser.BuildParsingTable(); err != nil {
+		fmt.Println("构建分析表错误:", err)
+		parser.PrintConflicts(os.Stdout)
+		return nil
+	}
 
+	if parser.cachePath != "" {
+		if err := parser.saveCache(); err != nil {
+			fmt.Println("写入分析表缓存错误:", err)
+		}
+	}
 	return parser
 }
 
-// 解析产生式
-func (p *Parser) ParseGrammar(grammar string) error {
-	lines := strings.Split(grammar, "\n")
-	regex := regexp.MustCompile(`(\w+)\s*->\s*(.+)`)
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+// 解析文法文件，格式见 grammar 包。开始符号有多个候选式或出现在右部时自动添加增广产生式，
+// 使第一条产生式总是 S' -> S
+func (p *Parser) ParseGrammar(text string) error {
+	g, err := grammar.Parse(text)
+	if err != nil {
+		return err
+	}
+	g = g.Augmented()
+	p.hash = GrammarHash(text, p.Mode)
+
+	// 后声明的优先级更高
+	p.Precedence = make(map[string]Precedence)
+	for i, decl := range g.Precedences {
+		assoc := AssocLeft
+		switch decl.Assoc {
+		case "right":
+			assoc = AssocRight
+		case "nonassoc":
+			assoc = AssocNonassoc
 		}
-
-		matches := regex.FindStringSubmatch(line)
-		if len(matches) != 3 {
-			continue
+		for _, symbol := range decl.Symbols {
+			p.Precedence[symbol] = Precedence{Level: i + 1, Assoc: assoc}
 		}
+	}
 
-		left := matches[1]
-		right := strings.Fields(matches[2])
-
-		if len(right) == 1 && right[0] == "ε" {
-			right = []string{}
-		}
+	p.Analysis = grammar.Analyze(g)
 
+	p.Productions = nil
+	p.nonTerminals = make(map[string]bool)
+	for i, prod := range g.Productions {
 		p.Productions = append(p.Productions, Production{
-			Left:  left,
-			Right: right,
+			Left:  prod.Left,
+			Right: prod.Right,
+			Prec:  prod.Prec,
+			ID:    i,
 		})
+		p.nonTerminals[prod.Left] = true
 	}
-
 	return nil
 }
 
-// 生成项目集规范簇
+// 生成项目集规范簇，构造过程见 builder
 func (p *Parser) GenerateCanonicalCollection() {
-	// 初始项目集
-	initialItem := Item{
-		Prod:      p.Productions[0],
-		Dot:       0,
-		Lookahead: "$",
-	}
-
-	initialSet := ItemSet{
-		Items: []Item{initialItem},
-	}
-
-	p.ItemSets = append(p.ItemSets, p.closure(initialSet))
-
-	// 继续生成其他项目集
-	for i := 0; i < len(p.ItemSets); i++ {
-		set := p.ItemSets[i]
-		// 获取所有可能的下一个符号
-		symbols := p.getNextSymbols(set)
-
-		for _, symbol := range symbols {
-			newSet := p.goto_(set, symbol)
-			if len(newSet.Items) == 0 {
-				continue
-			}
-
-			// 检查是否已经存在相同的项目集
-			existingIndex := p.findItemSetIndex(newSet)
-			if existingIndex == -1 {
-				p.ItemSets = append(p.ItemSets, newSet)
-			}
-		}
-	}
+	b := newBuilder(p)
+	b.build()
+	b.export()
+	p.canonicalStates = len(p.ItemSets)
 }
 
 // 构建LR(1)分析表
-func (p *Parser) BuildParsingTable() {
+func (p *Parser) BuildParsingTable() error {
 	p.Action = make(ActionTable)
 	p.Goto = make(GotoTable)
+	p.Conflicts = nil
+	p.Resolved = nil
 
 	for i := range p.ItemSets {
 		p.Action[i] = make(map[string]string)
 		p.Goto[i] = make(map[string]int)
 
+		// 先收集每个输入符号下的全部候选动作，再检查冲突
+		candidates := make(map[string][]string)
+		items := make(map[string][]Item)
+		add := func(symbol, action string, item Item) {
+			if !slices.Contains(candidates[symbol], action) {
+				candidates[symbol] = append(candidates[symbol], action)
+			}
+			items[symbol] = append(items[symbol], item)
+		}
+
 		set := p.ItemSets[i]
 		for _, item := range set.Items {
 			if item.Dot < len(item.Prod.Right) {
 				// 移进动作
 				symbol := item.Prod.Right[item.Dot]
-				nextSet := p.goto_(set, symbol)
-				nextIndex := p.findItemSetIndex(nextSet)
-				if nextIndex >= 0 {
+				if nextIndex, exists := p.Transitions[i][symbol]; exists {
 					if p.isTerminal(symbol) {
-						p.Action[i][symbol] = fmt.Sprintf("s%d", nextIndex)
+						add(symbol, fmt.Sprintf("s%d", nextIndex), item)
 					} else {
 						p.Goto[i][symbol] = nextIndex
 					}
 				}
 			} else {
-				// 规约动作
-				prodIndex := p.findProductionIndex(item.Prod)
-				if prodIndex == 0 && item.Lookahead == "$" {
-					p.Action[i]["$"] = "accept"
+				// 规约动作。增广产生式 S' -> S · 只在输入结束时接受，表中不会出现 r0
+				prodIndex := item.Prod.ID
+				if prodIndex == 0 {
+					add("$", "accept", item)
+					continue
+				}
+				for _, lookahead := range p.reduceLookaheads(item) {
+					add(lookahead, fmt.Sprintf("r%d", prodIndex), item)
+				}
+			}
+		}
+
+		symbols := make([]string, 0, len(candidates))
+		for symbol := range candidates {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+		for _, symbol := range symbols {
+			actions := sortActions(candidates[symbol])
+			p.Action[i][symbol] = actions[0]
+			if resolved, ok := p.resolveByPrecedence(i, symbol, actions); ok {
+				p.Resolved = append(p.Resolved, resolved)
+				if resolved.Chosen == "" {
+					delete(p.Action[i], symbol)
 				} else {
-					if _, exists := p.Action[i][item.Lookahead]; !exists {
-						p.Action[i][item.Lookahead] = fmt.Sprintf("r%d", prodIndex)
-					}
+					p.Action[i][symbol] = resolved.Chosen
 				}
+			} else if len(actions) > 1 {
+				p.Conflicts = append(p.Conflicts, Conflict{
+					State:     i,
+					Lookahead: symbol,
+					Actions:   actions,
+					Items:     items[symbol],
+				})
 			}
 		}
 	}
+
+	if p.Strict && len(p.Conflicts) > 0 {
+		p.Action, p.Goto = nil, nil
+		return fmt.Errorf("%s 分析表中有 %d 处冲突", p.Mode, len(p.Conflicts))
+	}
+	return nil
+}
+
+// 归约项目在哪些输入符号下归约：LR(1) 与 LALR(1) 为项目的展望符，
+// SLR(1) 为左部的 FOLLOW 集，LR(0) 为全部终结符
+func (p *Parser) reduceLookaheads(item Item) []string {
+	switch p.Mode {
+	case ModeLR0:
+		return append(p.getTerminals(), "$")
+	case ModeSLR1:
+		return p.getFollowSet(item.Prod.Left)
+	default:
+		return []string{item.Lookahead}
+	}
 }
 
-// 获取所有终结符
-// func (p *Parser) getTerminals() []string {
-//     terminals := make(map[string]bool)
-
-//     // 遍历所有产生式的右部
-//     for _, prod := range p.Productions {
-//         for _, symbol := range prod.Right {
-//             if p.isTerminal(symbol) {
-//                 terminals[symbol] = true
-//             }
-//         }
-//     }
-
-//     // 转换为切片
-//     result := make([]string, 0)
-//     for terminal := range terminals {
-//         result = append(result, terminal)
-//     }
-//     return result
-// }
+// 获取所有终结符，按字典序排列
+func (p *Parser) getTerminals() []string {
+	terminals := make(map[string]bool)
+
+	// 遍历所有产生式的右部
+	for _, prod := range p.Productions {
+		for _, symbol := range prod.Right {
+			if p.isTerminal(symbol) {
+				terminals[symbol] = true
+			}
+		}
+	}
+
+	// 转换为切片
+	result := make([]string, 0)
+	for terminal := range terminals {
+		result = append(result, terminal)
+	}
+	sort.Strings(result)
+	return result
+}
 
 // 执行语法分析
 func (p *Parser) Parse(tokens []lexer.Token) bool {
+	return p.ParseStream(lexer.NewSliceStream(tokens))
+}
+
+// 从 Token 流中读取输入并进行 LR 分析
+func (p *Parser) ParseStream(tokens *lexer.TokenStream) bool {
 	stack := []int{0}     // 状态栈
 	symbols := []string{} // 符号栈
 	actions := []string{} // 动作序列
 
-	// 将词法单元转换为语法符号的辅助函数
-	tokenToSymbol := func(tok lexer.Token) string {
-		switch tok.Type {
-		case lexer.MAIN:
-			return "main"
-		case lexer.IDENT:
-			return "id"
-		case lexer.NUMBER:
-			return "num"
-		case lexer.LBRACE:
-			return "{"
-		case lexer.RBRACE:
-			return "}"
-		case lexer.LPAREN:
-			return "("
-		case lexer.RPAREN:
-			return ")"
-		case lexer.ASSIGN:
-			return "="
-		case lexer.SEMICOLON:
-			return ";"
-		case lexer.WHILE:
-			return "while"
-		case lexer.LTE:
-			return "<="
-		case lexer.GTE:
-			return ">="
-		case lexer.PLUS:
-			return "+"
-		case lexer.ASTERISK:
-			return "*"
-		default:
-			return string(tok.Type)
-		}
-	}
-
-	i := 0
 	for {
 		state := stack[len(stack)-1]
-		var symbol string
-		if i < len(tokens) {
-			symbol = tokenToSymbol(tokens[i])
-		} else {
-			symbol = "$"
-		}
+		tok := tokens.Peek(1)
+		symbol := tok.GrammarSymbol()
 
 		action, exists := p.Action[state][symbol]
 		if !exists {
-			fmt.Printf("\n语法错误: 状态%d下无法处理符号%s\n", state, symbol)
-			fmt.Printf("当前令牌: %v\n", tokens[i])
+			fmt.Printf("\n%s: 语法错误: 状态%d下无法处理符号%s\n", tok.Span.Start, state, symbol)
+			if tok.Type != lexer.EOF {
+				fmt.Printf("当前令牌: %v\n", tok)
+			}
 			fmt.Printf("当前分析栈: %v\n符号栈: %v\n输入: %s\n", stack, symbols, symbol)
 			return false
 		}
@@ -274,7 +343,7 @@ func (p *Parser) Parse(tokens []lexer.Token) bool {
 			actions = append(actions, actionStr)
 			fmt.Printf("%s\n当前状态栈: %v\n符号栈: %v\n\n", actionStr, stack, symbols)
 
-			i++
+			tokens.Next()
 		} else if action[0] == 'r' { // 规约
 			prodIndex := 0
 			if _, err := fmt.Sscanf(action, "r%d", &prodIndex); err != nil {
@@ -283,26 +352,18 @@ func (p *Parser) Parse(tokens []lexer.Token) bool {
 			}
 			prod := p.Productions[prodIndex]
 
-			// 初始化 nextState 变量
-			nextState := 0
-
-			// 检查是否是空产生式的归约
-			if len(prod.Right) == 1 && prod.Right[0] == "ε" {
-				// 直接在符号栈中压入左部符号
-				symbols = append(symbols, prod.Left)
-			} else {
-				// 弹出右部长度个状态和符号
-				stack = stack[:len(stack)-len(prod.Right)]
-				symbols = symbols[:len(symbols)-len(prod.Right)]
-
-				// 压入左部符号
-				symbols = append(symbols, prod.Left)
+			// 弹出右部长度个状态和符号，压入左部符号
+			stack = stack[:len(stack)-len(prod.Right)]
+			symbols = append(symbols[:len(symbols)-len(prod.Right)], prod.Left)
 
-				// 查找GOTO表确定下一个状态
-				state = stack[len(stack)-1]
-				nextState = p.Goto[state][prod.Left]
-				stack = append(stack, nextState)
+			// 查找GOTO表确定下一个状态
+			state = stack[len(stack)-1]
+			nextState, exists := p.Goto[state][prod.Left]
+			if !exists {
+				fmt.Printf("\n%s: 语法错误: 状态%d下没有 %s 的转移\n", tok.Span.Start, state, prod.Left)
+				return false
 			}
+			stack = append(stack, nextState)
 
 			// 记录规约动作
 			actionStr := fmt.Sprintf("规约: 使用产生式 %s -> %s", prod.Left, strings.Join(prod.Right, " "))
@@ -323,319 +384,26 @@ func (p *Parser) Parse(tokens []lexer.Token) bool {
 	}
 }
 
-// 判断是否为终结符
+// 判断是否为终结符：不出现在任何产生式左部的符号
 func (p *Parser) isTerminal(symbol string) bool {
-	// 检查symbol是否出现在任何产生式的左部
-	for _, prod := range p.Productions {
-		if prod.Left == symbol {
-			return false
-		}
-	}
-	return true
-}
-
-// 判断是否为非终结符
-func (p *Parser) isNonTerminal(symbol string) bool {
-	return !p.isTerminal(symbol)
-}
-
-func (p *Parser) closure(set ItemSet) ItemSet {
-	result := ItemSet{
-		Items: make([]Item, len(set.Items)),
-	}
-	copy(result.Items, set.Items)
-
-	changed := true
-	for changed {
-		changed = false
-		size := len(result.Items)
-
-		// 遍历当前项目集中的所有项目
-		for i := 0; i < size; i++ {
-			item := result.Items[i]
-
-			// 如果点后面没有符号，继续下一个项目
-			if item.Dot >= len(item.Prod.Right) {
-				continue
-			}
-
-			// 获取点后面的符号
-			nextSymbol := item.Prod.Right[item.Dot]
-
-			// 如果点后面的符号是非终结符
-			if p.isNonTerminal(nextSymbol) {
-				// 计算FIRST(βa)
-				lookaheads := p.computeFirst(item.Prod.Right[item.Dot+1:], item.Lookahead)
-
-				// 查找以该非终结符为左部的所有产生式
-				for _, prod := range p.Productions {
-					if prod.Left == nextSymbol {
-						for _, lookahead := range lookaheads {
-							// 创建新项目
-							newItem := Item{
-								Prod:      prod,
-								Dot:       0,
-								Lookahead: lookahead,
-							}
-
-							// 检查新项目是否已存在
-							exists := false
-							for _, existingItem := range result.Items {
-								if p.itemsEqual(existingItem, newItem) {
-									exists = true
-									break
-								}
-							}
-
-							// 如果不存在则添加
-							if !exists {
-								result.Items = append(result.Items, newItem)
-								changed = true
-							}
-						}
-					}
-				}
-
-				// // 如果E可推导出空字符串，生成E->#
-				// if p.canDeriveEmpty(nextSymbol) {
-				//     newItem := Item{
-				//         Prod: Production{Left: nextSymbol, Right: []string{"#"}},
-				//         Dot:  1,
-				//         Lookahead: item.Lookahead,
-				//     }
-				//     result.Items = append(result.Items, newItem)
-				//     changed = true
-				// }
-			}
-		}
-	}
-
-	return result
+	return !p.nonTerminals[symbol]
 }
 
-// // 判断非终结符是否可推导出空字符串
-//
-//	func (p *Parser) canDeriveEmpty(symbol string) bool {
-//	    for _, prod := range p.Productions {
-//	        if prod.Left == symbol && len(prod.Right) == 1 && prod.Right[0] == "ε" {
-//	            return true
-//	        }
-//	    }
-//	    return false
-//	}
-//
-// 计算FIRST集合
-func (p *Parser) computeFirst(symbols []string, lookahead string) []string {
-	if len(symbols) == 0 {
-		return []string{lookahead}
-	}
-
-	firstSet := make(map[string]bool)
-	for _, symbol := range symbols {
-		if p.isTerminal(symbol) {
-			firstSet[symbol] = true
-			break
-		}
-
-		nullable := false
-		for _, prod := range p.Productions {
-			if prod.Left == symbol {
-				if len(prod.Right) == 1 && prod.Right[0] == "ε" {
-					nullable = true
-				} else {
-					for _, sym := range p.computeFirst(prod.Right, lookahead) {
-						firstSet[sym] = true
-					}
-				}
-			}
-		}
-
-		if !nullable {
-			break
-		}
-	}
-
-	// 将 lookahead 添加到 firstSet 中
-	firstSet[lookahead] = true
-
-	result := make([]string, 0, len(firstSet))
-	for sym := range firstSet {
-		result = append(result, sym)
-	}
-	return result
+// 获取FOLLOW集，按字典序排列
+func (p *Parser) getFollowSet(symbol string) []string {
+	return sortedSet(p.Analysis.Follow[symbol])
 }
 
-// 计算GOTO函数
-func (p *Parser) goto_(set ItemSet, symbol string) ItemSet {
-	resultSet := ItemSet{
-		Items: make([]Item, 0),
-	}
-
-	// 遍历项目集中的所有项目
-	for _, item := range set.Items {
-		// 如果点已经到达末尾，继续下一个项目
-		if item.Dot >= len(item.Prod.Right) {
-			continue
-		}
-
-		// 如果点后面的符号匹配
-		if item.Prod.Right[item.Dot] == symbol {
-			// 创建新项目，将点向后移动一位
-			newItem := Item{
-				Prod:      item.Prod,
-				Dot:       item.Dot + 1,
-				Lookahead: item.Lookahead,
-			}
-			resultSet.Items = append(resultSet.Items, newItem)
-		}
-	}
-
-	// 如果结果集非空，计算其闭包
-	if len(resultSet.Items) > 0 {
-		return p.closure(resultSet)
-	}
-
-	return resultSet
-}
-
-// 获取项目集中所有可能的下一个符号
-func (p *Parser) getNextSymbols(set ItemSet) []string {
-	symbols := make(map[string]bool)
-	for _, item := range set.Items {
-		if item.Dot < len(item.Prod.Right) {
-			symbols[item.Prod.Right[item.Dot]] = true
-		}
-	}
-
-	result := make([]string, 0)
-	for symbol := range symbols {
+// 集合中的元素，按字典序排列
+func sortedSet(set map[string]bool) []string {
+	result := make([]string, 0, len(set))
+	for symbol := range set {
 		result = append(result, symbol)
 	}
+	sort.Strings(result)
 	return result
 }
 
-// 查找项目集的索引
-func (p *Parser) findItemSetIndex(set ItemSet) int {
-	for i, existingSet := range p.ItemSets {
-		if p.itemSetsEqual(existingSet, set) {
-			return i
-		}
-	}
-	return -1
-}
-
-// 比较两个项目集是否相等
-func (p *Parser) itemSetsEqual(set1, set2 ItemSet) bool {
-	if len(set1.Items) != len(set2.Items) {
-		return false
-	}
-
-	for _, item1 := range set1.Items {
-		found := false
-		for _, item2 := range set2.Items {
-			if p.itemsEqual(item1, item2) {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return false
-		}
-	}
-	return true
-}
-
-// 比较两个项目是否相等
-func (p *Parser) itemsEqual(item1, item2 Item) bool {
-	return item1.Dot == item2.Dot &&
-		item1.Prod.Left == item2.Prod.Left &&
-		strings.Join(item1.Prod.Right, " ") == strings.Join(item2.Prod.Right, " ") &&
-		item1.Lookahead == item2.Lookahead
-}
-
-// 查找产生式的索引
-func (p *Parser) findProductionIndex(prod Production) int {
-	for i, existingProd := range p.Productions {
-		if existingProd.Left == prod.Left &&
-			strings.Join(existingProd.Right, " ") == strings.Join(prod.Right, " ") {
-			return i
-		}
-	}
-	return -1
-}
-
-// // 获取FOLLOW集
-// func (p *Parser) getFollowSet(symbol string) []string {
-//     follows := make(map[string]bool)
-
-//     // 初始化FOLLOW集
-//     if symbol == p.Productions[0].Left {
-//         follows["$"] = true
-//     }
-
-//     changed := true
-//     for changed {
-//         changed = false
-
-//         // 遍历所有产生式
-//         for _, prod := range p.Productions {
-//             for i, sym := range prod.Right {
-//                 if sym == symbol {
-//                     // 如果是最后一个符号
-//                     if i == len(prod.Right)-1 {
-//                         // 添加产生式左部的FOLLOW集
-//                         if prod.Left != symbol { // 避免左递归
-//                             for _, follow := range p.getFollowSet(prod.Left) {
-//                                 if !follows[follow] {
-//                                     follows[follow] = true
-//                                     changed = true
-//                                 }
-//                             }
-//                         }
-//                     } else {
-//                         // 添加后面符号的FIRST集
-//                         nextSymbols := prod.Right[i+1:]
-//                         first := p.computeFirst(nextSymbols, "$")
-//                         for _, f := range first {
-//                             if f != "ε" && !follows[f] {
-//                                 follows[f] = true
-//                                 changed = true
-//                             }
-//                         }
-
-//                         // 如果FIRST集包含ε，添加产生式左部的FOLLOW集
-//                         if containsEpsilon(first) {
-//                             for _, follow := range p.getFollowSet(prod.Left) {
-//                                 if !follows[follow] {
-//                                     follows[follow] = true
-//                                     changed = true
-//                                 }
-//                             }
-//                         }
-//                     }
-//                 }
-//             }
-//         }
-//     }
-
-//     result := make([]string, 0)
-//     for follow := range follows {
-//         result = append(result, follow)
-//     }
-//     return result
-// }
-
-// // 检查FIRST集是否包含ε
-//
-//	func containsEpsilon(first []string) bool {
-//	    for _, f := range first {
-//	        if f == "ε" {
-//	            return true
-//	        }
-//	    }
-//	    return false
-//	}
-//
 // 打印项目集规范族为 .dot 文件，每个项目集为方形节点
 func (p *Parser) PrintItemSets(filename string) error {
 	file, err := os.Create(filename)
@@ -644,41 +412,30 @@ func (p *Parser) PrintItemSets(filename string) error {
 	}
 	defer file.Close()
 
-	// 计算并存储所有转换
-	transitions := make(map[int]map[string]int)
-	for i, set := range p.ItemSets {
-		transitions[i] = make(map[string]int)
-		symbols := p.getNextSymbols(set)
-
-		for _, symbol := range symbols {
-			nextSet := p.goto_(set, symbol)
-			nextIndex := p.findItemSetIndex(nextSet)
-			if nextIndex != -1 {
-				transitions[i][symbol] = nextIndex
-			}
-		}
-	}
-
 	fmt.Fprintln(file, "digraph ItemSets {")
 	fmt.Fprintln(file, "    rankdir=LR;")
 	fmt.Fprintln(file, "    node [shape=rectangle];")
 
 	// 定义节点
 	for i, set := range p.ItemSets {
-		var productions []string
-		productionsMap := make(map[string][]string)
-
+		// 同一个带点产生式的展望符合并显示，按项目在项目集中的顺序排列
+		var dotted []string
+		lookaheads := make(map[string][]string)
 		for _, item := range set.Items {
-			rightPart := make([]string, len(item.Prod.Right)+1)
-			copy(rightPart, item.Prod.Right[:item.Dot])
-			rightPart[item.Dot] = "·"
-			copy(rightPart[item.Dot+1:], item.Prod.Right[item.Dot:])
-			dottedProd := fmt.Sprintf("%s -> %s", item.Prod.Left, strings.Join(rightPart, " "))
-			productionsMap[dottedProd] = append(productionsMap[dottedProd], item.Lookahead)
+			prod := item.dotted()
+			if _, exists := lookaheads[prod]; !exists {
+				dotted = append(dotted, prod)
+			}
+			lookaheads[prod] = append(lookaheads[prod], item.Lookahead)
 		}
 
-		for prod, lookaheads := range productionsMap {
-			productions = append(productions, fmt.Sprintf("%s, 《%s》", prod, strings.Join(lookaheads, ", ")))
+		var productions []string
+		for _, prod := range dotted {
+			if p.usesLR0Items() {
+				productions = append(productions, prod)
+			} else {
+				productions = append(productions, fmt.Sprintf("%s, 《%s》", prod, strings.Join(lookaheads[prod], ", ")))
+			}
 		}
 
 		label := fmt.Sprintf("I%d:\\n%s", i, strings.Join(productions, "\\n"))
@@ -686,9 +443,14 @@ func (p *Parser) PrintItemSets(filename string) error {
 	}
 
 	// 使用计算好的transitions定义边
-	for from, symbolMap := range transitions {
-		for symbol, to := range symbolMap {
-			fmt.Fprintf(file, "    I%d -> I%d [label=\"%s\"];\n", from, to, symbol)
+	for from := range p.ItemSets {
+		symbols := make([]string, 0, len(p.Transitions[from]))
+		for symbol := range p.Transitions[from] {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+		for _, symbol := range symbols {
+			fmt.Fprintf(file, "    I%d -> I%d [label=\"%s\"];\n", from, p.Transitions[from][symbol], symbol)
 		}
 	}
 
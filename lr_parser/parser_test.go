@@ -0,0 +1,138 @@
+package lr_parser
+
+import (
+	"mygo_c_compiler/lexer"
+	"testing"
+)
+
+var allModes = []Mode{ModeLR0, ModeSLR1, ModeLR1, ModeLALR1}
+
+// 用给定的文法与构造方法构造分析表
+func build(t testing.TB, text string, mode Mode) *Parser {
+	t.Helper()
+	p := &Parser{Mode: mode}
+	if err := p.ParseGrammar(text); err != nil {
+		t.Fatalf("解析文法错误: %v", err)
+	}
+	p.GenerateCanonicalCollection()
+	if mode == ModeLALR1 {
+		p.MergeCores()
+	}
+	if err := p.BuildParsingTable(); err != nil {
+		t.Fatalf("构建分析表错误: %v", err)
+	}
+	return p
+}
+
+func parse(p *Parser, source string) bool {
+	return p.ParseStream(lexer.NewTokenStream(lexer.NewLexer(source, lexer.WithDialect(lexer.DialectCourse))))
+}
+
+func TestDefaultGrammarTables(t *testing.T) {
+	tests := []struct {
+		mode      Mode
+		states    int
+		conflicts int
+	}{
+		{ModeLR0, 44, 14},
+		{ModeSLR1, 44, 0},
+		{ModeLR1, 63, 0},
+		{ModeLALR1, 44, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			p := build(t, defaultGrammar, tt.mode)
+			if len(p.ItemSets) != tt.states {
+				t.Errorf("项目集数 = %d, 期望 %d", len(p.ItemSets), tt.states)
+			}
+			if len(p.Conflicts) != tt.conflicts {
+				t.Errorf("冲突数 = %d, 期望 %d", len(p.Conflicts), tt.conflicts)
+			}
+			if tt.conflicts > 0 {
+				return
+			}
+			for _, source := range []string{
+				"main { a = 1 + b * (c + 2); while (a <= 10) { a = a + 1; } }",
+				"main { x = 0x10 + 010 * 1.5; }",
+				"main { while (a != b) while (a == 1) { } }",
+			} {
+				if !parse(p, source) {
+					t.Errorf("合法的程序 %q 分析失败", source)
+				}
+			}
+			if parse(p, "main { a = ; }") {
+				t.Error("非法的程序分析成功")
+			}
+		})
+	}
+}
+
+// 增广产生式只在 $ 下接受，LR(0) 文法不应因此出现冲突
+func TestAugmentedProductionAcceptsOnlyAtEnd(t *testing.T) {
+	const text = "S -> S '+' id | id\n"
+	for _, mode := range allModes {
+		t.Run(mode.String(), func(t *testing.T) {
+			p := build(t, text, mode)
+			if len(p.Conflicts) != 0 {
+				t.Errorf("冲突数 = %d, 期望 0: %v", len(p.Conflicts), p.Conflicts)
+			}
+			for state, row := range p.Action {
+				for symbol, action := range row {
+					if action == "r0" {
+						t.Errorf("状态 %d 在输入 %s 下归约第 0 条产生式", state, symbol)
+					}
+					if action == "accept" && symbol != "$" {
+						t.Errorf("状态 %d 在输入 %s 下接受", state, symbol)
+					}
+				}
+			}
+
+			tests := []struct {
+				input string
+				want  bool
+			}{
+				{"a", true},
+				{"a + b + c", true},
+				{"a b", false},
+				{"a +", false},
+				{"", false},
+			}
+			for _, tt := range tests {
+				if got := parse(p, tt.input); got != tt.want {
+					t.Errorf("分析 %q = %v, 期望 %v", tt.input, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPrecedenceResolvesConflicts(t *testing.T) {
+	const text = `%token id
+%left '+'
+%left '*'
+E -> E '+' E | E '*' E | id
+`
+	for _, mode := range allModes[1:] {
+		p := build(t, text, mode)
+		if len(p.Conflicts) != 0 {
+			t.Errorf("%s: 冲突数 = %d, 期望 0", mode, len(p.Conflicts))
+		}
+		if len(p.Resolved) == 0 {
+			t.Errorf("%s: 没有按优先级解决的冲突", mode)
+		}
+		if !parse(p, "a + b * c + d") {
+			t.Errorf("%s: 分析失败", mode)
+		}
+	}
+}
+
+func TestStrictRejectsConflicts(t *testing.T) {
+	p := &Parser{Mode: ModeLR0, Strict: true}
+	if err := p.ParseGrammar(defaultGrammar); err != nil {
+		t.Fatal(err)
+	}
+	p.GenerateCanonicalCollection()
+	if err := p.BuildParsingTable(); err == nil {
+		t.Error("严格模式下有冲突的分析表没有返回错误")
+	}
+}
@@ -0,0 +1,112 @@
+package lr_parser
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// 结合性
+type Assoc int
+
+const (
+	AssocLeft     Assoc = iota // %left
+	AssocRight                 // %right
+	AssocNonassoc              // %nonassoc
+)
+
+func (a Assoc) String() string {
+	switch a {
+	case AssocRight:
+		return "右结合"
+	case AssocNonassoc:
+		return "不结合"
+	default:
+		return "左结合"
+	}
+}
+
+// 终结符的优先级，Level 越大优先级越高
+type Precedence struct {
+	Level int
+	Assoc Assoc
+}
+
+// 按优先级解决的移进/归约冲突
+type ResolvedConflict struct {
+	State     int
+	Lookahead string
+	Shift     string // 移进动作
+	Reduce    string // 归约动作
+	Chosen    string // 表中保留的动作，为空表示 %nonassoc 使该输入成为语法错误
+	Reason    string
+}
+
+// 产生式的优先级：%prec 指定的符号，否则为右部最后一个有优先级的终结符
+func (p *Parser) productionPrecedence(prod Production) (Precedence, bool) {
+	if prod.Prec != "" {
+		return p.Precedence[prod.Prec], true
+	}
+	for i := len(prod.Right) - 1; i >= 0; i-- {
+		if prec, exists := p.Precedence[prod.Right[i]]; exists && p.isTerminal(prod.Right[i]) {
+			return prec, true
+		}
+	}
+	return Precedence{}, false
+}
+
+// 像 yacc 一样按优先级解决移进/归约冲突：比较产生式与输入符号的优先级，
+// 相同时按结合性决定，左结合归约、右结合移进、不结合报错。
+// 只处理一个移进与一个归约之间的冲突，并且双方都有优先级
+func (p *Parser) resolveByPrecedence(state int, symbol string, actions []string) (ResolvedConflict, bool) {
+	if len(actions) != 2 || actions[0][0] != 's' || actions[1][0] != 'r' {
+		return ResolvedConflict{}, false
+	}
+	tokenPrec, ok := p.Precedence[symbol]
+	if !ok {
+		return ResolvedConflict{}, false
+	}
+	prodIndex, _ := strconv.Atoi(actions[1][1:])
+	prodPrec, ok := p.productionPrecedence(p.Productions[prodIndex])
+	if !ok {
+		return ResolvedConflict{}, false
+	}
+
+	resolved := ResolvedConflict{State: state, Lookahead: symbol, Shift: actions[0], Reduce: actions[1]}
+	switch {
+	case prodPrec.Level > tokenPrec.Level:
+		resolved.Chosen = actions[1]
+		resolved.Reason = "产生式的优先级较高"
+	case prodPrec.Level < tokenPrec.Level:
+		resolved.Chosen = actions[0]
+		resolved.Reason = fmt.Sprintf("%s 的优先级较高", symbol)
+	case tokenPrec.Assoc == AssocLeft:
+		resolved.Chosen = actions[1]
+		resolved.Reason = "优先级相同，左结合"
+	case tokenPrec.Assoc == AssocRight:
+		resolved.Chosen = actions[0]
+		resolved.Reason = "优先级相同，右结合"
+	default:
+		resolved.Reason = "优先级相同，不结合"
+	}
+	return resolved, true
+}
+
+// 打印所有按优先级解决的冲突
+func (p *Parser) PrintResolved(w io.Writer) {
+	if len(p.Resolved) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "按优先级解决了 %d 处移进/归约冲突:\n", len(p.Resolved))
+	for _, r := range p.Resolved {
+		prodIndex, _ := strconv.Atoi(r.Reduce[1:])
+		prod := p.Productions[prodIndex]
+		chosen := r.Chosen
+		if chosen == "" {
+			chosen = "报错"
+		}
+		fmt.Fprintf(w, "I%d 在输入 %s 下: %s 与 %s（%s -> %s），%s，选择 %s\n",
+			r.State, r.Lookahead, r.Shift, r.Reduce, prod.Left, strings.Join(prod.Right, " "), r.Reason, chosen)
+	}
+}
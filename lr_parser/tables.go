@@ -0,0 +1,143 @@
+package lr_parser
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// 分析表格式的版本，格式改变时递增，使旧的缓存失效
+const tablesVersion = 1
+
+// 可以保存与加载的分析表，包含语法分析与打印冲突所需的全部信息，不包含项目集
+type Tables struct {
+	Hash        string // 文法文本与构造方法的哈希，用于判断缓存是否过期
+	Mode        Mode
+	Productions []Production
+	Action      ActionTable
+	Goto        GotoTable
+	Transitions map[int]map[string]int
+	Conflicts   []Conflict
+	Resolved    []ResolvedConflict
+}
+
+// 文法文本与构造方法的哈希
+func GrammarHash(text string, mode Mode) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d\n%s\n%s", tablesVersion, mode, text)))
+	return hex.EncodeToString(sum[:])
+}
+
+// 导出构造好的分析表
+func (p *Parser) Tables() *Tables {
+	return &Tables{
+		Hash:        p.hash,
+		Mode:        p.Mode,
+		Productions: p.Productions,
+		Action:      p.Action,
+		Goto:        p.Goto,
+		Transitions: p.Transitions,
+		Conflicts:   p.Conflicts,
+		Resolved:    p.Resolved,
+	}
+}
+
+// 由保存的分析表创建解析器，解析器没有项目集，不能再构造分析表
+func NewFromTables(t *Tables) *Parser {
+	p := &Parser{
+		Mode:         t.Mode,
+		Productions:  t.Productions,
+		Action:       t.Action,
+		Goto:         t.Goto,
+		Transitions:  t.Transitions,
+		Conflicts:    t.Conflicts,
+		Resolved:     t.Resolved,
+		hash:         t.Hash,
+		nonTerminals: make(map[string]bool),
+		Cached:       true,
+	}
+	for i := range p.Productions {
+		p.nonTerminals[p.Productions[i].Left] = true
+	}
+	return p
+}
+
+// 以 JSON 格式写出分析表
+func (t *Tables) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", " ")
+	return encoder.Encode(t)
+}
+
+// 读入 JSON 格式的分析表
+func ReadTablesJSON(r io.Reader) (*Tables, error) {
+	t := &Tables{}
+	if err := json.NewDecoder(r).Decode(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// 以二进制格式（encoding/gob）写出分析表
+func (t *Tables) WriteBinary(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(t)
+}
+
+// 读入二进制格式的分析表
+func ReadTablesBinary(r io.Reader) (*Tables, error) {
+	t := &Tables{}
+	if err := gob.NewDecoder(r).Decode(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// 读入分析表文件，按扩展名选择格式
+func ReadTablesFile(path string) (*Tables, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if filepath.Ext(path) == ".json" {
+		return ReadTablesJSON(file)
+	}
+	return ReadTablesBinary(file)
+}
+
+// 写出分析表文件，按扩展名选择格式
+func (t *Tables) WriteFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if filepath.Ext(path) == ".json" {
+		err = t.WriteJSON(file)
+	} else {
+		err = t.WriteBinary(file)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// 缓存与文法、构造方法一致时加载缓存中的分析表。严格模式下不使用有冲突的分析表
+func (p *Parser) loadCache(text string) bool {
+	t, err := ReadTablesFile(p.cachePath)
+	if err != nil || t.Hash != GrammarHash(text, p.Mode) || p.Strict && len(t.Conflicts) > 0 {
+		return false
+	}
+	strict, cachePath := p.Strict, p.cachePath
+	*p = *NewFromTables(t)
+	p.Strict, p.cachePath = strict, cachePath
+	return true
+}
+
+func (p *Parser) saveCache() error {
+	return p.Tables().WriteFile(p.cachePath)
+}
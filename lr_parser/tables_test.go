@@ -0,0 +1,85 @@
+package lr_parser
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestTablesRoundTrip(t *testing.T) {
+	p := build(t, defaultGrammar, ModeLR0)
+	want := p.Tables()
+
+	tests := []struct {
+		name  string
+		write func(*Tables, *bytes.Buffer) error
+		read  func(*bytes.Buffer) (*Tables, error)
+	}{
+		{
+			"JSON",
+			func(t *Tables, buf *bytes.Buffer) error { return t.WriteJSON(buf) },
+			func(buf *bytes.Buffer) (*Tables, error) { return ReadTablesJSON(buf) },
+		},
+		{
+			"gob",
+			func(t *Tables, buf *bytes.Buffer) error { return t.WriteBinary(buf) },
+			func(buf *bytes.Buffer) (*Tables, error) { return ReadTablesBinary(buf) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(want, &buf); err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.read(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Hash != want.Hash || got.Mode != want.Mode {
+				t.Errorf("Hash, Mode = %s, %s, 期望 %s, %s", got.Hash, got.Mode, want.Hash, want.Mode)
+			}
+			if !reflect.DeepEqual(got.Action, want.Action) || !reflect.DeepEqual(got.Goto, want.Goto) {
+				t.Error("ACTION 或 GOTO 表不一致")
+			}
+			if !reflect.DeepEqual(got.Transitions, want.Transitions) {
+				t.Error("转换不一致")
+			}
+			// gob 把空切片读为 nil，按内容比较产生式
+			for i, prod := range want.Productions {
+				g := got.Productions[i]
+				if g.Left != prod.Left || !slices.Equal(g.Right, prod.Right) || g.Prec != prod.Prec || g.ID != prod.ID {
+					t.Errorf("产生式 %d = %v, 期望 %v", i, g, prod)
+				}
+			}
+			if len(got.Conflicts) != len(want.Conflicts) {
+				t.Errorf("冲突数 = %d, 期望 %d", len(got.Conflicts), len(want.Conflicts))
+			}
+			if !parse(NewFromTables(got), "main { a = 1; }") {
+				t.Error("由读入的分析表创建的解析器分析失败")
+			}
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	for _, name := range []string{"tables.bin", "tables.json"} {
+		path := filepath.Join(t.TempDir(), name)
+		first := New(WithMode(ModeLALR1), WithCache(path))
+		if first == nil || first.Cached {
+			t.Fatalf("%s: 第一次应当构造分析表", name)
+		}
+		second := New(WithMode(ModeLALR1), WithCache(path))
+		if second == nil || !second.Cached {
+			t.Fatalf("%s: 第二次应当从缓存加载", name)
+		}
+		if !reflect.DeepEqual(first.Action, second.Action) {
+			t.Errorf("%s: 缓存的分析表不一致", name)
+		}
+		if other := New(WithMode(ModeSLR1), WithCache(path)); other == nil || other.Cached {
+			t.Errorf("%s: 构造方法改变后仍然使用缓存", name)
+		}
+	}
+}
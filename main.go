@@ -1,106 +1,144 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
-	// recDesParser "mygo_c_compiler/rec_des_parser"
 	"mygo_c_compiler/lexer"
+	lLParser "mygo_c_compiler/ll_parser"
 	lRParser "mygo_c_compiler/lr_parser"
+	"mygo_c_compiler/preprocessor"
+	recDesParser "mygo_c_compiler/rec_des_parser"
 	"os"
 	"strings"
 )
 
+// 可重复出现的命令行参数
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
 func main() {
-	if len(os.Args) < 2 {
+	var includePaths, defines stringList
+	flag.Var(&includePaths, "I", "添加头文件搜索路径")
+	flag.Var(&defines, "D", "预定义宏，格式为 NAME 或 NAME=VALUE")
+	strict := flag.Bool("strict", false, "LR 分析表有冲突时停止语法分析")
+	lrMode := flag.String("lr", "lr1", "LR 分析表的构造方法：lr0、slr1、lr1 或 lalr1")
+	recDes := flag.Bool("rd", false, "使用递归下降语法分析器并输出语法树，代替 LL(1) 与 LR 语法分析")
+	rdTrace := flag.Bool("trace", false, "输出递归下降语法分析的推导过程，与 -rd 一起使用")
+	lrCache := flag.String("cache", "", "LR 分析表缓存文件，文法与构造方法不变时直接加载；扩展名为 .json 时使用 JSON 格式")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
 		fmt.Println("请提供源代码文件路径")
 		return
 	}
 
-	filePath := os.Args[1]
-	file, err := os.Open(filePath)
+	mode, err := lRParser.ParseMode(*lrMode)
 	if err != nil {
-		fmt.Println("无法打开文件:", err)
+		fmt.Println(err)
 		return
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	var sourceCode strings.Builder
-	lineNum := 1
-	inComment := false
 
-	// 读取整个源文件内容，处理注释
-	for scanner.Scan() {
-		line := scanner.Text()
+	filePath := flag.Arg(0)
+	source, err := os.ReadFile(filePath)
+	if err != nil {
+		fmt.Println("无法打开文件:", err)
+		return
+	}
 
-		if inComment {
-			if strings.Contains(line, "*/") {
-				line = line[strings.Index(line, "*/")+2:]
-				inComment = false
-			} else {
-				lineNum++
-				continue
-			}
-		}
+	// 预处理与词法分析
+	opts := []preprocessor.Option{
+		preprocessor.WithDialect(lexer.DialectCourse),
+		preprocessor.WithIncludePaths(includePaths...),
+	}
+	for _, def := range defines {
+		opts = append(opts, preprocessor.WithDefine(def))
+	}
+	pp := preprocessor.New(opts...)
+	tokens := pp.Process(filePath, string(source))
 
-		if strings.Contains(line, "//") {
-			line = strings.Split(line, "//")[0]
-		}
-		if strings.Contains(line, "/*") {
-			inComment = true
-			line = line[:strings.Index(line, "/*")]
+	fmt.Println("\n词法分析结果:")
+	for _, tok := range tokens {
+		if tok.Type == lexer.EOF {
+			break
 		}
-
-		if strings.TrimSpace(line) != "" {
-			sourceCode.WriteString(line + "\n")
+		if tok.Error != "" {
+			fmt.Printf("%s: 错误: (%s, %s) - %s\n", tok.Span.Start, tok.Type, tok.Value, tok.Error)
+		} else {
+			fmt.Printf("%s: (%s, %s)\n", tok.Span.Start, tok.Type, tok.Value)
 		}
-		lineNum++
 	}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Println("读取文件错误:", err)
+	if pp.HasErrors() {
+		fmt.Printf("\n预处理与词法分析发现 %d 个错误，停止语法分析:\n", len(pp.Diagnostics()))
+		for _, d := range pp.Diagnostics() {
+			fmt.Println(d)
+		}
 		return
 	}
 
-	// 词法分析
-	fmt.Println("\n词法分析结果:")
-
-	tokens := []lexer.Token{}
-	lines := strings.Split(sourceCode.String(), "\n")
-	for i, line := range lines {
-		fmt.Printf("%d: %s\n", i+1, line)
-		l := lexer.NewLexer(line)
-		for {
-			tok := l.NextToken()
-			if tok.Type == lexer.UNKNOWN && tok.Value == "" {
-				break
-			}
-			tokens = append(tokens, tok)
-
-			if tok.Error != "" {
-				fmt.Printf("错误: (%s, %s) - %s\n", tok.Type, tok.Value, tok.Error)
-			} else {
-				fmt.Printf("(%s, %s)\n", tok.Type, tok.Value)
-			}
+	// 语法分析
+	if *recDes {
+		fmt.Println("\n递归下降语法分析结果:")
+		var rdOpts []recDesParser.Option
+		if *rdTrace {
+			rdOpts = append(rdOpts, recDesParser.WithTracer(recDesParser.NewPrintTracer(os.Stdout)))
+		}
+		tree, syntaxErrors := recDesParser.New(rdOpts...).ParseTokens(tokens)
+		fmt.Print(recDesParser.Dump(tree))
+		for _, e := range syntaxErrors {
+			fmt.Println(e)
 		}
+		return
 	}
 
-	// 语法分析
-	// fmt.Println("\n递归下降语法分析结果:")
-	// grammar := recDesParser.New()
-	// defer func() {
-	//     if r := recover(); r != nil {
-	//         fmt.Printf("语法错误: %v\n", r)
-	//     }
-	// }()
-	// grammar.Parse(sourceCode.String())
+	fmt.Println("\nLL(1)语法分析结果:")
+	llParser, err := lLParser.New()
+	if err != nil {
+		fmt.Println("解析文法错误:", err)
+		return
+	}
+	llParser.PrintSets(os.Stdout)
+	llParser.PrintConflicts(os.Stdout)
+	llParser.PrintParsingTable()
+	if err := llParser.PrintParsingTableCSV("ll_table.csv"); err != nil {
+		fmt.Println("Error printing LL(1) parsing table to CSV:", err)
+		return
+	}
+	trace, err := llParser.Parse(tokens)
+	trace.Print(os.Stdout)
+	if err != nil {
+		fmt.Println(err)
+	}
 
-	fmt.Println("\nLR(1)语法分析结果:")
-	lrParser := lRParser.New()
-	if err := lrParser.PrintItemSets("items.dot"); err != nil {
-		fmt.Println("Error printing item sets:", err)
+	fmt.Printf("\n%s语法分析结果:\n", mode)
+	lrOpts := []lRParser.Option{lRParser.WithMode(mode)}
+	if *strict {
+		lrOpts = append(lrOpts, lRParser.WithStrict())
+	}
+	if *lrCache != "" {
+		lrOpts = append(lrOpts, lRParser.WithCache(*lrCache))
+	}
+	lrParser := lRParser.New(lrOpts...)
+	if lrParser == nil {
 		return
 	}
+	lrParser.PrintStateReport(os.Stdout)
+	lrParser.PrintConflicts(os.Stdout)
+	lrParser.PrintResolved(os.Stdout)
+	if !lrParser.Cached {
+		if err := lrParser.PrintItemSets("items.dot"); err != nil {
+			fmt.Println("Error printing item sets:", err)
+			return
+		}
+	}
 	lrParser.PrintParsingTable()
 	if err := lrParser.PrintParsingTableCSV("tables.csv"); err != nil {
 		fmt.Println("Error printing parsing table to CSV:", err)
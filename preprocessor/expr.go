@@ -0,0 +1,322 @@
+package preprocessor
+
+import (
+	"fmt"
+	"mygo_c_compiler/lexer"
+	"strings"
+)
+
+// 二元运算符优先级，数值越大优先级越高
+var binaryPrecedence = map[lexer.TokenType]int{
+	lexer.OR:        1,
+	lexer.AND:       2,
+	lexer.PIPE:      3,
+	lexer.CARET:     4,
+	lexer.AMPERSAND: 5,
+	lexer.EQ:        6,
+	lexer.NEQ:       6,
+	lexer.LT:        7,
+	lexer.GT:        7,
+	lexer.LTE:       7,
+	lexer.GTE:       7,
+	lexer.SHL:       8,
+	lexer.SHR:       8,
+	lexer.PLUS:      9,
+	lexer.MINUS:     9,
+	lexer.ASTERISK:  10,
+	lexer.SLASH:     10,
+	lexer.PERCENT:   10,
+}
+
+// #if 常量表达式求值器。按 C11 6.10.1，有符号整数按 intmax_t、无符号整数按 uintmax_t 计算，
+// 两者都是 64 位
+type condEvaluator struct {
+	tokens       []lexer.Token
+	pos          int
+	skip         int  // 大于0时处于短路求值不会执行的分支，不报告除零错误
+	unsignedChar bool // 普通 char 是否为无符号类型，决定 '\xff' 等字符常量的值
+	err          error
+}
+
+// #if 表达式中的值
+type condValue struct {
+	bits     uint64 // 值的二进制补码表示
+	unsigned bool
+}
+
+func signedValue(v int64) condValue {
+	return condValue{bits: uint64(v)}
+}
+
+func (v condValue) isZero() bool {
+	return v.bits == 0
+}
+
+// 计算 #if/#elif 的条件表达式
+func (p *Preprocessor) evalCondition(directive lexer.Token, args []lexer.Token) bool {
+	if len(args) == 0 {
+		p.errorf(ErrIfExpression, directive.Span, "#%s 缺少表达式", directive.Value)
+		return false
+	}
+
+	// 先处理 defined 运算符，再展开宏，剩余的标识符视为 0
+	var tokens []ppToken
+	for i := 0; i < len(args); i++ {
+		tok := args[i]
+		if tok.Value != "defined" {
+			tokens = append(tokens, ppToken{Token: tok})
+			continue
+		}
+		paren := i+1 < len(args) && args[i+1].Type == lexer.LPAREN
+		nameIndex := i + 1
+		if paren {
+			nameIndex++
+		}
+		if nameIndex >= len(args) || !isIdentLike(args[nameIndex]) ||
+			(paren && (nameIndex+1 >= len(args) || args[nameIndex+1].Type != lexer.RPAREN)) {
+			p.errorf(ErrIfExpression, tok.Span, "defined 后面需要宏名")
+			return false
+		}
+		tokens = append(tokens, ppToken{Token: numberToken(p.IsDefined(args[nameIndex].Value), tok)})
+		i = nameIndex
+		if paren {
+			i++
+		}
+	}
+
+	expanded := p.expand(tokens)
+	e := &condEvaluator{unsignedChar: p.unsignedChar}
+	for _, tok := range expanded {
+		if isIdentLike(tok.Token) && tok.Number == nil {
+			tok.Token = numberToken(false, tok.Token)
+		}
+		e.tokens = append(e.tokens, tok.Token)
+	}
+
+	value := e.conditional()
+	if e.err == nil && e.pos < len(e.tokens) {
+		e.err = fmt.Errorf("表达式中多余的 Token: %s", e.tokens[e.pos].Value)
+	}
+	if e.err != nil {
+		p.errorf(ErrIfExpression, lexer.Span{Start: directive.Span.Start, End: args[len(args)-1].Span.End}, "#%s 表达式错误: %v", directive.Value, e.err)
+		return false
+	}
+	return !value.isZero()
+}
+
+func numberToken(value bool, site lexer.Token) lexer.Token {
+	tok := lexer.Token{Type: lexer.NUMBER, Value: "0", Number: &lexer.NumberValue{CType: "int"}, Span: site.Span}
+	if value {
+		tok.Value = "1"
+		tok.Number.Int = 1
+	}
+	return tok
+}
+
+func (e *condEvaluator) peek() lexer.TokenType {
+	if e.pos >= len(e.tokens) {
+		return lexer.EOF
+	}
+	return e.tokens[e.pos].Type
+}
+
+func (e *condEvaluator) fail(format string, args ...interface{}) condValue {
+	if e.err == nil {
+		e.err = fmt.Errorf(format, args...)
+	}
+	return condValue{}
+}
+
+// conditional -> binary [ ? conditional : conditional ]，结果的类型由两个分支按通常算术转换确定
+func (e *condEvaluator) conditional() condValue {
+	cond := e.binary(1)
+	if e.peek() != lexer.QUESTION {
+		return cond
+	}
+	e.pos++
+	if cond.isZero() {
+		e.skip++
+	}
+	a := e.conditional()
+	if cond.isZero() {
+		e.skip--
+	}
+	if e.peek() != lexer.COLON {
+		return e.fail("?: 缺少 :")
+	}
+	e.pos++
+	if !cond.isZero() {
+		e.skip++
+	}
+	b := e.conditional()
+	if !cond.isZero() {
+		e.skip--
+	}
+	result := b
+	if !cond.isZero() {
+		result = a
+	}
+	result.unsigned = a.unsigned || b.unsigned
+	return result
+}
+
+// 优先级爬升法解析二元运算
+func (e *condEvaluator) binary(minPrec int) condValue {
+	lhs := e.unary()
+	for e.err == nil {
+		op := e.peek()
+		prec, ok := binaryPrecedence[op]
+		if !ok || prec < minPrec {
+			break
+		}
+		e.pos++
+
+		// && 与 || 短路求值
+		shortCircuit := (op == lexer.AND && lhs.isZero()) || (op == lexer.OR && !lhs.isZero())
+		if shortCircuit {
+			e.skip++
+		}
+		rhs := e.binary(prec + 1)
+		if shortCircuit {
+			e.skip--
+		}
+		lhs = e.apply(op, lhs, rhs)
+	}
+	return lhs
+}
+
+// 二元运算。除移位外，有一个操作数为无符号数时两个操作数都转换为无符号数；
+// 逻辑、关系与相等运算的结果为有符号的 0 或 1
+func (e *condEvaluator) apply(op lexer.TokenType, a, b condValue) condValue {
+	unsigned := a.unsigned || b.unsigned
+	x, y := int64(a.bits), int64(b.bits)
+	less := func(a, b condValue) bool {
+		if unsigned {
+			return a.bits < b.bits
+		}
+		return int64(a.bits) < int64(b.bits)
+	}
+	result := condValue{unsigned: unsigned}
+	switch op {
+	case lexer.OR:
+		return boolValue(!a.isZero() || !b.isZero())
+	case lexer.AND:
+		return boolValue(!a.isZero() && !b.isZero())
+	case lexer.EQ:
+		return boolValue(a.bits == b.bits)
+	case lexer.NEQ:
+		return boolValue(a.bits != b.bits)
+	case lexer.LT:
+		return boolValue(less(a, b))
+	case lexer.GT:
+		return boolValue(less(b, a))
+	case lexer.LTE:
+		return boolValue(!less(b, a))
+	case lexer.GTE:
+		return boolValue(!less(a, b))
+	case lexer.PIPE:
+		result.bits = a.bits | b.bits
+	case lexer.CARET:
+		result.bits = a.bits ^ b.bits
+	case lexer.AMPERSAND:
+		result.bits = a.bits & b.bits
+	case lexer.PLUS:
+		result.bits = a.bits + b.bits
+	case lexer.MINUS:
+		result.bits = a.bits - b.bits
+	case lexer.ASTERISK:
+		result.bits = a.bits * b.bits
+	case lexer.SHL:
+		// 移位的结果类型为左操作数的类型
+		return condValue{bits: a.bits << (b.bits & 63), unsigned: a.unsigned}
+	case lexer.SHR:
+		if a.unsigned {
+			return condValue{bits: a.bits >> (b.bits & 63), unsigned: true}
+		}
+		return signedValue(x >> (b.bits & 63))
+	default:
+		// 除法与取模
+		if b.isZero() {
+			if e.skip > 0 {
+				return condValue{}
+			}
+			return e.fail("除数为零")
+		}
+		switch {
+		case op == lexer.SLASH && unsigned:
+			result.bits = a.bits / b.bits
+		case op == lexer.SLASH:
+			result.bits = uint64(x / y)
+		case unsigned:
+			result.bits = a.bits % b.bits
+		default:
+			result.bits = uint64(x % y)
+		}
+	}
+	return result
+}
+
+// unary -> ( + | - | ~ | ! ) unary | primary
+func (e *condEvaluator) unary() condValue {
+	switch e.peek() {
+	case lexer.PLUS:
+		e.pos++
+		return e.unary()
+	case lexer.MINUS:
+		e.pos++
+		v := e.unary()
+		v.bits = -v.bits
+		return v
+	case lexer.TILDE:
+		e.pos++
+		v := e.unary()
+		v.bits = ^v.bits
+		return v
+	case lexer.NOT:
+		e.pos++
+		return boolValue(e.unary().isZero())
+	}
+	return e.primary()
+}
+
+// primary -> ( conditional ) | 整数常量 | 字符常量
+func (e *condEvaluator) primary() condValue {
+	if e.pos >= len(e.tokens) {
+		return e.fail("表达式不完整")
+	}
+	tok := e.tokens[e.pos]
+	e.pos++
+	switch tok.Type {
+	case lexer.LPAREN:
+		value := e.conditional()
+		if e.peek() != lexer.RPAREN {
+			return e.fail("缺少 )")
+		}
+		e.pos++
+		return value
+	case lexer.NUMBER, lexer.HEX, lexer.OCTAL, lexer.BINARY:
+		if tok.Number == nil {
+			return e.fail("无效的整数常量: %s", tok.Value)
+		}
+		return condValue{bits: tok.Number.Int, unsigned: strings.HasPrefix(tok.Number.CType, "unsigned")}
+	case lexer.CHAR:
+		if tok.Number == nil {
+			return e.fail("无效的字符常量: %s", tok.Value)
+		}
+		// 普通字符常量的值是 char 转换为 int 的结果，char 有符号时 '\xff' 为 -1
+		if strings.HasPrefix(tok.Value, "'") && len(tok.Decoded) == 1 && !e.unsignedChar {
+			return signedValue(int64(int8(tok.Number.Int)))
+		}
+		return condValue{bits: tok.Number.Int, unsigned: strings.HasPrefix(tok.Number.CType, "unsigned")}
+	case lexer.FLOAT:
+		return e.fail("#if 中不能使用浮点常量: %s", tok.Value)
+	}
+	return e.fail("无效的 Token: %s", tok.Value)
+}
+
+func boolValue(b bool) condValue {
+	if b {
+		return signedValue(1)
+	}
+	return signedValue(0)
+}
@@ -0,0 +1,74 @@
+package preprocessor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 在临时目录中创建文件，files 的键为相对路径
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestInclude(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"local.h":          "local",
+		"sub/nested.h":     "#include \"sibling.h\"",
+		"sub/sibling.h":    "sibling",
+		"inc/system.h":     "system",
+		"inc/local.h":      "from_include_path",
+		"once.h":           "#pragma once\nonce",
+		"guard.h":          "#ifndef GUARD\n#define GUARD\nguard\n#endif",
+		"recursive.h":      "#include \"recursive.h\"",
+		"inc/only_local.h": "only_local",
+	})
+	inc := filepath.Join(dir, "inc")
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+		errors int
+	}{
+		{"当前目录", `#include "local.h"`, "local", 0},
+		{"被包含文件所在目录", `#include "sub/nested.h"`, "sibling", 0},
+		{"-I 路径", `#include <system.h>`, "system", 0},
+		{"<> 不查找当前目录", `#include <local.h>`, "from_include_path", 0},
+		{"\"\" 回退到 -I 路径", `#include "only_local.h"`, "only_local", 0},
+		{"#pragma once", "#include \"once.h\"\n#include \"once.h\"", "once", 0},
+		{"包含保护", "#include \"guard.h\"\n#include \"guard.h\"", "guard", 0},
+		{"计算得到的文件名", "#define H <system.h>\n#include H", "system", 0},
+		{"找不到头文件", `#include "missing.h"`, "", 1},
+		{"缺少文件名", `#include`, "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(WithIncludePaths(inc))
+			tokens := p.Process(filepath.Join(dir, "main.c"), tt.source)
+			if out := spell(tokens[:len(tokens)-1]); out != tt.want {
+				t.Errorf("输出 %q, 期望 %q", out, tt.want)
+			}
+			if len(p.Diagnostics()) != tt.errors {
+				t.Errorf("诊断信息 %v, 期望 %d 条", p.Diagnostics(), tt.errors)
+			}
+		})
+	}
+
+	p := New()
+	p.Process(filepath.Join(dir, "main.c"), `#include "recursive.h"`)
+	if diags := p.Diagnostics(); len(diags) != 1 || diags[0].Code != ErrIncludeDepth {
+		t.Errorf("递归包含: 诊断信息 %v, 期望 %s", diags, ErrIncludeDepth)
+	}
+}
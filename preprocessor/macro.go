@@ -0,0 +1,389 @@
+package preprocessor
+
+import (
+	"mygo_c_compiler/lexer"
+	"strings"
+)
+
+// 处理 #define，tokens 为宏名及其后的全部 Token
+func (p *Preprocessor) define(directive lexer.Token, tokens []lexer.Token) {
+	if len(tokens) == 0 || !isIdentLike(tokens[0]) {
+		p.errorf(ErrInvalidDirective, directive.Span, "#define 缺少宏名")
+		return
+	}
+	nameTok := tokens[0]
+	if nameTok.Value == "defined" {
+		p.errorf(ErrInvalidDirective, nameTok.Span, "\"defined\" 不能作为宏名")
+		return
+	}
+	m := &Macro{Name: nameTok.Value, Pos: nameTok.Span.Start}
+	body := tokens[1:]
+
+	// 宏名后紧跟 ( 才是函数式宏
+	if len(body) > 0 && body[0].Type == lexer.LPAREN && !body[0].SpaceBefore {
+		m.FuncLike = true
+		i := 1
+		for {
+			if i >= len(body) {
+				p.errorf(ErrInvalidDirective, nameTok.Span, "宏 %s 的参数列表缺少 )", m.Name)
+				return
+			}
+			tok := body[i]
+			i++
+			if tok.Type == lexer.RPAREN && len(m.Params) == 0 {
+				break
+			}
+			switch {
+			case tok.Type == lexer.ELLIPSIS:
+				m.Variadic = true
+				m.Params = append(m.Params, "__VA_ARGS__")
+			case isIdentLike(tok) && tok.Value != "__VA_ARGS__":
+				for _, param := range m.Params {
+					if param == tok.Value {
+						p.errorf(ErrInvalidDirective, tok.Span, "宏 %s 的参数 %s 重复", m.Name, tok.Value)
+						return
+					}
+				}
+				m.Params = append(m.Params, tok.Value)
+			default:
+				p.errorf(ErrInvalidDirective, tok.Span, "宏 %s 的参数列表中出现无效的 Token: %s", m.Name, tok.Value)
+				return
+			}
+			if i >= len(body) {
+				continue
+			}
+			sep := body[i]
+			i++
+			if sep.Type == lexer.RPAREN {
+				break
+			}
+			if sep.Type != lexer.COMMA || m.Variadic {
+				p.errorf(ErrInvalidDirective, sep.Span, "宏 %s 的参数列表中应为 , 或 )", m.Name)
+				return
+			}
+		}
+		body = body[i:]
+	}
+	m.Body = body
+
+	if m.FuncLike {
+		for i, tok := range body {
+			if tok.Type == lexer.HASH && (i+1 >= len(body) || m.paramIndex(body[i+1]) < 0) {
+				p.errorf(ErrInvalidDirective, tok.Span, "# 后面必须是宏参数")
+				return
+			}
+		}
+	}
+	if len(body) > 0 && (body[0].Type == lexer.HASH_HASH || body[len(body)-1].Type == lexer.HASH_HASH) {
+		p.errorf(ErrInvalidDirective, nameTok.Span, "## 不能出现在替换列表的开头或结尾")
+		return
+	}
+
+	if old, ok := p.macros[m.Name]; ok && !sameDefinition(old, m) {
+		p.errorf(ErrMacroRedefined, nameTok.Span, "宏 %s 重定义，之前的定义位于 %s", m.Name, old.Pos)
+	}
+	p.macros[m.Name] = m
+}
+
+// 两个宏定义是否相同（参数与替换列表的拼写、空白位置一致）
+func sameDefinition(a, b *Macro) bool {
+	if a.FuncLike != b.FuncLike || a.Variadic != b.Variadic || a.builtin != nil ||
+		len(a.Params) != len(b.Params) || len(a.Body) != len(b.Body) {
+		return false
+	}
+	for i := range a.Params {
+		if a.Params[i] != b.Params[i] {
+			return false
+		}
+	}
+	for i := range a.Body {
+		if a.Body[i].Value != b.Body[i].Value || (i > 0 && a.Body[i].SpaceBefore != b.Body[i].SpaceBefore) {
+			return false
+		}
+	}
+	return true
+}
+
+// 返回 tok 对应的形参下标，不是形参时返回 -1
+func (m *Macro) paramIndex(tok lexer.Token) int {
+	if !m.FuncLike || !isIdentLike(tok) {
+		return -1
+	}
+	for i, param := range m.Params {
+		if param == tok.Value {
+			return i
+		}
+	}
+	return -1
+}
+
+// 宏展开（Prosser 算法，使用隐藏集防止递归展开）
+func (p *Preprocessor) expand(input []ppToken) []ppToken {
+	var output []ppToken
+	var q tokenQueue
+	q.push(input)
+	for !q.empty() {
+		tok := q.next()
+
+		m, ok := p.macros[tok.Value]
+		if !ok || !isIdentLike(tok.Token) || tok.hide[tok.Value] {
+			output = append(output, tok)
+			continue
+		}
+		if m.builtin != nil {
+			output = append(output, ppToken{Token: m.builtin(tok.Token)})
+			continue
+		}
+		if !m.FuncLike {
+			hide := union(tok.hide, map[string]bool{m.Name: true})
+			q.push(p.substitute(m, nil, hide, tok.Token))
+			continue
+		}
+
+		// 函数式宏的名字后面没有 ( 时不展开
+		if q.empty() || q.peek().Type != lexer.LPAREN {
+			output = append(output, tok)
+			continue
+		}
+		args, rparen, ok := p.collectArgs(m, tok.Token, &q)
+		if !ok {
+			output = append(output, tok)
+			continue
+		}
+		hide := union(intersect(tok.hide, rparen.hide), map[string]bool{m.Name: true})
+		q.push(p.substitute(m, args, hide, tok.Token))
+	}
+	return output
+}
+
+// 等待展开的 Token。替换结果压在剩余输入之前，按段保存而不复制剩余的输入
+type tokenQueue struct {
+	segments [][]ppToken // 最后一段最先读取，每段都不为空
+}
+
+// 把 tokens 放在队列的最前面
+func (q *tokenQueue) push(tokens []ppToken) {
+	if len(tokens) > 0 {
+		q.segments = append(q.segments, tokens)
+	}
+}
+
+func (q *tokenQueue) empty() bool {
+	return len(q.segments) == 0
+}
+
+func (q *tokenQueue) peek() ppToken {
+	return q.segments[len(q.segments)-1][0]
+}
+
+func (q *tokenQueue) next() ppToken {
+	top := len(q.segments) - 1
+	tok := q.segments[top][0]
+	if len(q.segments[top]) == 1 {
+		q.segments = q.segments[:top]
+	} else {
+		q.segments[top] = q.segments[top][1:]
+	}
+	return tok
+}
+
+// 从以 ( 开头的队列中收集函数式宏调用的实参，返回实参与右括号。
+// 失败时读取的 Token 放回队列
+func (p *Preprocessor) collectArgs(m *Macro, site lexer.Token, q *tokenQueue) ([][]ppToken, ppToken, bool) {
+	var args [][]ppToken
+	var current []ppToken
+	consumed := []ppToken{q.next()}
+	fail := func() ([][]ppToken, ppToken, bool) {
+		q.push(consumed)
+		return nil, ppToken{}, false
+	}
+	depth := 0
+	for !q.empty() {
+		tok := q.next()
+		consumed = append(consumed, tok)
+		switch {
+		case tok.Type == lexer.LPAREN:
+			depth++
+		case tok.Type == lexer.RPAREN && depth > 0:
+			depth--
+		case tok.Type == lexer.RPAREN:
+			args = append(args, current)
+			// F() 对于无参数的宏表示零个实参
+			if len(m.Params) == 0 && len(args) == 1 && len(args[0]) == 0 {
+				args = nil
+			}
+			// 可变参数可以省略
+			if m.Variadic && len(args) == len(m.Params)-1 {
+				args = append(args, nil)
+			}
+			if len(args) != len(m.Params) {
+				p.errorf(ErrMacroArguments, site.Span, "宏 %s 需要 %d 个参数，实际传入 %d 个", m.Name, len(m.Params), len(args))
+				return fail()
+			}
+			return args, tok, true
+		case tok.Type == lexer.COMMA && depth == 0 && !(m.Variadic && len(args) == len(m.Params)-1):
+			args = append(args, current)
+			current = nil
+			continue
+		}
+		current = append(current, tok)
+	}
+	p.errorf(ErrMacroArguments, site.Span, "宏 %s 的调用缺少 )", m.Name)
+	return fail()
+}
+
+// 用实参替换宏体中的形参，处理 # 与 ##，结果中的宏体 Token 使用调用处的位置
+func (p *Preprocessor) substitute(m *Macro, args [][]ppToken, hide map[string]bool, site lexer.Token) []ppToken {
+	var output []ppToken
+	body := m.Body
+	lhsEmpty := false // ## 左侧为空实参（占位符）
+	for i := 0; i < len(body); i++ {
+		tok := body[i]
+
+		// # 形参
+		if tok.Type == lexer.HASH && m.FuncLike {
+			i++
+			output = append(output, ppToken{Token: stringize(args[m.paramIndex(body[i])], site)})
+			lhsEmpty = false
+			continue
+		}
+
+		// ## 右操作数
+		if tok.Type == lexer.HASH_HASH {
+			i++
+			rhs := []ppToken{{Token: atSite(body[i], site)}}
+			if idx := m.paramIndex(body[i]); idx >= 0 {
+				rhs = args[idx]
+			}
+			if len(rhs) == 0 {
+				continue
+			}
+			if lhsEmpty || len(output) == 0 {
+				output = append(output, rhs...)
+			} else {
+				last := output[len(output)-1]
+				output = append(output[:len(output)-1], p.paste(last, rhs[0])...)
+				output = append(output, rhs[1:]...)
+			}
+			lhsEmpty = false
+			continue
+		}
+
+		if idx := m.paramIndex(tok); idx >= 0 {
+			if i+1 < len(body) && body[i+1].Type == lexer.HASH_HASH {
+				// ## 的左操作数不展开
+				output = append(output, args[idx]...)
+				lhsEmpty = len(args[idx]) == 0
+			} else {
+				output = append(output, p.expand(args[idx])...)
+			}
+			continue
+		}
+		output = append(output, ppToken{Token: atSite(tok, site)})
+		lhsEmpty = false
+	}
+
+	for i := range output {
+		output[i].hide = union(output[i].hide, hide)
+	}
+	return output
+}
+
+// 宏体中的 Token 使用调用处的位置
+func atSite(tok, site lexer.Token) lexer.Token {
+	tok.Span = site.Span
+	tok.LineStart = false
+	return tok
+}
+
+// ## 运算：连接两个 Token 的拼写并重新进行词法分析，失败时保留原来的两个 Token
+func (p *Preprocessor) paste(lhs, rhs ppToken) []ppToken {
+	spelling := lhs.Value + rhs.Value
+	l := p.newLexer(lhs.Span.Start.File, spelling)
+	tok := l.NextToken()
+	if tok.Type == lexer.EOF || tok.Error != "" || l.NextToken().Type != lexer.EOF {
+		p.errorf(ErrInvalidPaste, lhs.Span, "连接 \"%s\" 和 \"%s\" 得不到有效的预处理记号", lhs.Value, rhs.Value)
+		return []ppToken{lhs, rhs}
+	}
+	tok.Span = lhs.Span
+	tok.SpaceBefore = lhs.SpaceBefore
+	tok.LineStart = false
+	return []ppToken{{Token: tok, hide: lhs.hide}}
+}
+
+// # 运算：把实参转换为字符串常量
+func stringize(arg []ppToken, site lexer.Token) lexer.Token {
+	tokens := make([]lexer.Token, len(arg))
+	for i, tok := range arg {
+		tokens[i] = tok.Token
+	}
+	return stringToken(spell(tokens), site)
+}
+
+// 构造内容为 s 的字符串常量 Token，拼写中的 \ 与 " 会被转义
+func stringToken(s string, site lexer.Token) lexer.Token {
+	return lexer.Token{
+		Type:        lexer.STRING,
+		Value:       "\"" + escapeString(s) + "\"",
+		Decoded:     s,
+		Span:        site.Span,
+		SpaceBefore: site.SpaceBefore,
+	}
+}
+
+func escapeString(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(s)
+}
+
+// 将 Token 按原有空白拼接为文本
+func spell(tokens []lexer.Token) string {
+	var text strings.Builder
+	for i, tok := range tokens {
+		if i > 0 && tok.SpaceBefore {
+			text.WriteByte(' ')
+		}
+		text.WriteString(tok.Value)
+	}
+	return text.String()
+}
+
+// 判断 Token 在预处理阶段是否为标识符（关键字在预处理阶段也是标识符）
+func isIdentLike(tok lexer.Token) bool {
+	if tok.Type == lexer.STRING || tok.Type == lexer.CHAR || tok.Value == "" {
+		return false
+	}
+	if tok.Type == lexer.IDENT {
+		return true
+	}
+	c := tok.Value[0]
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func toPPTokens(tokens []lexer.Token) []ppToken {
+	result := make([]ppToken, len(tokens))
+	for i, tok := range tokens {
+		result[i] = ppToken{Token: tok}
+	}
+	return result
+}
+
+func union(a, b map[string]bool) map[string]bool {
+	result := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		result[k] = true
+	}
+	for k := range b {
+		result[k] = true
+	}
+	return result
+}
+
+func intersect(a, b map[string]bool) map[string]bool {
+	result := make(map[string]bool)
+	for k := range a {
+		if b[k] {
+			result[k] = true
+		}
+	}
+	return result
+}
@@ -0,0 +1,95 @@
+package preprocessor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMacroExpansion(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"对象式宏", "#define N 10\nint a[N];", "int a[10];"},
+		{"函数式宏", "#define max(a, b) ((a) > (b) ? (a) : (b))\nmax(x, y + 1)", "((x) > (y + 1) ? (x) : (y + 1))"},
+		{"名字后没有括号", "#define f(x) x\nf + f(1)", "f + 1"},
+		{"跨越宏体的调用", "#define f(x) [x]\n#define g f\ng(1)", "[1]"},
+		{"直接递归", "#define foo foo + 1\nfoo", "foo + 1"},
+		{"间接递归", "#define a b\n#define b a\na b", "a b"},
+		{"实参中的递归", "#define f(x) x f\nf(f)(1)", "f f(1)"},
+		{"字符串化", "#define str(x) #x\nstr(a \"b\\n\")", `"a \"b\\n\""`},
+		{"记号连接", "#define cat(a, b) a ## b\ncat(x, 1) cat(, y) cat(z, )", "x1 y z"},
+		{"可变参数", "#define p(fmt, ...) printf(fmt, __VA_ARGS__)\np(\"%d\", 1, 2)", `printf("%d", 1, 2)`},
+		{"#undef", "#define X 1\n#undef X\nX", "X"},
+		{"__LINE__", "\n\n__LINE__", "3"},
+		// C11 6.10.3.5 EXAMPLE 3
+		{"标准示例", `#define x 3
+#define f(a) f(x * (a))
+#undef x
+#define x 2
+#define g f
+#define z z[0]
+#define h g(~
+#define m(a) a(w)
+#define w 0,1
+#define t(a) a
+#define p() int
+#define q(x) x
+#define r(x,y) x ## y
+#define str(x) # x
+f(y+1) + f(f(z)) % t(t(g)(0) + t)(1);
+g(x+(3,4)-w) | h 5) & m
+(f)^m(m);
+p() i[q()] = { q(1), r(2,3), r(4,), r(,5), r(,) };
+char c[2][6] = { str(hello), str() };`, `f(2 * (y+1)) + f(2 * (f(2 * (z[0])))) % f(2 * (0)) + t(1);
+f(2 * (2+(3,4)-0,1)) | f(2 * (~ 5)) & f(2 * (0,1))^m(0,1);
+int i[] = { 1, 23, 4, 5, };
+char c[2][6] = { "hello", "" };`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, diags := preprocess(t, tt.source)
+			if len(diags) > 0 {
+				t.Errorf("诊断信息: %v", diags)
+			}
+			// 只比较 Token 的拼写，不比较空白
+			if strip(out) != strip(tt.want) {
+				t.Errorf("展开结果\n%s\n期望\n%s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestMacroErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string // 诊断代码
+	}{
+		{"#define f(x) x\nf(1, 2)", string(ErrMacroArguments)},
+		{"#define f(x) x\nf(1", string(ErrMacroArguments)},
+		{"#define X 1\n#define X 2", string(ErrMacroRedefined)},
+		{"#define cat(a, b) a ## b\ncat(+, /)", string(ErrInvalidPaste)},
+		{"#define\n", string(ErrInvalidDirective)},
+	}
+	for _, tt := range tests {
+		_, diags := preprocess(t, tt.source)
+		if len(diags) == 0 || string(diags[0].Code) != tt.want {
+			t.Errorf("%q: 诊断信息 %v, 期望 %s", tt.source, diags, tt.want)
+		}
+	}
+}
+
+// 大量展开时剩余的输入不应被反复复制
+func TestLongExpansion(t *testing.T) {
+	const n = 100000
+	source := "#define A B\n#define B 1\n" + strings.Repeat("A ", n)
+	out, _ := preprocess(t, source)
+	if got := strings.Count(out, "1"); got != n {
+		t.Errorf("展开得到 %d 个 1, 期望 %d", got, n)
+	}
+}
+
+func strip(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
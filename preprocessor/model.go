@@ -0,0 +1,77 @@
+package preprocessor
+
+import (
+	"mygo_c_compiler/lexer"
+)
+
+// 预处理器诊断代码
+const (
+	ErrInvalidDirective        lexer.DiagnosticCode = "invalid-directive"
+	ErrIncludeNotFound         lexer.DiagnosticCode = "include-not-found"
+	ErrIncludeDepth            lexer.DiagnosticCode = "include-depth"
+	ErrUnterminatedConditional lexer.DiagnosticCode = "unterminated-conditional"
+	ErrMacroRedefined          lexer.DiagnosticCode = "macro-redefined"
+	ErrMacroArguments          lexer.DiagnosticCode = "macro-arguments"
+	ErrInvalidPaste            lexer.DiagnosticCode = "invalid-paste"
+	ErrIfExpression            lexer.DiagnosticCode = "if-expression"
+	ErrUserError               lexer.DiagnosticCode = "error-directive"
+)
+
+// 最大 #include 嵌套深度
+const maxIncludeDepth = 200
+
+// 宏定义
+type Macro struct {
+	Name     string
+	FuncLike bool          // 是否为函数式宏
+	Params   []string      // 形参名，可变参数宏的最后一个形参为 __VA_ARGS__
+	Variadic bool          // 参数列表是否以 ... 结尾
+	Body     []lexer.Token // 替换列表
+	Pos      lexer.Position
+
+	// 内置的动态宏（如 __LINE__），根据展开位置生成结果
+	builtin func(site lexer.Token) lexer.Token
+}
+
+// 带隐藏集的 Token，隐藏集中的宏名在该 Token 上不再展开
+type ppToken struct {
+	lexer.Token
+	hide map[string]bool
+}
+
+// 一层条件编译 (#if ... #endif) 的状态
+type condState struct {
+	active       bool // 当前分支是否输出
+	taken        bool // 之前是否已有分支被选中
+	parentActive bool // 外层是否处于输出状态
+	sawElse      bool
+	pos          lexer.Position
+}
+
+// 正在处理的源文件
+type sourceFile struct {
+	name        string
+	dir         string
+	input       string
+	tokens      []lexer.Token
+	next        int
+	diagnostics []lexer.Diagnostic // 该文件的词法诊断，只有实际使用到的 Token 才会报告
+	lineDelta   int                // #line 设置的行号偏移
+	presumed    string             // #line 设置的文件名
+}
+
+// 预处理器选项
+type Option func(*Preprocessor)
+
+// 预处理器
+type Preprocessor struct {
+	includePaths []string
+	dialect      lexer.Dialect
+	unsignedChar bool // 普通 char 是否为无符号类型，默认为有符号（与 x86 上的 GCC 一致）
+	defines      []string
+	macros       map[string]*Macro
+	files        []*sourceFile // 当前的 #include 栈
+	onceFiles    map[string]bool
+	output       []lexer.Token
+	diagnostics  []lexer.Diagnostic
+}
@@ -0,0 +1,533 @@
+package preprocessor
+
+import (
+	"fmt"
+	"mygo_c_compiler/lexer"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 创建预处理器
+func New(opts ...Option) *Preprocessor {
+	p := &Preprocessor{
+		macros:    make(map[string]*Macro),
+		onceFiles: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.definePredefined()
+	for _, def := range p.defines {
+		name, value, found := strings.Cut(def, "=")
+		if !found {
+			value = "1"
+		}
+		p.defineText("<command-line>", name+" "+value)
+	}
+	return p
+}
+
+// 添加 #include 搜索路径（对应 -I）
+func WithIncludePaths(paths ...string) Option {
+	return func(p *Preprocessor) {
+		p.includePaths = append(p.includePaths, paths...)
+	}
+}
+
+// 预定义宏，格式为 NAME 或 NAME=VALUE（对应 -D）
+func WithDefine(def string) Option {
+	return func(p *Preprocessor) {
+		p.defines = append(p.defines, def)
+	}
+}
+
+// 选择词法分析使用的语言方言
+func WithDialect(dialect lexer.Dialect) Option {
+	return func(p *Preprocessor) {
+		p.dialect = dialect
+	}
+}
+
+// 普通 char 为无符号类型（对应 -funsigned-char），影响 #if 中字符常量的值并定义 __CHAR_UNSIGNED__
+func WithUnsignedChar() Option {
+	return func(p *Preprocessor) {
+		p.unsignedChar = true
+	}
+}
+
+// 读取并预处理文件，只有读取主文件失败时才返回 error，其余问题记录在 Diagnostics 中
+func (p *Preprocessor) ProcessFile(filename string) ([]lexer.Token, error) {
+	source, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return p.Process(filename, string(source)), nil
+}
+
+// 预处理源代码，返回以 EOF 结尾的 Token 序列
+func (p *Preprocessor) Process(filename, source string) []lexer.Token {
+	p.output = nil
+	eof := p.includeSource(filename, source)
+	tokens := concatStrings(p.output)
+	return append(tokens, eof)
+}
+
+// 返回预处理过程中收集到的诊断信息（包括实际使用到的 Token 的词法错误）
+func (p *Preprocessor) Diagnostics() []lexer.Diagnostic {
+	return p.diagnostics
+}
+
+// 是否发生过错误
+func (p *Preprocessor) HasErrors() bool {
+	return len(p.diagnostics) > 0
+}
+
+// 判断宏是否已定义
+func (p *Preprocessor) IsDefined(name string) bool {
+	_, ok := p.macros[name]
+	return ok
+}
+
+func (p *Preprocessor) errorf(code lexer.DiagnosticCode, span lexer.Span, format string, args ...interface{}) {
+	p.diagnostics = append(p.diagnostics, lexer.Diagnostic{Code: code, Span: span, Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *Preprocessor) newLexer(filename, source string) *lexer.Lexer {
+	return lexer.NewLexer(source, lexer.WithFilename(filename), lexer.WithDialect(p.dialect), lexer.WithoutStringConcat())
+}
+
+// 预处理一个源文件，结果追加到 p.output，返回该文件的 EOF Token
+func (p *Preprocessor) includeSource(filename, source string) lexer.Token {
+	l := p.newLexer(filename, source)
+	f := &sourceFile{name: filename, dir: filepath.Dir(filename), input: source}
+	for {
+		tok := l.NextToken()
+		f.tokens = append(f.tokens, tok)
+		if tok.Type == lexer.EOF {
+			break
+		}
+	}
+	f.diagnostics = l.Diagnostics()
+
+	p.files = append(p.files, f)
+	defer func() { p.files = p.files[:len(p.files)-1] }()
+
+	var conds []condState
+	var text []ppToken
+	for {
+		tok := f.tokens[f.next]
+		if tok.Type == lexer.EOF {
+			break
+		}
+		f.next++
+
+		if tok.LineStart && tok.Type == lexer.HASH {
+			p.emit(text)
+			text = nil
+			line := f.readLine()
+			for i := range line {
+				line[i] = f.presumedToken(line[i])
+			}
+			p.directive(f, f.presumedToken(tok), line, &conds)
+			continue
+		}
+		if !isActive(conds) {
+			continue
+		}
+		p.checkToken(f, tok)
+		text = append(text, ppToken{Token: f.presumedToken(tok)})
+	}
+	p.emit(text)
+
+	for _, c := range conds {
+		p.errorf(ErrUnterminatedConditional, lexer.Span{Start: c.pos, End: c.pos}, "未终止的条件编译指令")
+	}
+	return f.presumedToken(f.tokens[len(f.tokens)-1])
+}
+
+// 读取当前逻辑行剩余的 Token（返回副本）
+func (f *sourceFile) readLine() []lexer.Token {
+	start := f.next
+	for f.tokens[f.next].Type != lexer.EOF && !f.tokens[f.next].LineStart {
+		f.next++
+	}
+	return append([]lexer.Token(nil), f.tokens[start:f.next]...)
+}
+
+// 应用 #line 设置的行号与文件名
+func (f *sourceFile) presumedToken(tok lexer.Token) lexer.Token {
+	if f.lineDelta == 0 && f.presumed == "" {
+		return tok
+	}
+	tok.Span.Start.Line += f.lineDelta
+	tok.Span.End.Line += f.lineDelta
+	if f.presumed != "" {
+		tok.Span.Start.File = f.presumed
+		tok.Span.End.File = f.presumed
+	}
+	return tok
+}
+
+// 报告 Token 自身携带的词法错误
+func (p *Preprocessor) checkToken(f *sourceFile, tok lexer.Token) {
+	if tok.Error == "" {
+		return
+	}
+	for _, d := range f.diagnostics {
+		if d.Span.Start.Offset >= tok.Span.Start.Offset && d.Span.Start.Offset < tok.Span.End.Offset {
+			p.diagnostics = append(p.diagnostics, d)
+		}
+	}
+}
+
+// 对正文 Token 做宏展开并输出
+func (p *Preprocessor) emit(text []ppToken) {
+	if len(text) == 0 {
+		return
+	}
+	for _, tok := range p.expand(text) {
+		p.output = append(p.output, tok.Token)
+	}
+}
+
+func isActive(conds []condState) bool {
+	return len(conds) == 0 || conds[len(conds)-1].active
+}
+
+// 处理一条预处理指令，hash 为行首的 #，line 为其后的 Token
+func (p *Preprocessor) directive(f *sourceFile, hash lexer.Token, line []lexer.Token, conds *[]condState) {
+	if len(line) == 0 {
+		return // 空指令
+	}
+	name := line[0].Value
+	args := line[1:]
+	active := isActive(*conds)
+	span := lexer.Span{Start: hash.Span.Start, End: line[len(line)-1].Span.End}
+
+	switch name {
+	case "if", "ifdef", "ifndef":
+		if !active {
+			*conds = append(*conds, condState{taken: true, pos: hash.Span.Start})
+			return
+		}
+		p.checkTokens(f, args)
+		cond := p.condition(name, line[0], args)
+		*conds = append(*conds, condState{active: cond, taken: cond, parentActive: true, pos: hash.Span.Start})
+		return
+	case "elif", "else", "endif":
+		if len(*conds) == 0 {
+			p.errorf(ErrInvalidDirective, span, "#%s 没有匹配的 #if", name)
+			return
+		}
+		c := &(*conds)[len(*conds)-1]
+		if name == "endif" {
+			*conds = (*conds)[:len(*conds)-1]
+			return
+		}
+		if c.sawElse {
+			p.errorf(ErrInvalidDirective, span, "#%s 出现在 #else 之后", name)
+		}
+		if name == "else" {
+			c.sawElse = true
+			c.active = c.parentActive && !c.taken
+			c.taken = true
+			return
+		}
+		if !c.parentActive || c.taken {
+			c.active = false
+			return
+		}
+		p.checkTokens(f, args)
+		c.active = p.condition("if", line[0], args)
+		c.taken = c.active
+		return
+	}
+
+	if !active {
+		return
+	}
+	switch name {
+	case "define":
+		p.checkTokens(f, args)
+		p.define(line[0], args)
+	case "undef":
+		p.checkTokens(f, args)
+		if len(args) == 0 || !isIdentLike(args[0]) {
+			p.errorf(ErrInvalidDirective, span, "#undef 缺少宏名")
+			return
+		}
+		delete(p.macros, args[0].Value)
+	case "include":
+		p.checkTokens(f, args)
+		p.include(f, span, args)
+	case "line":
+		// 行号是数字序列而不是整数常量，0089 这样的拼写不是错误
+		for _, tok := range args {
+			if !isDigitSequence(tok.Value) {
+				p.checkToken(f, tok)
+			}
+		}
+		p.lineDirective(f, span, args)
+	case "error":
+		message := ""
+		if len(args) > 0 {
+			message = f.input[args[0].Span.Start.Offset:args[len(args)-1].Span.End.Offset]
+		}
+		p.errorf(ErrUserError, span, "#error %s", message)
+	case "pragma":
+		if len(args) == 1 && args[0].Value == "once" {
+			p.onceFiles[absPath(f.name)] = true
+		}
+		// 其他 #pragma 忽略
+	default:
+		p.errorf(ErrInvalidDirective, span, "未知的预处理指令: #%s", name)
+	}
+}
+
+func (p *Preprocessor) checkTokens(f *sourceFile, tokens []lexer.Token) {
+	for _, tok := range tokens {
+		p.checkToken(f, tok)
+	}
+}
+
+// 计算 #if/#ifdef/#ifndef 的条件
+func (p *Preprocessor) condition(kind string, nameTok lexer.Token, args []lexer.Token) bool {
+	if kind == "if" {
+		return p.evalCondition(nameTok, args)
+	}
+	if len(args) == 0 || !isIdentLike(args[0]) {
+		p.errorf(ErrInvalidDirective, nameTok.Span, "#%s 缺少宏名", kind)
+		return false
+	}
+	defined := p.IsDefined(args[0].Value)
+	if kind == "ifdef" {
+		return defined
+	}
+	return !defined
+}
+
+// 处理 #include
+func (p *Preprocessor) include(f *sourceFile, span lexer.Span, args []lexer.Token) {
+	name, system, ok := headerName(f, args)
+	if !ok {
+		// 计算得到的 #include：先展开宏再解析
+		expanded := p.expand(toPPTokens(args))
+		tokens := make([]lexer.Token, len(expanded))
+		for i, tok := range expanded {
+			tokens[i] = tok.Token
+		}
+		name, system, ok = headerName(nil, tokens)
+	}
+	if !ok {
+		p.errorf(ErrInvalidDirective, span, "#include 需要 \"文件名\" 或 <文件名>")
+		return
+	}
+
+	path := p.findInclude(name, f.dir, system)
+	if path == "" {
+		p.errorf(ErrIncludeNotFound, span, "找不到头文件: %s", name)
+		return
+	}
+	if len(p.files) >= maxIncludeDepth {
+		p.errorf(ErrIncludeDepth, span, "#include 嵌套过深: %s", name)
+		return
+	}
+	if p.onceFiles[absPath(path)] {
+		return
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		p.errorf(ErrIncludeNotFound, span, "无法读取头文件 %s: %v", path, err)
+		return
+	}
+	p.includeSource(path, string(source))
+}
+
+// 从 #include 的参数中取出头文件名。f 不为空时 <...> 中的内容直接取自源代码
+func headerName(f *sourceFile, args []lexer.Token) (string, bool, bool) {
+	if len(args) == 0 {
+		return "", false, false
+	}
+	first := args[0]
+	if first.Type == lexer.STRING && strings.HasPrefix(first.Value, "\"") {
+		return first.Value[1 : len(first.Value)-1], false, true
+	}
+	if first.Type != lexer.LT {
+		return "", false, false
+	}
+	for i := 1; i < len(args); i++ {
+		if args[i].Type != lexer.GT {
+			continue
+		}
+		if f != nil {
+			return f.input[first.Span.End.Offset:args[i].Span.Start.Offset], true, true
+		}
+		return spell(args[1:i]), true, true
+	}
+	return "", false, false
+}
+
+// 查找头文件："..." 先在当前文件所在目录查找，然后依次查找 -I 路径
+func (p *Preprocessor) findInclude(name, dir string, system bool) string {
+	if filepath.IsAbs(name) {
+		if fileExists(name) {
+			return name
+		}
+		return ""
+	}
+	var dirs []string
+	if !system {
+		dirs = append(dirs, dir)
+	}
+	dirs = append(dirs, p.includePaths...)
+	for _, d := range dirs {
+		path := filepath.Join(d, name)
+		if fileExists(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// 处理 #line 行号 ["文件名"]
+func (p *Preprocessor) lineDirective(f *sourceFile, span lexer.Span, args []lexer.Token) {
+	expanded := p.expand(toPPTokens(args))
+	if len(expanded) == 0 || len(expanded) > 2 {
+		p.errorf(ErrInvalidDirective, span, "#line 需要一个行号和可选的文件名")
+		return
+	}
+	// C11 6.10.4：行号是按十进制解释的数字序列，前导 0 不表示八进制，不能有后缀
+	line, err := strconv.ParseUint(expanded[0].Value, 10, 31)
+	if err != nil || line == 0 {
+		p.errorf(ErrInvalidDirective, span, "#line 的行号必须是 1 到 2147483647 之间的数字序列: %s", expanded[0].Value)
+		return
+	}
+	// 指令之后的下一个物理行的行号为给定值，span 已经应用了之前的 #line 偏移
+	nextLine := span.End.Line - f.lineDelta + 1
+	f.lineDelta = int(line) - nextLine
+	if len(expanded) == 2 {
+		if expanded[1].Type != lexer.STRING {
+			p.errorf(ErrInvalidDirective, span, "#line 的文件名必须是字符串常量")
+			return
+		}
+		f.presumed = expanded[1].Decoded
+	}
+}
+
+func isDigitSequence(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// 定义预定义宏
+func (p *Preprocessor) definePredefined() {
+	p.defineText("<built-in>", "__STDC__ 1")
+	p.defineText("<built-in>", "__STDC_HOSTED__ 1")
+	if p.unsignedChar {
+		p.defineText("<built-in>", "__CHAR_UNSIGNED__ 1")
+	}
+	switch p.dialect {
+	case lexer.DialectC99:
+		p.defineText("<built-in>", "__STDC_VERSION__ 199901L")
+	case lexer.DialectC11, lexer.DialectGNU11:
+		p.defineText("<built-in>", "__STDC_VERSION__ 201112L")
+	}
+
+	now := time.Now()
+	p.defineText("<built-in>", now.Format(`__DATE__ "Jan _2 2006"`))
+	p.defineText("<built-in>", now.Format(`__TIME__ "15:04:05"`))
+
+	p.macros["__FILE__"] = &Macro{Name: "__FILE__", builtin: func(site lexer.Token) lexer.Token {
+		return stringToken(site.Span.Start.File, site)
+	}}
+	p.macros["__LINE__"] = &Macro{Name: "__LINE__", builtin: func(site lexer.Token) lexer.Token {
+		line := site.Span.Start.Line
+		return lexer.Token{
+			Type:   lexer.NUMBER,
+			Value:  fmt.Sprint(line),
+			Number: &lexer.NumberValue{Int: uint64(line), CType: "int"},
+			Span:   site.Span,
+		}
+	}}
+}
+
+// 以 "NAME 替换列表" 形式的文本定义宏
+func (p *Preprocessor) defineText(filename, text string) {
+	l := p.newLexer(filename, text)
+	var tokens []lexer.Token
+	for tok := l.NextToken(); tok.Type != lexer.EOF; tok = l.NextToken() {
+		tokens = append(tokens, tok)
+	}
+	if len(tokens) == 0 {
+		return
+	}
+	p.define(tokens[0], tokens)
+}
+
+// 连接相邻的字符串常量（翻译阶段6）
+func concatStrings(tokens []lexer.Token) []lexer.Token {
+	var result []lexer.Token
+	for _, tok := range tokens {
+		if n := len(result); n > 0 && tok.Type == lexer.STRING && result[n-1].Type == lexer.STRING {
+			prev := &result[n-1]
+			prefix := stringPrefix(prev.Value)
+			if prefix == "" {
+				prefix = stringPrefix(tok.Value)
+			}
+			prev.Decoded += tok.Decoded
+			prev.Value = prefix + "\"" + escapeDecoded(prev.Decoded) + "\""
+			if prev.Error == "" {
+				prev.Error = tok.Error
+			}
+			if prev.Span.Start.File == tok.Span.End.File {
+				prev.Span.End = tok.Span.End
+			}
+			continue
+		}
+		result = append(result, tok)
+	}
+	return result
+}
+
+// 字符串常量的编码前缀（L、u、U、u8），没有前缀时为空
+func stringPrefix(spelling string) string {
+	return spelling[:strings.IndexByte(spelling, '"')]
+}
+
+// 将解码后的字符串内容重新转义为字符串常量的拼写，不可打印的字节使用八进制转义
+func escapeDecoded(s string) string {
+	var sb strings.Builder
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		switch {
+		case r == '"' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == '\n':
+			sb.WriteString("\\n")
+		case r == '\t':
+			sb.WriteString("\\t")
+		case r == utf8.RuneError && size == 1, r < 0x20, r == 0x7F:
+			fmt.Fprintf(&sb, "\\%03o", s[0])
+		default:
+			sb.WriteString(s[:size])
+		}
+		s = s[size:]
+	}
+	return sb.String()
+}
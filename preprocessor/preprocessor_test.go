@@ -0,0 +1,162 @@
+package preprocessor
+
+import (
+	"mygo_c_compiler/lexer"
+	"testing"
+)
+
+// 预处理 source，返回输出 Token 的拼写（不含 EOF）与诊断信息
+func preprocess(t *testing.T, source string, opts ...Option) (string, []lexer.Diagnostic) {
+	t.Helper()
+	p := New(opts...)
+	tokens := p.Process("test.c", source)
+	return spell(tokens[:len(tokens)-1]), p.Diagnostics()
+}
+
+func TestIfExpression(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"1", true},
+		{"0", false},
+		{"1 + 2 * 3 == 7", true},
+		{"(1 + 2) * 3 == 9", true},
+		{"10 / 3 == 3 && 10 % 3 == 1", true},
+		{"-7 / 2 == -3 && -7 % 2 == -1", true},
+		{"1 << 3 == 8 && -16 >> 2 == -4", true},
+		{"~0 == -1", true},
+		{"!0 && !!5", true},
+		{"1 ? 2 : 0", true},
+		{"0 ? 1 : 0", false},
+		{"0 && 1 / 0", false},
+		{"1 || 1 / 0", true},
+		{"'A' == 65", true},
+		{"UNDEFINED_NAME == 0", true},
+		{"defined(FOO) || defined BAR", false},
+		{"defined __STDC__", true},
+		// 有一个操作数为无符号数时按 uintmax_t 比较
+		{"-1 > 0u", true},
+		{"-1 > 0", false},
+		{"-1 < 0u", false},
+		{"0xFFFFFFFFFFFFFFFF == -1", true},
+		{"0xFFFFFFFFFFFFFFFF > 0", true},
+		{"-1 / 2u > 0", true},
+		{"(0 ? 1u : -1) > 0", true},
+		{"-1u >> 63 == 1", true},
+		{"-1 >> 63 == -1", true},
+		{"(1u << 63) > 0", true},
+		{"(1 << 63) < 0", true},
+	}
+	for _, tt := range tests {
+		out, diags := preprocess(t, "#if "+tt.expr+"\nyes\n#else\nno\n#endif\n")
+		if len(diags) > 0 {
+			t.Errorf("#if %s: %v", tt.expr, diags)
+			continue
+		}
+		want := "no"
+		if tt.want {
+			want = "yes"
+		}
+		if out != want {
+			t.Errorf("#if %s 选择了 %s, 期望 %s", tt.expr, out, want)
+		}
+	}
+}
+
+// 字符常量按 char 的符号性求值，不经过 UTF-8 解码
+func TestIfCharConstant(t *testing.T) {
+	tests := []struct {
+		expr     string
+		signed   bool
+		unsigned bool
+	}{
+		{"'\\xff' == -1", true, false},
+		{"'\\xff' == 255", false, true},
+		{"'\\377' < 0", true, false},
+		{"'\\x7f' == 127", true, true},
+		{"L'\\xff' == 255", true, true},
+		{"u'\\xff' > -1", false, false},
+		{"U'\\x10FFFF' == 0x10FFFF", true, true},
+		{"'\\n' == 10", true, true},
+		{"'é' == 0xE9", true, true},
+		{"defined __CHAR_UNSIGNED__", false, true},
+	}
+	for _, tt := range tests {
+		source := "#if " + tt.expr + "\nyes\n#else\nno\n#endif\n"
+		for _, c := range []struct {
+			want string
+			opts []Option
+		}{
+			{map[bool]string{true: "yes", false: "no"}[tt.signed], nil},
+			{map[bool]string{true: "yes", false: "no"}[tt.unsigned], []Option{WithUnsignedChar()}},
+		} {
+			out, diags := preprocess(t, source, c.opts...)
+			if len(diags) > 0 || out != c.want {
+				t.Errorf("#if %s (unsigned char = %v) 选择了 %s, 期望 %s: %v", tt.expr, len(c.opts) > 0, out, c.want, diags)
+			}
+		}
+	}
+}
+
+func TestIfExpressionErrors(t *testing.T) {
+	for _, expr := range []string{"", "1 +", "(1", "1 / 0", "1.5", "1 ? 2", "defined"} {
+		_, diags := preprocess(t, "#if "+expr+"\n#endif\n")
+		if len(diags) == 0 {
+			t.Errorf("#if %s 没有报告错误", expr)
+		}
+	}
+}
+
+// #line 的行号按十进制解释，前导 0 不表示八进制
+func TestLineDirective(t *testing.T) {
+	tests := []struct {
+		directive string
+		line      int
+		file      string
+	}{
+		{"#line 100", 100, "test.c"},
+		{"#line 010", 10, "test.c"},
+		{"#line 0089", 89, "test.c"},
+		{"#line 7 \"a.c\"", 7, "a.c"},
+		{"#define L 20\n#line L", 20, "test.c"},
+	}
+	for _, tt := range tests {
+		p := New()
+		tokens := p.Process("test.c", tt.directive+"\nx\n")
+		if len(p.Diagnostics()) > 0 {
+			t.Errorf("%q: %v", tt.directive, p.Diagnostics())
+			continue
+		}
+		if start := tokens[0].Span.Start; start.Line != tt.line || start.File != tt.file {
+			t.Errorf("%q 之后 x 位于 %s, 期望 %s:%d", tt.directive, start, tt.file, tt.line)
+		}
+	}
+	for _, directive := range []string{"#line", "#line 0", "#line 10u", "#line 0x10", "#line 2147483648", "#line 1.5", "#line x", "#line 1 2", "#line 1 \"a\" 2"} {
+		if _, diags := preprocess(t, directive+"\nx\n"); len(diags) == 0 {
+			t.Errorf("%q 没有报告错误", directive)
+		}
+	}
+}
+
+// 相邻字符串常量按解码后的内容连接
+func TestStringConcat(t *testing.T) {
+	tests := []struct {
+		source  string
+		value   string
+		decoded string
+	}{
+		{`"a" "b"`, `"ab"`, "ab"},
+		{`"\x41" /* c */ "\n"`, `"A\n"`, "A\n"},
+		{`"\x4" "1"`, `"\0041"`, "\x041"},
+		{`"\"" "\\"`, `"\"\\"`, "\"\\"},
+		{`"a" L"b"`, `L"ab"`, "ab"},
+		{"#define S \"x\"\nS S \"é\"", `"xxé"`, "xxé"},
+	}
+	for _, tt := range tests {
+		tokens := New().Process("test.c", tt.source)
+		if len(tokens) != 2 || tokens[0].Value != tt.value || tokens[0].Decoded != tt.decoded {
+			t.Errorf("%s: 得到 %q (%q), 期望 %q (%q)", tt.source, tokens[0].Value, tokens[0].Decoded, tt.value, tt.decoded)
+		}
+	}
+}
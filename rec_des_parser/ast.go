@@ -0,0 +1,350 @@
+package rec_des_parser
+
+import (
+	"mygo_c_compiler/lexer"
+)
+
+// 语法树节点
+type Node interface {
+	Span() lexer.Span
+}
+
+// 外部声明：函数定义或声明
+type Decl interface {
+	Node
+	declNode()
+}
+
+// 语句节点
+type Stmt interface {
+	Node
+	stmtNode()
+}
+
+// 表达式节点
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// 翻译单元：一个源文件中的全部外部声明
+type TranslationUnit struct {
+	Decls []Decl
+	Loc   lexer.Span
+}
+
+// 函数定义
+type FuncDef struct {
+	Storage string // 存储类说明符，如 static，没有时为空
+	Name    *Ident
+	Type    *FuncType
+	Body    *Block
+	Loc     lexer.Span
+}
+
+// 一个声明符对应的变量、函数原型或 typedef 声明
+type VarDecl struct {
+	Storage string
+	Name    *Ident
+	Type    Type
+	Init    Expr // 初始化器，没有时为 nil
+	Loc     lexer.Span
+}
+
+// 无法解析的外部声明，仅记录范围
+type BadDecl struct {
+	Loc lexer.Span
+}
+
+// { block_items }，块中的声明以 DeclStmt 出现在 Stmts 中
+type Block struct {
+	Stmts []Stmt
+	Loc   lexer.Span
+}
+
+// 块内的声明
+type DeclStmt struct {
+	Decls []*VarDecl
+	Loc   lexer.Span
+}
+
+// 表达式语句
+type ExprStmt struct {
+	X   Expr
+	Loc lexer.Span
+}
+
+// 空语句 ;
+type EmptyStmt struct {
+	Loc lexer.Span
+}
+
+// if ( cond ) then [else else]，没有 else 分支时 Else 为 nil
+type If struct {
+	Cond Expr
+	Then Stmt
+	Else Stmt
+	Loc  lexer.Span
+}
+
+// while ( cond ) body
+type While struct {
+	Cond Expr
+	Body Stmt
+	Loc  lexer.Span
+}
+
+// do body while ( cond ) ;
+type DoWhile struct {
+	Body Stmt
+	Cond Expr
+	Loc  lexer.Span
+}
+
+// for ( init ; cond ; post ) body，省略的部分为 nil。Init 为 DeclStmt 或 ExprStmt
+type For struct {
+	Init Stmt
+	Cond Expr
+	Post Expr
+	Body Stmt
+	Loc  lexer.Span
+}
+
+// switch ( tag ) body
+type Switch struct {
+	Tag  Expr
+	Body Stmt
+	Loc  lexer.Span
+}
+
+// case value : body
+type Case struct {
+	Value Expr
+	Body  Stmt
+	Loc   lexer.Span
+}
+
+// default : body
+type Default struct {
+	Body Stmt
+	Loc  lexer.Span
+}
+
+// return [value] ;
+type Return struct {
+	Value Expr
+	Loc   lexer.Span
+}
+
+// break ;
+type Break struct {
+	Loc lexer.Span
+}
+
+// continue ;
+type Continue struct {
+	Loc lexer.Span
+}
+
+// 无法解析的语句，仅记录范围
+type BadStmt struct {
+	Loc lexer.Span
+}
+
+// target op value，Op 为 ASSIGN 或复合赋值运算符
+type Assign struct {
+	Op     lexer.TokenType
+	Target Expr
+	Value  Expr
+	Loc    lexer.Span
+}
+
+// 二元运算，Op 为运算符的 Token 类型
+type BinaryExpr struct {
+	Op    lexer.TokenType
+	Left  Expr
+	Right Expr
+	Loc   lexer.Span
+}
+
+// 前缀一元运算：++ -- + - ! ~ * &
+type UnaryExpr struct {
+	Op  lexer.TokenType
+	X   Expr
+	Loc lexer.Span
+}
+
+// 后缀自增自减：x++ x--
+type PostfixExpr struct {
+	Op  lexer.TokenType
+	X   Expr
+	Loc lexer.Span
+}
+
+// 括号表达式 ( x )
+type ParenExpr struct {
+	X   Expr
+	Loc lexer.Span
+}
+
+// 条件运算 cond ? then : else
+type CondExpr struct {
+	Cond Expr
+	Then Expr
+	Else Expr
+	Loc  lexer.Span
+}
+
+// 类型转换 (type) x
+type CastExpr struct {
+	Type Type
+	X    Expr
+	Loc  lexer.Span
+}
+
+// sizeof x 或 sizeof (type)，二者只有一个非空
+type SizeofExpr struct {
+	X    Expr
+	Type Type
+	Loc  lexer.Span
+}
+
+// 下标 x[index]
+type IndexExpr struct {
+	X     Expr
+	Index Expr
+	Loc   lexer.Span
+}
+
+// 成员访问 x.name 或 x->name
+type MemberExpr struct {
+	X     Expr
+	Name  *Ident
+	Arrow bool
+	Loc   lexer.Span
+}
+
+// 函数调用
+type CallExpr struct {
+	Func Expr
+	Args []Expr
+	Loc  lexer.Span
+}
+
+// 初始化列表 { elems }
+type InitList struct {
+	Elems []Expr
+	Loc   lexer.Span
+}
+
+// 无法解析的表达式，仅记录范围
+type BadExpr struct {
+	Loc lexer.Span
+}
+
+// 标识符
+type Ident struct {
+	Name string
+	Loc  lexer.Span
+}
+
+// 数值常量，Value 为原始拼写
+type NumberLit struct {
+	Value  string
+	Number *lexer.NumberValue
+	Loc    lexer.Span
+}
+
+// 字符常量，Value 为原始拼写，Decoded 为转义处理后的内容
+type CharLit struct {
+	Value   string
+	Decoded string
+	Loc     lexer.Span
+}
+
+// 字符串常量，相邻的字符串常量已由词法分析器连接
+type StringLit struct {
+	Value   string
+	Decoded string
+	Loc     lexer.Span
+}
+
+func (n *TranslationUnit) Span() lexer.Span { return n.Loc }
+func (n *FuncDef) Span() lexer.Span         { return n.Loc }
+func (n *VarDecl) Span() lexer.Span         { return n.Loc }
+func (n *BadDecl) Span() lexer.Span         { return n.Loc }
+func (n *Block) Span() lexer.Span           { return n.Loc }
+func (n *DeclStmt) Span() lexer.Span        { return n.Loc }
+func (n *ExprStmt) Span() lexer.Span        { return n.Loc }
+func (n *EmptyStmt) Span() lexer.Span       { return n.Loc }
+func (n *If) Span() lexer.Span              { return n.Loc }
+func (n *While) Span() lexer.Span           { return n.Loc }
+func (n *DoWhile) Span() lexer.Span         { return n.Loc }
+func (n *For) Span() lexer.Span             { return n.Loc }
+func (n *Switch) Span() lexer.Span          { return n.Loc }
+func (n *Case) Span() lexer.Span            { return n.Loc }
+func (n *Default) Span() lexer.Span         { return n.Loc }
+func (n *Return) Span() lexer.Span          { return n.Loc }
+func (n *Break) Span() lexer.Span           { return n.Loc }
+func (n *Continue) Span() lexer.Span        { return n.Loc }
+func (n *BadStmt) Span() lexer.Span         { return n.Loc }
+func (n *Assign) Span() lexer.Span          { return n.Loc }
+func (n *BinaryExpr) Span() lexer.Span      { return n.Loc }
+func (n *UnaryExpr) Span() lexer.Span       { return n.Loc }
+func (n *PostfixExpr) Span() lexer.Span     { return n.Loc }
+func (n *ParenExpr) Span() lexer.Span       { return n.Loc }
+func (n *CondExpr) Span() lexer.Span        { return n.Loc }
+func (n *CastExpr) Span() lexer.Span        { return n.Loc }
+func (n *SizeofExpr) Span() lexer.Span      { return n.Loc }
+func (n *IndexExpr) Span() lexer.Span       { return n.Loc }
+func (n *MemberExpr) Span() lexer.Span      { return n.Loc }
+func (n *CallExpr) Span() lexer.Span        { return n.Loc }
+func (n *InitList) Span() lexer.Span        { return n.Loc }
+func (n *BadExpr) Span() lexer.Span         { return n.Loc }
+func (n *Ident) Span() lexer.Span           { return n.Loc }
+func (n *NumberLit) Span() lexer.Span       { return n.Loc }
+func (n *CharLit) Span() lexer.Span         { return n.Loc }
+func (n *StringLit) Span() lexer.Span       { return n.Loc }
+
+func (*FuncDef) declNode() {}
+func (*VarDecl) declNode() {}
+func (*BadDecl) declNode() {}
+
+func (*Block) stmtNode()     {}
+func (*DeclStmt) stmtNode()  {}
+func (*ExprStmt) stmtNode()  {}
+func (*EmptyStmt) stmtNode() {}
+func (*If) stmtNode()        {}
+func (*While) stmtNode()     {}
+func (*DoWhile) stmtNode()   {}
+func (*For) stmtNode()       {}
+func (*Switch) stmtNode()    {}
+func (*Case) stmtNode()      {}
+func (*Default) stmtNode()   {}
+func (*Return) stmtNode()    {}
+func (*Break) stmtNode()     {}
+func (*Continue) stmtNode()  {}
+func (*BadStmt) stmtNode()   {}
+
+func (*Assign) exprNode()      {}
+func (*BinaryExpr) exprNode()  {}
+func (*UnaryExpr) exprNode()   {}
+func (*PostfixExpr) exprNode() {}
+func (*ParenExpr) exprNode()   {}
+func (*CondExpr) exprNode()    {}
+func (*CastExpr) exprNode()    {}
+func (*SizeofExpr) exprNode()  {}
+func (*IndexExpr) exprNode()   {}
+func (*MemberExpr) exprNode()  {}
+func (*CallExpr) exprNode()    {}
+func (*InitList) exprNode()    {}
+func (*BadExpr) exprNode()     {}
+func (*Ident) exprNode()       {}
+func (*NumberLit) exprNode()   {}
+func (*CharLit) exprNode()     {}
+func (*StringLit) exprNode()   {}
+
+// 合并两个位置区间
+func spanOf(start, end lexer.Span) lexer.Span {
+	return lexer.Span{Start: start.Start, End: end.End}
+}
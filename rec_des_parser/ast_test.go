@@ -0,0 +1,126 @@
+package rec_des_parser
+
+import (
+	"mygo_c_compiler/lexer"
+	"strings"
+	"testing"
+)
+
+// 节点范围覆盖的源代码
+func spanText(source string, span lexer.Span) string {
+	return source[span.Start.Offset:span.End.Offset]
+}
+
+func parseOK(t *testing.T, source string) *TranslationUnit {
+	t.Helper()
+	tree, errs := New().Parse(source)
+	if len(errs) > 0 {
+		t.Fatalf("%q: 语法错误 %v", source, errs)
+	}
+	return tree
+}
+
+func TestExprSpans(t *testing.T) {
+	for _, expr := range []string{
+		"(1 + 2)",
+		"(1 + 2) * 3",
+		"((a))",
+		"f(a, (b))",
+		"a[(i)]",
+		"s.x",
+		"p->x",
+		"(int)x",
+		"(int)(x)",
+		"sizeof (int)",
+		"sizeof x",
+		"-x",
+		"a == b ? c : d",
+		"{ 1, (2) }",
+		"\"a\" \"b\"",
+	} {
+		source := "int v = " + expr + ";"
+		tree := parseOK(t, source)
+		init := tree.Decls[0].(*VarDecl).Init
+		if got := spanText(source, init.Span()); got != expr {
+			t.Errorf("%s 的范围为 %q", expr, got)
+		}
+	}
+}
+
+func TestStmtSpans(t *testing.T) {
+	for _, stmt := range []string{
+		"x = 1;",
+		"(x) = 1;",
+		"int a = 1, *b;",
+		"{ x; { } }",
+		"if (a) x; else { y; }",
+		"if (a) x;",
+		"while (a) { }",
+		"do x++; while (a);",
+		"for (int i = 0; i < n; i++) ;",
+		"for (;;) break;",
+		"switch (a) { case 1: default: ; }",
+		"return (x);",
+		"return;",
+		";",
+	} {
+		source := "void f(void) { " + stmt + " }"
+		tree := parseOK(t, source)
+		body := tree.Decls[0].(*FuncDef).Body
+		if got := spanText(source, body.Stmts[0].Span()); got != stmt {
+			t.Errorf("%s 的范围为 %q", stmt, got)
+		}
+		if got := spanText(source, body.Span()); got != "{ "+stmt+" }" {
+			t.Errorf("函数体的范围为 %q", got)
+		}
+	}
+}
+
+func TestDeclSpans(t *testing.T) {
+	source := "static int x = 1, y;\nint f(void) { return 0; }\n"
+	tree := parseOK(t, source)
+	want := []string{"static int x = 1", "static int x = 1, y", "int f(void) { return 0; }"}
+	for i, decl := range tree.Decls {
+		if got := spanText(source, decl.Span()); got != want[i] {
+			t.Errorf("第 %d 个声明的范围为 %q, 期望 %q", i, got, want[i])
+		}
+	}
+	if got := spanText(source, tree.Span()); got != strings.TrimSpace(source) {
+		t.Errorf("翻译单元的范围为 %q", got)
+	}
+}
+
+func TestFuncTypeString(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"int f();", "func() int"},
+		{"int f(void);", "func(void) int"},
+		{"int f(int, char *p, ...);", "func(int, *char, ...) int"},
+		{"int (*f(void))(int);", "func(void) *func(int) int"},
+		{"const char *const p[3];", "[3]*const const char"},
+	}
+	for _, tt := range tests {
+		tree := parseOK(t, tt.source)
+		if got := tree.Decls[0].(*VarDecl).Type.String(); got != tt.want {
+			t.Errorf("%s 的类型为 %s, 期望 %s", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestPrintTracer(t *testing.T) {
+	var sb strings.Builder
+	New(WithTracer(NewPrintTracer(&sb))).Parse("int x;")
+	trace := sb.String()
+	for _, want := range []string{
+		"Entering translation_unit\n",
+		"translation_unit -> external_decl translation_unit\n",
+		"external_decl -> decl_specs init_declarators ;\n",
+		"translation_unit -> ε\n",
+	} {
+		if !strings.Contains(trace, want) {
+			t.Errorf("推导过程中没有 %q:\n%s", want, trace)
+		}
+	}
+}
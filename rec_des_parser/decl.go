@@ -0,0 +1,336 @@
+package rec_des_parser
+
+import (
+	"mygo_c_compiler/lexer"
+	"strings"
+)
+
+// 声明说明符的分析结果
+type declSpecs struct {
+	storage string // 存储类与函数说明符，如 "static inline"
+	typ     *BasicType
+	start   lexer.Token
+}
+
+// tok 能否开始一个声明：声明说明符或已声明的 typedef 名
+func (g *Parser) isDeclStart(tok lexer.Token) bool {
+	return firstDeclSpecs[tok.Type] || (tok.Type == lexer.IDENT && g.isTypedefName(tok.Value))
+}
+
+// 进入新的作用域
+func (g *Parser) pushScope() {
+	g.scopes = append(g.scopes, make(map[string]bool))
+}
+
+func (g *Parser) popScope() {
+	g.scopes = g.scopes[:len(g.scopes)-1]
+}
+
+// 在当前作用域中声明 name。普通标识符会遮蔽外层作用域中同名的 typedef 名
+func (g *Parser) declareName(name string, typedef bool) {
+	g.scopes[len(g.scopes)-1][name] = typedef
+}
+
+// name 是否为 typedef 名，由声明了 name 的最内层作用域决定
+func (g *Parser) isTypedefName(name string) bool {
+	for i := len(g.scopes) - 1; i >= 0; i-- {
+		if typedef, ok := g.scopes[i][name]; ok {
+			return typedef
+		}
+	}
+	return false
+}
+
+// 外部声明是错误恢复点，出错时返回 BadDecl
+func (g *Parser) externalDecl() (decls []Decl) {
+	g.tracer.Enter("external_decl")
+	start := g.tokens.Peek(1)
+	defer g.recoverDecl(start, g.consumed, &decls)
+
+	// 课程子集：main { ... }
+	if start.Type == lexer.MAIN && g.tokens.Peek(2).Type == lexer.LBRACE {
+		g.tracer.Production("external_decl -> main block")
+		g.advance()
+		body := g.block()
+		return []Decl{&FuncDef{
+			Name: &Ident{Name: start.Value, Loc: start.Span},
+			Type: &FuncType{Result: &BasicType{Name: "int"}},
+			Body: body,
+			Loc:  spanOf(start.Span, body.Loc),
+		}}
+	}
+	if !g.isDeclStart(start) {
+		g.errorAt(start, nil, "expected declaration, got %s", start.Type)
+		panic(bailout{})
+	}
+
+	specs := g.declSpecs()
+	if g.tokens.At(lexer.SEMICOLON) {
+		g.tracer.Production("external_decl -> decl_specs ;")
+		g.advance()
+		return nil
+	}
+	name, wrap := g.declarator(false)
+	typ := wrap(specs.typ)
+	if funcType, ok := typ.(*FuncType); ok && g.tokens.At(lexer.LBRACE) {
+		g.tracer.Production("external_decl -> decl_specs declarator block")
+		body := g.funcBody(funcType)
+		return []Decl{&FuncDef{
+			Storage: specs.storage,
+			Name:    name,
+			Type:    funcType,
+			Body:    body,
+			Loc:     spanOf(start.Span, body.Loc),
+		}}
+	}
+
+	g.tracer.Production("external_decl -> decl_specs init_declarators ;")
+	vars := g.initDeclarators(specs, name, typ)
+	g.match(lexer.SEMICOLON)
+	for _, v := range vars {
+		decls = append(decls, v)
+	}
+	return decls
+}
+
+// 向前试探当前位置是否为函数定义（声明说明符与函数声明符之后紧跟 {），之后回到原处，
+// 试探期间的语法错误与推导过程都不记录
+func (g *Parser) atFuncDef() (ok bool) {
+	saved := *g
+	mark := g.tokens.Mark()
+	g.tracer = nopTracer{}
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isBailout := r.(bailout); !isBailout {
+				panic(r)
+			}
+			ok = false
+		}
+		g.tokens.Reset(mark)
+		*g = saved
+	}()
+	specs := g.declSpecs()
+	_, wrap := g.declarator(false)
+	_, isFunc := wrap(specs.typ).(*FuncType)
+	return isFunc && g.tokens.At(lexer.LBRACE)
+}
+
+// 函数体，形参在函数体的作用域中声明
+func (g *Parser) funcBody(funcType *FuncType) *Block {
+	g.pushScope()
+	defer g.popScope()
+	for _, param := range funcType.Params {
+		if param.Name != nil {
+			g.declareName(param.Name.Name, false)
+		}
+	}
+	return g.block()
+}
+
+// 块内声明，包括 for 语句中的初始化声明
+func (g *Parser) declStmt() *DeclStmt {
+	g.tracer.Enter("declaration")
+	specs := g.declSpecs()
+	if g.tokens.At(lexer.SEMICOLON) {
+		g.tracer.Production("declaration -> decl_specs ;")
+		semi := g.advance()
+		return &DeclStmt{Loc: spanOf(specs.start.Span, semi.Span)}
+	}
+	g.tracer.Production("declaration -> decl_specs init_declarators ;")
+	name, wrap := g.declarator(false)
+	vars := g.initDeclarators(specs, name, wrap(specs.typ))
+	semi := g.match(lexer.SEMICOLON)
+	return &DeclStmt{Decls: vars, Loc: spanOf(specs.start.Span, semi.Span)}
+}
+
+// decl_specs -> (storage_class | type_qualifier | function_specifier | type_specifier)+
+func (g *Parser) declSpecs() declSpecs {
+	g.tracer.Enter("decl_specs")
+	specs := declSpecs{start: g.tokens.Peek(1)}
+	var storageClass string
+	var funcSpecs, names, quals []string
+	for {
+		tok := g.tokens.Peek(1)
+		switch {
+		case storageClasses[tok.Type]:
+			if storageClass != "" {
+				g.errorAt(tok, nil, "multiple storage classes in declaration specifiers")
+			}
+			storageClass = tok.Value
+		case funcSpecifiers[tok.Type]:
+			funcSpecs = append(funcSpecs, tok.Value)
+		case typeQualifiers[tok.Type]:
+			quals = append(quals, tok.Value)
+		case typeSpecifiers[tok.Type]:
+			names = append(names, tok.Value)
+		case tok.Type == lexer.IDENT && g.isTypedefName(tok.Value) && len(names) == 0:
+			names = append(names, tok.Value)
+		case unsupportedSpecifiers[tok.Type]:
+			g.errorAt(tok, nil, "%s is not supported", tok.Value)
+			panic(bailout{})
+		default:
+			if len(names) == 0 {
+				g.errorAt(tok, nil, "missing type specifier before %s", tok.Type)
+				names = []string{"int"}
+			}
+			if storageClass != "" {
+				funcSpecs = append([]string{storageClass}, funcSpecs...)
+			}
+			specs.storage = strings.Join(funcSpecs, " ")
+			specs.typ = &BasicType{Name: strings.Join(names, " "), Qualifiers: quals}
+			return specs
+		}
+		g.advance()
+	}
+}
+
+// init_declarators -> init_declarator ( , init_declarator )*，第一个声明符已由调用者读取
+func (g *Parser) initDeclarators(specs declSpecs, name *Ident, typ Type) []*VarDecl {
+	var vars []*VarDecl
+	for {
+		v := &VarDecl{Storage: specs.storage, Name: name, Type: typ}
+		if g.tokens.At(lexer.ASSIGN) {
+			g.advance()
+			v.Init = g.initializer()
+		}
+		v.Loc = spanOf(specs.start.Span, g.prev.Span)
+		vars = append(vars, v)
+		g.declareName(name.Name, strings.HasPrefix(specs.storage, "typedef"))
+		if !g.tokens.At(lexer.COMMA) {
+			return vars
+		}
+		g.advance()
+		var wrap func(Type) Type
+		name, wrap = g.declarator(false)
+		typ = wrap(specs.typ)
+	}
+}
+
+// initializer -> assign_expr | { initializer ( , initializer )* [,] }
+func (g *Parser) initializer() Expr {
+	if !g.tokens.At(lexer.LBRACE) {
+		return g.assignExpr()
+	}
+	lbrace := g.advance()
+	list := &InitList{}
+	for !g.tokens.At(lexer.RBRACE) {
+		list.Elems = append(list.Elems, g.initializer())
+		if !g.tokens.At(lexer.COMMA) {
+			break
+		}
+		g.advance()
+	}
+	rbrace := g.match(lexer.RBRACE)
+	list.Loc = spanOf(lbrace.Span, rbrace.Span)
+	return list
+}
+
+// declarator -> pointer direct_declarator
+// direct_declarator -> id suffixes | ( declarator ) suffixes
+// 返回声明的名字以及把声明说明符中的类型变为完整类型的函数。
+// abstract 为 true 时（形参声明）名字可以省略
+func (g *Parser) declarator(abstract bool) (*Ident, func(Type) Type) {
+	g.tracer.Enter("declarator")
+	var pointers [][]string
+	for g.tokens.At(lexer.ASTERISK) {
+		g.advance()
+		var quals []string
+		for typeQualifiers[g.tokens.Peek(1).Type] {
+			quals = append(quals, g.advance().Value)
+		}
+		pointers = append(pointers, quals)
+	}
+
+	var name *Ident
+	inner := func(t Type) Type { return t }
+	tok := g.tokens.Peek(1)
+	switch {
+	case tok.Type == lexer.IDENT || tok.Type == lexer.MAIN:
+		g.advance()
+		name = &Ident{Name: tok.Value, Loc: tok.Span}
+	case tok.Type == lexer.LPAREN && g.nestedDeclarator(abstract):
+		g.advance()
+		name, inner = g.declarator(abstract)
+		g.match(lexer.RPAREN)
+	case !abstract:
+		g.errorAt(tok, []lexer.TokenType{lexer.IDENT}, "expected %s, got %s", lexer.IDENT, tok.Type)
+		panic(bailout{})
+	}
+	suffix := g.declaratorSuffixes()
+
+	return name, func(t Type) Type {
+		for _, quals := range pointers {
+			t = &PointerType{Elem: t, Qualifiers: quals}
+		}
+		return inner(suffix(t))
+	}
+}
+
+// 声明符中的 ( 是否开始一个括号内的声明符，而不是形参列表（需要向前查看两个 Token）
+func (g *Parser) nestedDeclarator(abstract bool) bool {
+	if !abstract {
+		return true
+	}
+	next := g.tokens.Peek(2)
+	switch next.Type {
+	case lexer.ASTERISK, lexer.LPAREN, lexer.LBRACKET, lexer.MAIN:
+		return true
+	case lexer.IDENT:
+		return !g.isTypedefName(next.Value)
+	}
+	return false
+}
+
+// suffixes -> [ expr? ] suffixes | ( params ) suffixes | ε
+func (g *Parser) declaratorSuffixes() func(Type) Type {
+	switch g.tokens.Peek(1).Type {
+	case lexer.LBRACKET:
+		g.advance()
+		var length Expr
+		if !g.tokens.At(lexer.RBRACKET) {
+			length = g.constantExpr()
+		}
+		g.match(lexer.RBRACKET)
+		rest := g.declaratorSuffixes()
+		return func(t Type) Type { return &ArrayType{Elem: rest(t), Len: length} }
+	case lexer.LPAREN:
+		params, variadic, void := g.params()
+		rest := g.declaratorSuffixes()
+		return func(t Type) Type { return &FuncType{Result: rest(t), Params: params, Variadic: variadic, Void: void} }
+	}
+	return func(t Type) Type { return t }
+}
+
+// params -> ( ) | ( void ) | ( param_decl ( , param_decl )* [ , ... ] )
+// 返回形参、是否有 ... 以及形参列表是否为 (void)
+func (g *Parser) params() ([]*Param, bool, bool) {
+	g.tracer.Enter("params")
+	g.match(lexer.LPAREN)
+	if g.tokens.At(lexer.VOID) && g.tokens.Peek(2).Type == lexer.RPAREN {
+		g.advance()
+		g.advance()
+		return nil, false, true
+	}
+	var params []*Param
+	variadic := false
+	for !g.tokens.At(lexer.RPAREN) {
+		if g.tokens.At(lexer.ELLIPSIS) && len(params) > 0 {
+			g.advance()
+			variadic = true
+			break
+		}
+		if tok := g.tokens.Peek(1); !g.isDeclStart(tok) {
+			g.errorAt(tok, nil, "expected parameter declaration, got %s", tok.Type)
+			panic(bailout{})
+		}
+		specs := g.declSpecs()
+		name, wrap := g.declarator(true)
+		params = append(params, &Param{Name: name, Type: wrap(specs.typ)})
+		if !g.tokens.At(lexer.COMMA) {
+			break
+		}
+		g.advance()
+	}
+	g.match(lexer.RPAREN)
+	return params, variadic, false
+}
@@ -0,0 +1,174 @@
+package rec_des_parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 以缩进的树形文本输出语法树
+func Dump(node Node) string {
+	var sb strings.Builder
+	dump(&sb, node, 0)
+	return sb.String()
+}
+
+func dump(sb *strings.Builder, node Node, depth int) {
+	if node == nil {
+		return
+	}
+	indent := strings.Repeat("  ", depth)
+	line := func(format string, args ...any) {
+		fmt.Fprintf(sb, "%s%s %s\n", indent, fmt.Sprintf(format, args...), node.Span().Start)
+	}
+	// 带标签的可选子节点
+	labeled := func(label string, child Node) {
+		if child == nil {
+			return
+		}
+		fmt.Fprintf(sb, "%s  %s\n", indent, label)
+		dump(sb, child, depth+2)
+	}
+
+	switch n := node.(type) {
+	case *TranslationUnit:
+		line("TranslationUnit")
+		for _, decl := range n.Decls {
+			dump(sb, decl, depth+1)
+		}
+	case *FuncDef:
+		line("FuncDef %s%s %s", storagePrefix(n.Storage), n.Name.Name, n.Type)
+		dump(sb, n.Body, depth+1)
+	case *VarDecl:
+		line("VarDecl %s%s %s", storagePrefix(n.Storage), n.Name.Name, n.Type)
+		dump(sb, n.Init, depth+1)
+	case *BadDecl:
+		line("BadDecl")
+	case *Block:
+		line("Block")
+		for _, stmt := range n.Stmts {
+			dump(sb, stmt, depth+1)
+		}
+	case *DeclStmt:
+		line("DeclStmt")
+		for _, decl := range n.Decls {
+			dump(sb, decl, depth+1)
+		}
+	case *ExprStmt:
+		line("ExprStmt")
+		dump(sb, n.X, depth+1)
+	case *EmptyStmt:
+		line("EmptyStmt")
+	case *If:
+		line("If")
+		dump(sb, n.Cond, depth+1)
+		dump(sb, n.Then, depth+1)
+		labeled("Else", n.Else)
+	case *While:
+		line("While")
+		dump(sb, n.Cond, depth+1)
+		dump(sb, n.Body, depth+1)
+	case *DoWhile:
+		line("DoWhile")
+		dump(sb, n.Body, depth+1)
+		dump(sb, n.Cond, depth+1)
+	case *For:
+		line("For")
+		labeled("Init", n.Init)
+		labeled("Cond", n.Cond)
+		labeled("Post", n.Post)
+		dump(sb, n.Body, depth+1)
+	case *Switch:
+		line("Switch")
+		dump(sb, n.Tag, depth+1)
+		dump(sb, n.Body, depth+1)
+	case *Case:
+		line("Case")
+		dump(sb, n.Value, depth+1)
+		dump(sb, n.Body, depth+1)
+	case *Default:
+		line("Default")
+		dump(sb, n.Body, depth+1)
+	case *Return:
+		line("Return")
+		dump(sb, n.Value, depth+1)
+	case *Break:
+		line("Break")
+	case *Continue:
+		line("Continue")
+	case *BadStmt:
+		line("BadStmt")
+	case *Assign:
+		line("Assign %s", n.Op)
+		dump(sb, n.Target, depth+1)
+		dump(sb, n.Value, depth+1)
+	case *BinaryExpr:
+		line("BinaryExpr %s", n.Op)
+		dump(sb, n.Left, depth+1)
+		dump(sb, n.Right, depth+1)
+	case *UnaryExpr:
+		line("UnaryExpr %s", n.Op)
+		dump(sb, n.X, depth+1)
+	case *PostfixExpr:
+		line("PostfixExpr %s", n.Op)
+		dump(sb, n.X, depth+1)
+	case *ParenExpr:
+		line("ParenExpr")
+		dump(sb, n.X, depth+1)
+	case *CondExpr:
+		line("CondExpr")
+		dump(sb, n.Cond, depth+1)
+		dump(sb, n.Then, depth+1)
+		dump(sb, n.Else, depth+1)
+	case *CastExpr:
+		line("CastExpr %s", n.Type)
+		dump(sb, n.X, depth+1)
+	case *SizeofExpr:
+		if n.Type != nil {
+			line("SizeofExpr %s", n.Type)
+			break
+		}
+		line("SizeofExpr")
+		dump(sb, n.X, depth+1)
+	case *IndexExpr:
+		line("IndexExpr")
+		dump(sb, n.X, depth+1)
+		dump(sb, n.Index, depth+1)
+	case *MemberExpr:
+		if n.Arrow {
+			line("MemberExpr ->%s", n.Name.Name)
+		} else {
+			line("MemberExpr .%s", n.Name.Name)
+		}
+		dump(sb, n.X, depth+1)
+	case *CallExpr:
+		line("CallExpr")
+		dump(sb, n.Func, depth+1)
+		for _, arg := range n.Args {
+			dump(sb, arg, depth+1)
+		}
+	case *InitList:
+		line("InitList")
+		for _, elem := range n.Elems {
+			dump(sb, elem, depth+1)
+		}
+	case *BadExpr:
+		line("BadExpr")
+	case *Ident:
+		line("Ident %s", n.Name)
+	case *NumberLit:
+		line("NumberLit %s", n.Value)
+	case *CharLit:
+		line("CharLit %s", n.Value)
+	case *StringLit:
+		line("StringLit %s", n.Value)
+	default:
+		line("%T", n)
+	}
+}
+
+func storagePrefix(storage string) string {
+	if storage == "" {
+		return ""
+	}
+	return storage + " "
+}
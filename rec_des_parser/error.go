@@ -0,0 +1,164 @@
+package rec_des_parser
+
+import (
+	"fmt"
+	"mygo_c_compiler/lexer"
+)
+
+// 一条语法错误
+type SyntaxError struct {
+	Span     lexer.Span
+	Expected []lexer.TokenType // 期望的 Token 类型，无法一一列出时为空
+	Got      lexer.Token
+	Message  string
+}
+
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("%s: Syntax Error: %s", e.Span.Start, e.Message)
+}
+
+// 发生语法错误后沿调用栈向上传递，由最近的恢复点捕获
+type bailout struct{}
+
+type tokenSet map[lexer.TokenType]bool
+
+func newTokenSet(types ...lexer.TokenType) tokenSet {
+	set := make(tokenSet, len(types))
+	for _, t := range types {
+		set[t] = true
+	}
+	return set
+}
+
+func (s tokenSet) union(other tokenSet) tokenSet {
+	result := make(tokenSet, len(s)+len(other))
+	for t := range s {
+		result[t] = true
+	}
+	for t := range other {
+		result[t] = true
+	}
+	return result
+}
+
+var (
+	// 声明说明符
+	storageClasses = newTokenSet(lexer.TYPEDEF, lexer.EXTERN, lexer.STATIC, lexer.AUTO, lexer.REGISTER, lexer.THREAD_LOCAL)
+	typeQualifiers = newTokenSet(lexer.CONST, lexer.VOLATILE, lexer.RESTRICT)
+	funcSpecifiers = newTokenSet(lexer.INLINE, lexer.NORETURN)
+	typeSpecifiers = newTokenSet(lexer.VOID, lexer.CHAR_TYPE, lexer.SHORT, lexer.INT, lexer.LONG,
+		lexer.FLOAT_TYPE, lexer.DOUBLE, lexer.SIGNED, lexer.UNSIGNED, lexer.BOOL, lexer.COMPLEX)
+	unsupportedSpecifiers = newTokenSet(lexer.STRUCT, lexer.UNION, lexer.ENUM)
+	// FIRST(decl_specs)，不含 typedef 名
+	firstDeclSpecs = storageClasses.union(typeQualifiers).union(funcSpecifiers).
+			union(typeSpecifiers).union(unsupportedSpecifiers)
+	// FIRST(expr)：操作数与前缀一元运算符
+	firstExpr = newTokenSet(lexer.IDENT, lexer.MAIN, lexer.NUMBER, lexer.HEX, lexer.OCTAL, lexer.BINARY,
+		lexer.FLOAT, lexer.CHAR, lexer.STRING, lexer.LPAREN, lexer.SIZEOF, lexer.INCREMENT, lexer.DECREMENT,
+		lexer.PLUS, lexer.MINUS, lexer.NOT, lexer.TILDE, lexer.ASTERISK, lexer.AMPERSAND)
+	// 以关键字或 { 开始的语句
+	stmtKeywords = newTokenSet(lexer.IF, lexer.WHILE, lexer.DO, lexer.FOR, lexer.SWITCH, lexer.CASE,
+		lexer.DEFAULT, lexer.RETURN, lexer.BREAK, lexer.CONTINUE, lexer.LBRACE)
+	// FOLLOW(stmt) 中适合作为同步点的 Token：语句关键字、声明说明符、} 与 else，加上 EOF 防止越过输入末尾。
+	// 标识符只在行首时作为同步点（见 synchronize）；字面量和 ( 虽然也能开始语句，但更可能是出错语句的一部分
+	followStmt = stmtKeywords.union(firstDeclSpecs).union(newTokenSet(lexer.RBRACE, lexer.ELSE, lexer.EOF))
+	// struct { ... } 之后可以继续声明符
+	continuesDeclarators = newTokenSet(lexer.IDENT, lexer.ASTERISK, lexer.SEMICOLON)
+	// FOLLOW(operand)：二元、赋值与后缀运算符以及 ) ] , : ; }
+	followOperand = newTokenSet(lexer.QUESTION, lexer.OR, lexer.AND, lexer.PIPE, lexer.CARET, lexer.AMPERSAND,
+		lexer.EQ, lexer.NEQ, lexer.LT, lexer.LTE, lexer.GT, lexer.GTE, lexer.SHL, lexer.SHR,
+		lexer.PLUS, lexer.MINUS, lexer.ASTERISK, lexer.SLASH, lexer.PERCENT,
+		lexer.ASSIGN, lexer.PLUS_ASSIGN, lexer.MINUS_ASSIGN, lexer.ASTERISK_ASSIGN, lexer.SLASH_ASSIGN,
+		lexer.PERCENT_ASSIGN, lexer.SHL_ASSIGN, lexer.SHR_ASSIGN, lexer.AMPERSAND_ASSIGN, lexer.CARET_ASSIGN,
+		lexer.PIPE_ASSIGN, lexer.LBRACKET, lexer.DOT, lexer.ARROW, lexer.INCREMENT, lexer.DECREMENT,
+		lexer.RPAREN, lexer.RBRACKET, lexer.COMMA, lexer.COLON, lexer.SEMICOLON, lexer.RBRACE)
+)
+
+// 记录一条语法错误。同一位置只记录第一条，避免一个错误引起的连锁报错
+func (g *Parser) errorAt(tok lexer.Token, expected []lexer.TokenType, format string, args ...any) {
+	if n := len(g.errors); n > 0 && g.errors[n-1].Span.Start == tok.Span.Start {
+		return
+	}
+	g.errors = append(g.errors, SyntaxError{
+		Span:     tok.Span,
+		Expected: expected,
+		Got:      tok,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// 跳过 Token 直到遇见 stop 中的 Token 或行首的标识符（不消耗），或 ;（消耗）为止。
+// 行中间的标识符多半属于出错的语句本身，例如 while (x y) 中的 y
+func (g *Parser) synchronize(stop tokenSet) {
+	for {
+		tok := g.tokens.Peek(1)
+		if stop[tok.Type] || tok.Type == lexer.EOF || (tok.Type == lexer.IDENT && tok.LineStart) {
+			return
+		}
+		g.advance()
+		if tok.Type == lexer.SEMICOLON {
+			return
+		}
+	}
+}
+
+// 跳过出错的外部声明：跳过成对的 { }，停在顶层的 ;（消耗）或声明说明符（不消耗）处。
+// 至少消耗一个 Token 后才在声明说明符处停止，否则出错的说明符本身（如 struct）会再次引起错误。
+// 顶层的 } 之后不是声明符或 ; 时（如出错的函数定义之后）也停止
+func (g *Parser) skipExternalDecl(consumed int) {
+	depth := 0
+	for {
+		tok := g.tokens.Peek(1)
+		switch {
+		case tok.Type == lexer.EOF:
+			return
+		case depth == 0 && g.consumed > consumed && g.isDeclStart(tok):
+			return
+		case tok.Type == lexer.LBRACE:
+			depth++
+		case tok.Type == lexer.RBRACE:
+			depth--
+		}
+		g.advance()
+		switch {
+		case depth < 0:
+			return
+		case depth > 0:
+			continue
+		case tok.Type == lexer.SEMICOLON:
+			return
+		case tok.Type == lexer.RBRACE && !continuesDeclarators[g.tokens.Peek(1).Type]:
+			return
+		}
+	}
+}
+
+// 语句级恢复点，以 defer 方式调用：跳过到 FOLLOW(stmt) 或 ; 之后，结果替换为 BadStmt
+func (g *Parser) recoverStmt(start lexer.Token, node *Stmt) {
+	if r := recover(); r != nil {
+		if _, ok := r.(bailout); !ok {
+			panic(r)
+		}
+		g.synchronize(followStmt)
+		*node = &BadStmt{Loc: g.spanFrom(start)}
+	}
+}
+
+// 外部声明级恢复点，以 defer 方式调用：跳过出错的声明，结果替换为 BadDecl。
+// consumed 为声明开始时已消耗的 Token 数
+func (g *Parser) recoverDecl(start lexer.Token, consumed int, decls *[]Decl) {
+	if r := recover(); r != nil {
+		if _, ok := r.(bailout); !ok {
+			panic(r)
+		}
+		g.skipExternalDecl(consumed)
+		*decls = []Decl{&BadDecl{Loc: g.spanFrom(start)}}
+	}
+}
+
+// 恢复点跳过 Token 后仍停在原处时强制前进一个 Token，保证循环能够结束
+func (g *Parser) ensureProgress(consumed int) {
+	if g.consumed == consumed && !g.tokens.At(lexer.EOF) {
+		g.advance()
+	}
+}
@@ -0,0 +1,210 @@
+package rec_des_parser
+
+import (
+	"strings"
+	"testing"
+)
+
+type parseCase struct {
+	name   string
+	source string
+	dump   string   // Dump 的结果，比较时忽略空行与每行开头的制表符
+	errors []string // 语法错误的 Error()
+}
+
+func runParseCases(t *testing.T, cases []parseCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tree, errs := New().Parse(c.source)
+			if got, want := dumpLines(Dump(tree)), dumpLines(c.dump); got != want {
+				t.Errorf("%q 的语法树为\n%s\n期望\n%s", c.source, Dump(tree), c.dump)
+			}
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(c.errors, "\n") {
+				t.Errorf("%q 的语法错误为 %q，期望 %q", c.source, got, c.errors)
+			}
+		})
+	}
+}
+
+// 去掉空行、行尾空白与每行开头的制表符，便于在测试中缩进书写期望的 Dump 结果
+func dumpLines(s string) string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimRight(strings.TrimLeft(line, "\t"), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// 一条出错的语句或声明只报告一个错误，并从下一条语句或声明继续分析
+func TestRecovery(t *testing.T) {
+	runParseCases(t, []parseCase{
+		{
+			name:   "声明符之间缺少逗号",
+			source: "int f(void) { int a b; c; }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) int 1:1
+				    Block 1:13
+				      BadStmt 1:15
+				      ExprStmt 1:24
+				        Ident c 1:24`,
+			errors: []string{"1:21: Syntax Error: expected SEMICOLON, got IDENTIFIER"},
+		},
+		{
+			name:   "条件中多余的标识符",
+			source: "int f(void) { while (x y) z; w; }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) int 1:1
+				    Block 1:13
+				      BadStmt 1:15
+				      ExprStmt 1:30
+				        Ident w 1:30`,
+			errors: []string{"1:24: Syntax Error: expected RPAREN, got IDENTIFIER"},
+		},
+		{
+			name:   "缺少右括号",
+			source: "int f(void) { x = (1 + 2; y; }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) int 1:1
+				    Block 1:13
+				      BadStmt 1:15
+				      ExprStmt 1:27
+				        Ident y 1:27`,
+			errors: []string{"1:25: Syntax Error: expected RPAREN, got SEMICOLON"},
+		},
+		{
+			name:   "缺少操作数",
+			source: "int f(void) { x = 1 + ; y; }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) int 1:1
+				    Block 1:13
+				      ExprStmt 1:15
+				        Assign ASSIGN 1:15
+				          Ident x 1:15
+				          BinaryExpr PLUS 1:19
+				            NumberLit 1 1:19
+				            BadExpr 1:23
+				      ExprStmt 1:25
+				        Ident y 1:25`,
+			errors: []string{"1:23: Syntax Error: unexpected token SEMICOLON"},
+		},
+		{
+			name:   "行首的标识符作为同步点",
+			source: "int f(void) { x = 1 2\ny = 3; }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) int 1:1
+				    Block 1:13
+				      BadStmt 1:15
+				      ExprStmt 2:1
+				        Assign ASSIGN 2:1
+				          Ident y 2:1
+				          NumberLit 3 2:5`,
+			errors: []string{"1:21: Syntax Error: expected SEMICOLON, got NUMBER"},
+		},
+		{
+			name:   "缺少初始值",
+			source: "int x = ; int y;",
+			dump: `
+				TranslationUnit 1:1
+				  VarDecl x int 1:1
+				    BadExpr 1:9
+				  VarDecl y int 1:11`,
+			errors: []string{"1:9: Syntax Error: unexpected token SEMICOLON"},
+		},
+		{
+			name:   "不是声明",
+			source: "+ + ; int y;",
+			dump: `
+				TranslationUnit 1:1
+				  BadDecl 1:1
+				  VarDecl y int 1:7`,
+			errors: []string{"1:1: Syntax Error: expected declaration, got PLUS"},
+		},
+		{
+			name:   "不支持的说明符",
+			source: "struct s { int a; } v; int y;",
+			dump: `
+				TranslationUnit 1:1
+				  BadDecl 1:1
+				  VarDecl y int 1:24`,
+			errors: []string{"1:1: Syntax Error: struct is not supported"},
+		},
+	})
+}
+
+func TestTypedefScope(t *testing.T) {
+	runParseCases(t, []parseCase{
+		{
+			name:   "块中的 typedef 名在块外不可见",
+			source: "void f(void) { typedef int T; } T x;",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) void 1:1
+				    Block 1:14
+				      DeclStmt 1:16
+				        VarDecl typedef T int 1:16
+				  BadDecl 1:33`,
+			errors: []string{"1:33: Syntax Error: expected declaration, got IDENTIFIER"},
+		},
+		{
+			name:   "局部变量遮蔽 typedef 名",
+			source: "typedef int T; void f(void) { int T; T = 1; } T y;",
+			dump: `
+				TranslationUnit 1:1
+				  VarDecl typedef T int 1:1
+				  FuncDef f func(void) void 1:16
+				    Block 1:29
+				      DeclStmt 1:31
+				        VarDecl T int 1:31
+				      ExprStmt 1:38
+				        Assign ASSIGN 1:38
+				          Ident T 1:38
+				          NumberLit 1 1:42
+				  VarDecl y T 1:47`,
+		},
+		{
+			name:   "形参遮蔽 typedef 名",
+			source: "typedef int T; void f(int T) { T * 2; }",
+			dump: `
+				TranslationUnit 1:1
+				  VarDecl typedef T int 1:1
+				  FuncDef f func(int) void 1:16
+				    Block 1:30
+				      ExprStmt 1:32
+				        BinaryExpr ASTERISK 1:32
+				          Ident T 1:32
+				          NumberLit 2 1:36`,
+		},
+		{
+			name:   "for 语句中的 typedef 名",
+			source: "void f(void) { for (typedef int T;;) { T x; } T * y; }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) void 1:1
+				    Block 1:14
+				      For 1:16
+				        Init
+				          DeclStmt 1:21
+				            VarDecl typedef T int 1:21
+				        Block 1:38
+				          DeclStmt 1:40
+				            VarDecl x T 1:40
+				      ExprStmt 1:47
+				        BinaryExpr ASTERISK 1:47
+				          Ident T 1:47
+				          Ident y 1:51`,
+		},
+	})
+}
@@ -0,0 +1,281 @@
+package rec_des_parser
+
+import (
+	"mygo_c_compiler/lexer"
+)
+
+// 二元运算符的优先级，数值越大结合越紧。一元与后缀运算符的优先级高于所有二元运算符，
+// 在 unaryExpr 与 postfixExpr 中处理，合计 C 语言的 15 个优先级
+const (
+	precComma          = iota + 1 // ,
+	precAssign                    // = += -= *= /= %= <<= >>= &= ^= |=，右结合
+	precConditional               // ?:，右结合
+	precLogicalOr                 // ||
+	precLogicalAnd                // &&
+	precBitOr                     // |
+	precBitXor                    // ^
+	precBitAnd                    // &
+	precEquality                  // == !=
+	precRelational                // < <= > >=
+	precShift                     // << >>
+	precAdditive                  // + -
+	precMultiplicative            // * / %
+)
+
+var binaryPrecedence = map[lexer.TokenType]int{
+	lexer.COMMA:            precComma,
+	lexer.ASSIGN:           precAssign,
+	lexer.PLUS_ASSIGN:      precAssign,
+	lexer.MINUS_ASSIGN:     precAssign,
+	lexer.ASTERISK_ASSIGN:  precAssign,
+	lexer.SLASH_ASSIGN:     precAssign,
+	lexer.PERCENT_ASSIGN:   precAssign,
+	lexer.SHL_ASSIGN:       precAssign,
+	lexer.SHR_ASSIGN:       precAssign,
+	lexer.AMPERSAND_ASSIGN: precAssign,
+	lexer.CARET_ASSIGN:     precAssign,
+	lexer.PIPE_ASSIGN:      precAssign,
+	lexer.QUESTION:         precConditional,
+	lexer.OR:               precLogicalOr,
+	lexer.AND:              precLogicalAnd,
+	lexer.PIPE:             precBitOr,
+	lexer.CARET:            precBitXor,
+	lexer.AMPERSAND:        precBitAnd,
+	lexer.EQ:               precEquality,
+	lexer.NEQ:              precEquality,
+	lexer.LT:               precRelational,
+	lexer.LTE:              precRelational,
+	lexer.GT:               precRelational,
+	lexer.GTE:              precRelational,
+	lexer.SHL:              precShift,
+	lexer.SHR:              precShift,
+	lexer.PLUS:             precAdditive,
+	lexer.MINUS:            precAdditive,
+	lexer.ASTERISK:         precMultiplicative,
+	lexer.SLASH:            precMultiplicative,
+	lexer.PERCENT:          precMultiplicative,
+}
+
+// 前缀一元运算符
+var unaryOperators = newTokenSet(lexer.INCREMENT, lexer.DECREMENT, lexer.PLUS, lexer.MINUS,
+	lexer.NOT, lexer.TILDE, lexer.ASTERISK, lexer.AMPERSAND)
+
+// expr -> assign_expr ( , assign_expr )*
+func (g *Parser) expr() Expr {
+	g.tracer.Enter("expr")
+	return g.binaryExpr(precComma)
+}
+
+// 赋值表达式，用于实参与初始化器等不允许逗号运算符的位置
+func (g *Parser) assignExpr() Expr {
+	g.tracer.Enter("assign_expr")
+	return g.binaryExpr(precAssign)
+}
+
+// case 标号与数组长度中的常量表达式（条件表达式）
+func (g *Parser) constantExpr() Expr {
+	g.tracer.Enter("constant_expr")
+	return g.binaryExpr(precConditional)
+}
+
+// 优先级爬升：读取一个操作数，然后不断合并优先级不低于 minPrec 的二元运算符。
+// 左结合运算符的右操作数以 prec+1 递归，右结合的赋值与条件运算符以 prec 递归
+func (g *Parser) binaryExpr(minPrec int) Expr {
+	left := g.unaryExpr()
+	for {
+		op := g.tokens.Peek(1)
+		prec, ok := binaryPrecedence[op.Type]
+		if !ok || prec < minPrec {
+			return left
+		}
+		g.advance()
+		switch prec {
+		case precAssign:
+			g.tracer.Production("assign_expr -> unary_expr " + op.Value + " assign_expr")
+			g.checkAssignable(left, op)
+			right := g.binaryExpr(precAssign)
+			left = &Assign{Op: op.Type, Target: left, Value: right, Loc: spanOf(left.Span(), right.Span())}
+		case precConditional:
+			g.tracer.Production("conditional_expr -> binary_expr ? expr : conditional_expr")
+			then := g.expr()
+			g.match(lexer.COLON)
+			els := g.binaryExpr(precConditional)
+			left = &CondExpr{Cond: left, Then: then, Else: els, Loc: spanOf(left.Span(), els.Span())}
+		default:
+			g.tracer.Production("binary_expr -> binary_expr " + op.Value + " binary_expr")
+			right := g.binaryExpr(prec + 1)
+			left = &BinaryExpr{Op: op.Type, Left: left, Right: right, Loc: spanOf(left.Span(), right.Span())}
+		}
+	}
+}
+
+// 赋值与自增自减的操作数必须是可以赋值的表达式，括号不影响是否可以赋值
+func (g *Parser) checkAssignable(x Expr, op lexer.Token) {
+	for paren, ok := x.(*ParenExpr); ok; paren, ok = x.(*ParenExpr) {
+		x = paren.X
+	}
+	switch x := x.(type) {
+	case *Ident, *IndexExpr, *MemberExpr, *BadExpr:
+		return
+	case *UnaryExpr:
+		if x.Op == lexer.ASTERISK {
+			return
+		}
+	}
+	g.errorAt(op, nil, "operand of %s is not assignable", op.Value)
+}
+
+// unary_expr -> unary_op cast_expr | sizeof unary_expr | sizeof ( type_name ) | ( type_name ) cast_expr | postfix_expr
+func (g *Parser) unaryExpr() Expr {
+	g.tracer.Enter("unary_expr")
+	token := g.tokens.Peek(1)
+	switch {
+	case unaryOperators[token.Type]:
+		g.tracer.Production("unary_expr -> " + token.Value + " cast_expr")
+		g.advance()
+		x := g.unaryExpr()
+		if token.Type == lexer.INCREMENT || token.Type == lexer.DECREMENT {
+			g.checkAssignable(x, token)
+		}
+		return &UnaryExpr{Op: token.Type, X: x, Loc: spanOf(token.Span, x.Span())}
+	case token.Type == lexer.SIZEOF:
+		g.advance()
+		if g.tokens.At(lexer.LPAREN) && g.isDeclStart(g.tokens.Peek(2)) {
+			g.tracer.Production("unary_expr -> sizeof ( type_name )")
+			g.advance()
+			typ := g.typeName()
+			rparen := g.match(lexer.RPAREN)
+			return &SizeofExpr{Type: typ, Loc: spanOf(token.Span, rparen.Span)}
+		}
+		g.tracer.Production("unary_expr -> sizeof unary_expr")
+		x := g.unaryExpr()
+		return &SizeofExpr{X: x, Loc: spanOf(token.Span, x.Span())}
+	case token.Type == lexer.LPAREN && g.isDeclStart(g.tokens.Peek(2)):
+		// ( 后面是类型名时为类型转换，否则为括号表达式
+		g.tracer.Production("cast_expr -> ( type_name ) cast_expr")
+		g.advance()
+		typ := g.typeName()
+		g.match(lexer.RPAREN)
+		x := g.unaryExpr()
+		return &CastExpr{Type: typ, X: x, Loc: spanOf(token.Span, x.Span())}
+	}
+	return g.postfixExpr()
+}
+
+// type_name -> decl_specs abstract_declarator
+func (g *Parser) typeName() Type {
+	g.tracer.Enter("type_name")
+	specs := g.declSpecs()
+	name, wrap := g.declarator(true)
+	if name != nil {
+		g.errorAt(g.prev, nil, "unexpected identifier %s in type name", name.Name)
+	}
+	return wrap(specs.typ)
+}
+
+// postfix_expr -> primary_expr ( ( args ) | [ expr ] | . id | -> id | ++ | -- )*
+func (g *Parser) postfixExpr() Expr {
+	g.tracer.Enter("postfix_expr")
+	x := g.primaryExpr()
+	for {
+		token := g.tokens.Peek(1)
+		switch token.Type {
+		case lexer.LPAREN:
+			g.tracer.Production("postfix_expr -> postfix_expr ( args )")
+			x = g.callArgs(x)
+		case lexer.LBRACKET:
+			g.tracer.Production("postfix_expr -> postfix_expr [ expr ]")
+			g.advance()
+			index := g.expr()
+			rbracket := g.match(lexer.RBRACKET)
+			x = &IndexExpr{X: x, Index: index, Loc: spanOf(x.Span(), rbracket.Span)}
+		case lexer.DOT, lexer.ARROW:
+			g.tracer.Production("postfix_expr -> postfix_expr " + token.Value + " id")
+			g.advance()
+			name := g.match(lexer.IDENT)
+			x = &MemberExpr{
+				X:     x,
+				Name:  &Ident{Name: name.Value, Loc: name.Span},
+				Arrow: token.Type == lexer.ARROW,
+				Loc:   spanOf(x.Span(), name.Span),
+			}
+		case lexer.INCREMENT, lexer.DECREMENT:
+			g.tracer.Production("postfix_expr -> postfix_expr " + token.Value)
+			g.advance()
+			g.checkAssignable(x, token)
+			x = &PostfixExpr{Op: token.Type, X: x, Loc: spanOf(x.Span(), token.Span)}
+		default:
+			return x
+		}
+	}
+}
+
+// args -> assign_expr ( , assign_expr )* | ε
+func (g *Parser) callArgs(fn Expr) Expr {
+	g.match(lexer.LPAREN)
+	call := &CallExpr{Func: fn}
+	if !g.tokens.At(lexer.RPAREN) {
+		for {
+			call.Args = append(call.Args, g.assignExpr())
+			if !g.tokens.At(lexer.COMMA) {
+				break
+			}
+			g.advance()
+		}
+	}
+	rparen := g.match(lexer.RPAREN)
+	call.Loc = spanOf(fn.Span(), rparen.Span)
+	return call
+}
+
+// primary_expr -> id | num | char | string | ( expr )
+func (g *Parser) primaryExpr() Expr {
+	g.tracer.Enter("primary_expr")
+	token := g.tokens.Peek(1)
+	switch token.Type {
+	case lexer.LPAREN:
+		g.tracer.Production("primary_expr -> ( expr )")
+		g.advance()
+		inner := g.expr()
+		rparen := g.match(lexer.RPAREN)
+		return &ParenExpr{X: inner, Loc: spanOf(token.Span, rparen.Span)}
+	case lexer.IDENT, lexer.MAIN:
+		g.tracer.Production("primary_expr -> id")
+		g.advance()
+		return &Ident{Name: token.Value, Loc: token.Span}
+	case lexer.NUMBER, lexer.HEX, lexer.OCTAL, lexer.BINARY, lexer.FLOAT:
+		g.tracer.Production("primary_expr -> num")
+		g.advance()
+		return &NumberLit{Value: token.Value, Number: token.Number, Loc: token.Span}
+	case lexer.CHAR:
+		g.tracer.Production("primary_expr -> char")
+		g.advance()
+		return &CharLit{Value: token.Value, Decoded: token.Decoded, Loc: token.Span}
+	case lexer.STRING:
+		g.tracer.Production("primary_expr -> string")
+		g.advance()
+		return &StringLit{Value: token.Value, Decoded: token.Decoded, Loc: token.Span}
+	default:
+		return g.badOperand(token)
+	}
+}
+
+// 缺少操作数时不消耗 Token，返回 BadExpr；否则跳过多余的 Token，
+// 停在 FIRST(expr) 中的 Token 时把它作为操作数继续分析，停在 FOLLOW(operand) 中的 Token 时返回 BadExpr
+func (g *Parser) badOperand(token lexer.Token) Expr {
+	g.errorAt(token, nil, "unexpected token %s", token.Type)
+	stop := followOperand.union(followStmt)
+	if stop[token.Type] {
+		return &BadExpr{Loc: token.Span}
+	}
+	for {
+		tok := g.tokens.Peek(1)
+		if firstExpr[tok.Type] {
+			return g.unaryExpr()
+		}
+		if stop[tok.Type] {
+			return &BadExpr{Loc: g.spanFrom(token)}
+		}
+		g.advance()
+	}
+}
@@ -0,0 +1,171 @@
+package rec_des_parser
+
+import "testing"
+
+// 通过 Dump 检查表达式的结合方式：优先级、左结合的二元运算符、右结合的赋值与 ?:，
+// 以及 ( 之后是类型名时为类型转换，否则为括号表达式
+func TestExprShape(t *testing.T) {
+	for _, c := range []struct {
+		expr string
+		dump string
+	}{
+		{"a = b = c", `
+			Assign ASSIGN 2:1
+			  Ident a 2:1
+			  Assign ASSIGN 2:5
+			    Ident b 2:5
+			    Ident c 2:9`},
+		{"a += b -= c", `
+			Assign PLUS_ASSIGN 2:1
+			  Ident a 2:1
+			  Assign MINUS_ASSIGN 2:6
+			    Ident b 2:6
+			    Ident c 2:11`},
+		{"a = b ? c : d", `
+			Assign ASSIGN 2:1
+			  Ident a 2:1
+			  CondExpr 2:5
+			    Ident b 2:5
+			    Ident c 2:9
+			    Ident d 2:13`},
+		{"a ? b : c ? d : e", `
+			CondExpr 2:1
+			  Ident a 2:1
+			  Ident b 2:5
+			  CondExpr 2:9
+			    Ident c 2:9
+			    Ident d 2:13
+			    Ident e 2:17`},
+		{"a ? b = c : d", `
+			CondExpr 2:1
+			  Ident a 2:1
+			  Assign ASSIGN 2:5
+			    Ident b 2:5
+			    Ident c 2:9
+			  Ident d 2:13`},
+		{"a, b = c", `
+			BinaryExpr COMMA 2:1
+			  Ident a 2:1
+			  Assign ASSIGN 2:4
+			    Ident b 2:4
+			    Ident c 2:8`},
+		{"a - b - c", `
+			BinaryExpr MINUS 2:1
+			  BinaryExpr MINUS 2:1
+			    Ident a 2:1
+			    Ident b 2:5
+			  Ident c 2:9`},
+		{"a + b * c", `
+			BinaryExpr PLUS 2:1
+			  Ident a 2:1
+			  BinaryExpr ASTERISK 2:5
+			    Ident b 2:5
+			    Ident c 2:9`},
+		{"a * b + c", `
+			BinaryExpr PLUS 2:1
+			  BinaryExpr ASTERISK 2:1
+			    Ident a 2:1
+			    Ident b 2:5
+			  Ident c 2:9`},
+		{"a || b && c", `
+			BinaryExpr OR 2:1
+			  Ident a 2:1
+			  BinaryExpr AND 2:6
+			    Ident b 2:6
+			    Ident c 2:11`},
+		{"a << b < c == d & e ^ f | g", `
+			BinaryExpr PIPE 2:1
+			  BinaryExpr CARET 2:1
+			    BinaryExpr AMPERSAND 2:1
+			      BinaryExpr EQ 2:1
+			        BinaryExpr LT 2:1
+			          BinaryExpr SHL 2:1
+			            Ident a 2:1
+			            Ident b 2:6
+			          Ident c 2:10
+			        Ident d 2:15
+			      Ident e 2:19
+			    Ident f 2:23
+			  Ident g 2:27`},
+		{"!a == b", `
+			BinaryExpr EQ 2:1
+			  UnaryExpr NOT 2:1
+			    Ident a 2:2
+			  Ident b 2:7`},
+		{"-a[i]++", `
+			UnaryExpr MINUS 2:1
+			  PostfixExpr INCREMENT 2:2
+			    IndexExpr 2:2
+			      Ident a 2:2
+			      Ident i 2:4`},
+		{"*p->q", `
+			UnaryExpr ASTERISK 2:1
+			  MemberExpr ->q 2:2
+			    Ident p 2:2`},
+		{"f(a, b)(c)", `
+			CallExpr 2:1
+			  CallExpr 2:1
+			    Ident f 2:1
+			    Ident a 2:3
+			    Ident b 2:6
+			  Ident c 2:9`},
+		{"(T)x", `
+			CastExpr T 2:1
+			  Ident x 2:4`},
+		{"(T *)p", `
+			CastExpr *T 2:1
+			  Ident p 2:6`},
+		{"(T)-y", `
+			CastExpr T 2:1
+			  UnaryExpr MINUS 2:4
+			    Ident y 2:5`},
+		{"(T)(x)", `
+			CastExpr T 2:1
+			  ParenExpr 2:4
+			    Ident x 2:5`},
+		{"(x)", `
+			ParenExpr 2:1
+			  Ident x 2:2`},
+		{"(x)(y)", `
+			CallExpr 2:1
+			  ParenExpr 2:1
+			    Ident x 2:2
+			  Ident y 2:5`},
+		{"(x) - y", `
+			BinaryExpr MINUS 2:1
+			  ParenExpr 2:1
+			    Ident x 2:2
+			  Ident y 2:7`},
+		{"sizeof (T)", `
+			SizeofExpr T 2:1`},
+		{"sizeof (x)", `
+			SizeofExpr 2:1
+			  ParenExpr 2:8
+			    Ident x 2:9`},
+	} {
+		source := "typedef int T; void f(void) {\n" + c.expr + ";\n}"
+		tree, errs := New().Parse(source)
+		if len(errs) > 0 {
+			t.Errorf("%s: 语法错误 %v", c.expr, errs)
+			continue
+		}
+		x := tree.Decls[1].(*FuncDef).Body.Stmts[0].(*ExprStmt).X
+		if got, want := dumpLines(Dump(x)), dumpLines(c.dump); got != want {
+			t.Errorf("%s 的语法树为\n%s\n期望\n%s", c.expr, got, want)
+		}
+	}
+}
+
+func TestNotAssignable(t *testing.T) {
+	for _, expr := range []string{"a + b = c", "(a ? b : c) = d", "f() = 1", "++a = b", "1 += 2"} {
+		_, errs := New().Parse("void f(void) { " + expr + "; }")
+		if len(errs) != 1 {
+			t.Errorf("%s: 语法错误为 %v，期望一个", expr, errs)
+		}
+	}
+	for _, expr := range []string{"a = 1", "(a) = 1", "*p = 1", "a[i] = 1", "s.x = 1", "p->x = 1"} {
+		if _, errs := New().Parse("void f(void) { " + expr + "; }"); len(errs) > 0 {
+			t.Errorf("%s: 语法错误 %v", expr, errs)
+		}
+	}
+}
@@ -4,7 +4,15 @@ import (
 	"mygo_c_compiler/lexer"
 )
 
+// 语法分析器选项
+type Option func(*Parser)
+
 type Parser struct {
-	Result string
-	lexer  *lexer.Lexer
+	tracer   Tracer
+	dialect  lexer.Dialect
+	tokens   *lexer.TokenStream
+	prev     lexer.Token // 最近消耗的 Token
+	consumed int         // 已消耗的 Token 数
+	errors   []SyntaxError
+	scopes   []map[string]bool // 作用域栈，记录每个作用域中声明的名字是否为 typedef 名
 }
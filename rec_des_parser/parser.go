@@ -1,234 +1,281 @@
 package rec_des_parser
 
 import (
-	"fmt"
 	"mygo_c_compiler/lexer"
 )
 
-func New() *Parser {
-	return &Parser{}
+func New(opts ...Option) *Parser {
+	g := &Parser{tracer: nopTracer{}}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// 设置推导过程的跟踪器
+func WithTracer(tracer Tracer) Option {
+	return func(g *Parser) {
+		g.tracer = tracer
+	}
+}
+
+// 选择 Parse 与 ParseFile 使用的语言方言，默认为 C11
+func WithDialect(dialect lexer.Dialect) Option {
+	return func(g *Parser) {
+		g.dialect = dialect
+	}
 }
 
-func (g *Parser) Parse(input string) {
-	g.lexer = lexer.NewLexer(input)
-	g.program()
+func (g *Parser) Parse(input string) (*TranslationUnit, []SyntaxError) {
+	return g.ParseFile("", input)
 }
 
+// 解析文件内容，filename 用于错误信息中的位置
+func (g *Parser) ParseFile(filename, input string) (*TranslationUnit, []SyntaxError) {
+	return g.ParseStream(lexer.NewTokenStream(lexer.NewLexer(input, lexer.WithFilename(filename), lexer.WithDialect(g.dialect))))
+}
+
+// 解析已完成词法分析（例如预处理之后）的 Token 序列
+func (g *Parser) ParseTokens(tokens []lexer.Token) (*TranslationUnit, []SyntaxError) {
+	return g.ParseStream(lexer.NewSliceStream(tokens))
+}
+
+// 从 Token 流中解析翻译单元，返回语法树以及全部语法错误。
+// 出错的声明、语句和表达式在语法树中分别表示为 BadDecl、BadStmt 和 BadExpr
+func (g *Parser) ParseStream(tokens *lexer.TokenStream) (*TranslationUnit, []SyntaxError) {
+	g.tokens = tokens
+	g.errors = nil
+	g.prev = lexer.Token{}
+	g.consumed = 0
+	g.scopes = []map[string]bool{{}}
+	return g.translationUnit(), g.errors
+}
+
+// 消耗下一个 Token
+func (g *Parser) advance() lexer.Token {
+	g.prev = g.tokens.Next()
+	g.consumed++
+	return g.prev
+}
+
+// 消耗类型为 tokenType 的 Token，不匹配时记录错误并转到最近的恢复点
 func (g *Parser) match(tokenType lexer.TokenType) lexer.Token {
-	token := g.lexer.NextToken()
+	token := g.tokens.Peek(1)
 	if token.Type != tokenType {
-		panic(fmt.Sprintf("Syntax Error: expected %s, got %s", tokenType, token.Type))
+		g.errorAt(token, []lexer.TokenType{tokenType}, "expected %s, got %s", tokenType, token.Type)
+		panic(bailout{})
 	}
-	return token
+	return g.advance()
 }
 
-func (g *Parser) program() {
-	g.block()
+// 从 start 到最近消耗的 Token 的范围，什么都没有消耗时为 start 本身
+func (g *Parser) spanFrom(start lexer.Token) lexer.Span {
+	if g.prev.Span.End.Offset < start.Span.Start.Offset || g.prev.Span.End.File != start.Span.Start.File {
+		return start.Span
+	}
+	return spanOf(start.Span, g.prev.Span)
 }
 
-func (g *Parser) block() {
-	fmt.Println("Entering block")
-	fmt.Println("block -> { stmts }")
-	g.match(lexer.LBRACE)
-	g.stmts()
-	g.match(lexer.RBRACE)
+// translation_unit -> external_decl translation_unit | ε
+func (g *Parser) translationUnit() *TranslationUnit {
+	g.tracer.Enter("translation_unit")
+	start := g.tokens.Peek(1)
+	unit := &TranslationUnit{}
+	for !g.tokens.At(lexer.EOF) {
+		g.tracer.Production("translation_unit -> external_decl translation_unit")
+		consumed := g.consumed
+		unit.Decls = append(unit.Decls, g.externalDecl()...)
+		g.ensureProgress(consumed)
+	}
+	g.tracer.Production("translation_unit -> ε")
+	unit.Loc = g.spanFrom(start)
+	return unit
 }
 
-func (g *Parser) stmts() {
-	fmt.Println("Entering stmts")
-	token := g.lexer.NextToken()
-	switch token.Type {
-	case lexer.IF, lexer.WHILE, lexer.DO, lexer.BREAK, lexer.IDENT, lexer.LBRACE:
-		g.lexer.UnreadToken(token)
-		fmt.Println("stmts -> stmt stmts")
-		g.stmt()
-		g.stmts()
-	default:
-		g.lexer.UnreadToken(token)
-		fmt.Println("stmts -> ε")
-		return
+// block -> { block_items }，缺少 } 时记录错误后视为已闭合
+func (g *Parser) block() *Block {
+	g.tracer.Enter("block")
+	g.tracer.Production("block -> { block_items }")
+	lbrace := g.match(lexer.LBRACE)
+	g.pushScope()
+	defer g.popScope()
+	stmts := g.blockItems()
+	if rbrace := g.tokens.Peek(1); rbrace.Type != lexer.RBRACE {
+		g.errorAt(rbrace, []lexer.TokenType{lexer.RBRACE}, "expected %s, got %s", lexer.RBRACE, rbrace.Type)
+		return &Block{Stmts: stmts, Loc: g.spanFrom(lbrace)}
 	}
+	rbrace := g.advance()
+	return &Block{Stmts: stmts, Loc: spanOf(lbrace.Span, rbrace.Span)}
 }
 
-func (g *Parser) stmt() {
-	fmt.Println("Entering stmt")
-	token := g.lexer.NextToken()
-	g.lexer.UnreadToken(token)
+// block_items -> block_item block_items | ε，停在 }、EOF 或行首的函数定义处
+func (g *Parser) blockItems() []Stmt {
+	var stmts []Stmt
+	for {
+		g.tracer.Enter("block_items")
+		if g.tokens.At(lexer.RBRACE) || g.tokens.At(lexer.EOF) {
+			g.tracer.Production("block_items -> ε")
+			return stmts
+		}
+		if tok := g.tokens.Peek(1); tok.LineStart && g.isDeclStart(tok) && g.atFuncDef() {
+			// 块中不能定义函数，多半是前面缺少 }：结束所有未闭合的块，由外层分析这个函数定义
+			g.tracer.Production("block_items -> ε")
+			return stmts
+		}
+		g.tracer.Production("block_items -> block_item block_items")
+		consumed := g.consumed
+		stmts = append(stmts, g.blockItem())
+		g.ensureProgress(consumed)
+	}
+}
+
+// block_item -> declaration | stmt
+func (g *Parser) blockItem() (node Stmt) {
+	token := g.tokens.Peek(1)
+	if !g.isDeclStart(token) {
+		return g.stmt()
+	}
+	defer g.recoverStmt(token, &node)
+	return g.declStmt()
+}
+
+// 语句是错误恢复点：其中发生语法错误时跳过到 FOLLOW(stmt) 或 ; 之后，返回 BadStmt
+func (g *Parser) stmt() (node Stmt) {
+	g.tracer.Enter("stmt")
+	token := g.tokens.Peek(1)
+	defer g.recoverStmt(token, &node)
+
 	switch token.Type {
+	case lexer.LBRACE:
+		g.tracer.Production("stmt -> block")
+		return g.block()
 	case lexer.IF:
-		fmt.Println("stmt -> if ( bool ) stmt stmt'")
-		g.match(lexer.IF)
+		g.tracer.Production("stmt -> if ( expr ) stmt stmt'")
+		g.advance()
 		g.match(lexer.LPAREN)
-		g.boolExpr()
+		cond := g.expr()
 		g.match(lexer.RPAREN)
-		g.stmt()
-		g.stmtPrime()
-	case lexer.IDENT:
-		fmt.Println("stmt -> id = expr ;")
-		g.match(lexer.IDENT)
-		g.match(lexer.ASSIGN)
-		g.expr()
-		g.match(lexer.SEMICOLON)
+		then := g.stmt()
+		ifStmt := &If{Cond: cond, Then: then, Loc: spanOf(token.Span, then.Span())}
+		if ifStmt.Else = g.stmtPrime(); ifStmt.Else != nil {
+			ifStmt.Loc.End = ifStmt.Else.Span().End
+		}
+		return ifStmt
 	case lexer.WHILE:
-		fmt.Println("stmt -> while ( bool ) stmt")
-		g.match(lexer.WHILE)
+		g.tracer.Production("stmt -> while ( expr ) stmt")
+		g.advance()
 		g.match(lexer.LPAREN)
-		g.boolExpr()
+		cond := g.expr()
 		g.match(lexer.RPAREN)
-		g.stmt()
+		body := g.stmt()
+		return &While{Cond: cond, Body: body, Loc: spanOf(token.Span, body.Span())}
 	case lexer.DO:
-		fmt.Println("stmt -> do stmt while ( bool ) ;")
-		g.match(lexer.DO)
-		g.stmt()
+		g.tracer.Production("stmt -> do stmt while ( expr ) ;")
+		g.advance()
+		body := g.stmt()
 		g.match(lexer.WHILE)
 		g.match(lexer.LPAREN)
-		g.boolExpr()
+		cond := g.expr()
+		g.match(lexer.RPAREN)
+		semi := g.match(lexer.SEMICOLON)
+		return &DoWhile{Body: body, Cond: cond, Loc: spanOf(token.Span, semi.Span)}
+	case lexer.FOR:
+		g.tracer.Production("stmt -> for ( for_init expr? ; expr? ) stmt")
+		return g.forStmt()
+	case lexer.SWITCH:
+		g.tracer.Production("stmt -> switch ( expr ) stmt")
+		g.advance()
+		g.match(lexer.LPAREN)
+		tag := g.expr()
 		g.match(lexer.RPAREN)
-		g.match(lexer.SEMICOLON)
+		body := g.stmt()
+		return &Switch{Tag: tag, Body: body, Loc: spanOf(token.Span, body.Span())}
+	case lexer.CASE:
+		g.tracer.Production("stmt -> case constant_expr : stmt")
+		g.advance()
+		value := g.constantExpr()
+		g.match(lexer.COLON)
+		body := g.stmt()
+		return &Case{Value: value, Body: body, Loc: spanOf(token.Span, body.Span())}
+	case lexer.DEFAULT:
+		g.tracer.Production("stmt -> default : stmt")
+		g.advance()
+		g.match(lexer.COLON)
+		body := g.stmt()
+		return &Default{Body: body, Loc: spanOf(token.Span, body.Span())}
+	case lexer.RETURN:
+		g.tracer.Production("stmt -> return expr? ;")
+		g.advance()
+		var value Expr
+		if !g.tokens.At(lexer.SEMICOLON) {
+			value = g.expr()
+		}
+		semi := g.match(lexer.SEMICOLON)
+		return &Return{Value: value, Loc: spanOf(token.Span, semi.Span)}
 	case lexer.BREAK:
-		fmt.Println("stmt -> break ;")
-		g.match(lexer.BREAK)
-		g.match(lexer.SEMICOLON)
-	case lexer.LBRACE:
-		fmt.Println("stmt -> block")
-		g.block()
+		g.tracer.Production("stmt -> break ;")
+		g.advance()
+		semi := g.match(lexer.SEMICOLON)
+		return &Break{Loc: spanOf(token.Span, semi.Span)}
+	case lexer.CONTINUE:
+		g.tracer.Production("stmt -> continue ;")
+		g.advance()
+		semi := g.match(lexer.SEMICOLON)
+		return &Continue{Loc: spanOf(token.Span, semi.Span)}
+	case lexer.SEMICOLON:
+		g.tracer.Production("stmt -> ;")
+		g.advance()
+		return &EmptyStmt{Loc: token.Span}
 	default:
-		panic("Syntax Error")
-	}
-}
-
-func (g *Parser) stmtPrime() {
-	fmt.Println("Entering stmt'")
-	token := g.lexer.NextToken()
-	if token.Type == lexer.ELSE {
-		fmt.Println("stmt' -> else stmt")
-		g.match(lexer.ELSE)
-		g.stmt()
-	} else {
-		g.lexer.UnreadToken(token)
-		fmt.Println("stmt' -> ε")
-		return
+		if !firstExpr[token.Type] {
+			g.errorAt(token, nil, "unexpected token %s", token.Type)
+			panic(bailout{})
+		}
+		g.tracer.Production("stmt -> expr ;")
+		x := g.expr()
+		semi := g.match(lexer.SEMICOLON)
+		return &ExprStmt{X: x, Loc: spanOf(token.Span, semi.Span)}
 	}
 }
 
-func (g *Parser) boolExpr() {
-	fmt.Println("Entering bool")
-	fmt.Println("bool -> expr bool'")
-	g.expr()
-	g.boolPrime()
-}
-
-func (g *Parser) boolPrime() {
-	fmt.Println("Entering bool'")
-	token := g.lexer.NextToken()
-	switch token.Type {
-	case lexer.LT:
-		g.lexer.UnreadToken(token)
-		g.match(lexer.LT)
-		g.expr()
-	case lexer.LTE:
-		g.lexer.UnreadToken(token)
-		g.match(lexer.LTE)
-		g.expr()
-	case lexer.GT:
-		g.lexer.UnreadToken(token) // 先放回token
-		g.match(lexer.GT)
-		g.expr()
-	case lexer.GTE:
-		g.lexer.UnreadToken(token) // 先放回token
-		g.match(lexer.GTE)
-		g.expr()
-	case lexer.EQ:
-		g.lexer.UnreadToken(token) // 先放回token
-		g.match(lexer.EQ)
-		g.expr()
-	case lexer.NEQ:
-		g.lexer.UnreadToken(token) // 先放回token
-		g.match(lexer.NEQ)
-		g.expr()
+// for_init -> declaration | expr? ;，for 语句中的声明只在 for 语句内可见
+func (g *Parser) forStmt() Stmt {
+	forTok := g.advance()
+	g.pushScope()
+	defer g.popScope()
+	g.match(lexer.LPAREN)
+	node := &For{}
+	switch start := g.tokens.Peek(1); {
+	case g.isDeclStart(start):
+		node.Init = g.declStmt()
+	case start.Type == lexer.SEMICOLON:
+		g.advance()
 	default:
-		g.lexer.UnreadToken(token)
-
-		fmt.Println("bool' -> ε")
-		return
+		x := g.expr()
+		semi := g.match(lexer.SEMICOLON)
+		node.Init = &ExprStmt{X: x, Loc: spanOf(start.Span, semi.Span)}
 	}
-}
-
-func (g *Parser) expr() {
-	fmt.Println("Entering expr")
-	fmt.Println("expr -> term expr'")
-	g.term()
-	g.exprPrime()
-}
-
-func (g *Parser) exprPrime() {
-	fmt.Println("Entering expr'")
-	token := g.lexer.NextToken()
-	switch token.Type {
-	case lexer.PLUS:
-		g.lexer.UnreadToken(token)
-		g.match(lexer.PLUS)
-		g.term()
-		g.exprPrime()
-	case lexer.MINUS:
-		g.lexer.UnreadToken(token)
-		g.match(lexer.MINUS)
-		g.term()
-		g.exprPrime()
-	default:
-		g.lexer.UnreadToken(token)
-		fmt.Println("expr' -> ε")
-		return
+	if !g.tokens.At(lexer.SEMICOLON) {
+		node.Cond = g.expr()
 	}
-}
-
-func (g *Parser) term() {
-	fmt.Println("Entering term")
-	fmt.Println("term -> factor term'")
-	g.factor()
-	g.termPrime()
-}
-
-func (g *Parser) termPrime() {
-	fmt.Println("Entering term'")
-	token := g.lexer.NextToken()
-	switch token.Type {
-	case lexer.ASTERISK:
-		g.lexer.UnreadToken(token)
-		g.match(lexer.ASTERISK)
-		g.factor()
-		g.termPrime()
-	case lexer.SLASH:
-		g.lexer.UnreadToken(token)
-		g.match(lexer.SLASH)
-		g.factor()
-		g.termPrime()
-	default:
-		g.lexer.UnreadToken(token)
-		fmt.Println("term' -> ε")
-		return
+	g.match(lexer.SEMICOLON)
+	if !g.tokens.At(lexer.RPAREN) {
+		node.Post = g.expr()
 	}
+	g.match(lexer.RPAREN)
+	node.Body = g.stmt()
+	node.Loc = spanOf(forTok.Span, node.Body.Span())
+	return node
 }
 
-func (g *Parser) factor() {
-	fmt.Println("Entering factor")
-	token := g.lexer.NextToken()
-	switch token.Type {
-	case lexer.LPAREN:
-		fmt.Println("factor -> ( expr )")
-		g.lexer.UnreadToken(token) // 先放回token
-		g.match(lexer.LPAREN)
-		g.expr()
-		g.match(lexer.RPAREN)
-	case lexer.IDENT:
-		fmt.Println("factor -> id")
-		g.lexer.UnreadToken(token) // 先放回token
-		g.match(lexer.IDENT)
-	case lexer.NUMBER:
-		fmt.Println("factor -> num")
-		g.lexer.UnreadToken(token) // 先放回token
-		g.match(lexer.NUMBER)
-	default:
-		panic(fmt.Sprintf("Syntax Error: unexpected token %s", token.Type))
+// 返回 else 分支，没有 else 时返回 nil
+func (g *Parser) stmtPrime() Stmt {
+	g.tracer.Enter("stmt'")
+	if g.tokens.At(lexer.ELSE) {
+		g.tracer.Production("stmt' -> else stmt")
+		g.advance()
+		return g.stmt()
 	}
+	g.tracer.Production("stmt' -> ε")
+	return nil
 }
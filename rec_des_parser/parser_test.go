@@ -0,0 +1,269 @@
+package rec_des_parser
+
+import "testing"
+
+func TestDecls(t *testing.T) {
+	runParseCases(t, []parseCase{
+		{
+			name:   "变量声明",
+			source: "int a, *b = 0, c[3];\nstatic const char *s = \"x\";",
+			dump: `
+				TranslationUnit 1:1
+				  VarDecl a int 1:1
+				  VarDecl b *int 1:1
+				    NumberLit 0 1:13
+				  VarDecl c [3]int 1:1
+				  VarDecl static s *const char 2:1
+				    StringLit "x" 2:24`,
+		},
+		{
+			name:   "函数与复杂声明符",
+			source: "extern int f(int, char *p);\nint (*fp)(void);\nint *g(void), h[2][3];",
+			dump: `
+				TranslationUnit 1:1
+				  VarDecl extern f func(int, *char) int 1:1
+				  VarDecl fp *func(void) int 2:1
+				  VarDecl g func(void) *int 3:1
+				  VarDecl h [2][3]int 3:1`,
+		},
+		{
+			name:   "函数定义",
+			source: "int main(void) { return 0; }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef main func(void) int 1:1
+				    Block 1:16
+				      Return 1:18
+				        NumberLit 0 1:25`,
+		},
+	})
+}
+
+func TestStmts(t *testing.T) {
+	runParseCases(t, []parseCase{
+		{
+			name:   "if else",
+			source: "int f(int n) { if (n) return 1; else return 0; }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(int) int 1:1
+				    Block 1:14
+				      If 1:16
+				        Ident n 1:20
+				        Return 1:23
+				          NumberLit 1 1:30
+				        Else
+				          Return 1:38
+				            NumberLit 0 1:45`,
+		},
+		{
+			name:   "循环",
+			source: "void f(void) { while (i < 10) i++; do { i--; } while (i); for (i = 0; i < 3; i++) ; }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) void 1:1
+				    Block 1:14
+				      While 1:16
+				        BinaryExpr LT 1:23
+				          Ident i 1:23
+				          NumberLit 10 1:27
+				        ExprStmt 1:31
+				          PostfixExpr INCREMENT 1:31
+				            Ident i 1:31
+				      DoWhile 1:36
+				        Block 1:39
+				          ExprStmt 1:41
+				            PostfixExpr DECREMENT 1:41
+				              Ident i 1:41
+				        Ident i 1:55
+				      For 1:59
+				        Init
+				          ExprStmt 1:64
+				            Assign ASSIGN 1:64
+				              Ident i 1:64
+				              NumberLit 0 1:68
+				        Cond
+				          BinaryExpr LT 1:71
+				            Ident i 1:71
+				            NumberLit 3 1:75
+				        Post
+				          PostfixExpr INCREMENT 1:78
+				            Ident i 1:78
+				        EmptyStmt 1:83`,
+		},
+		{
+			name:   "break 与 continue",
+			source: "void f(void) { for (;;) { if (a) break; else continue; } }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) void 1:1
+				    Block 1:14
+				      For 1:16
+				        Block 1:25
+				          If 1:27
+				            Ident a 1:31
+				            Break 1:34
+				            Else
+				              Continue 1:46`,
+		},
+		{
+			name:   "switch",
+			source: "void f(void) { switch (x) { case 1: y = 2; break; default: ; } }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) void 1:1
+				    Block 1:14
+				      Switch 1:16
+				        Ident x 1:24
+				        Block 1:27
+				          Case 1:29
+				            NumberLit 1 1:34
+				            ExprStmt 1:37
+				              Assign ASSIGN 1:37
+				                Ident y 1:37
+				                NumberLit 2 1:41
+				          Break 1:44
+				          Default 1:51
+				            EmptyStmt 1:60`,
+		},
+	})
+}
+
+// typedef 名开始声明，普通标识符开始表达式
+func TestTypedefNames(t *testing.T) {
+	runParseCases(t, []parseCase{
+		{
+			name:   "typedef 名作为类型说明符",
+			source: "typedef unsigned long size; size n; size *p = (size *)0;",
+			dump: `
+				TranslationUnit 1:1
+				  VarDecl typedef size unsigned long 1:1
+				  VarDecl n size 1:29
+				  VarDecl p *size 1:37
+				    CastExpr *size 1:47
+				      NumberLit 0 1:55`,
+		},
+		{
+			name:   "T * x 与 T(y) 是声明",
+			source: "typedef int T; void f(void) { T * x; T(y); }",
+			dump: `
+				TranslationUnit 1:1
+				  VarDecl typedef T int 1:1
+				  FuncDef f func(void) void 1:16
+				    Block 1:29
+				      DeclStmt 1:31
+				        VarDecl x *T 1:31
+				      DeclStmt 1:38
+				        VarDecl y T 1:38`,
+		},
+		{
+			name:   "x * y 是表达式",
+			source: "void f(void) { x * y; }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) void 1:1
+				    Block 1:14
+				      ExprStmt 1:16
+				        BinaryExpr ASTERISK 1:16
+				          Ident x 1:16
+				          Ident y 1:20`,
+		},
+	})
+}
+
+func TestMissingSemicolonOrBrace(t *testing.T) {
+	runParseCases(t, []parseCase{
+		{
+			name:   "语句缺少 ; 后从下一行继续",
+			source: "void f(void) { a = 1\n b = 2; }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) void 1:1
+				    Block 1:14
+				      BadStmt 1:16
+				      ExprStmt 2:2
+				        Assign ASSIGN 2:2
+				          Ident b 2:2
+				          NumberLit 2 2:6`,
+			errors: []string{"2:2: Syntax Error: expected SEMICOLON, got IDENTIFIER"},
+		},
+		{
+			name:   "块中最后一条语句缺少 ;",
+			source: "void f(void) { a = 1 }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) void 1:1
+				    Block 1:14
+				      BadStmt 1:16`,
+			errors: []string{"1:22: Syntax Error: expected SEMICOLON, got RBRACE"},
+		},
+		{
+			name:   "外部声明缺少 ;",
+			source: "int x\nint y;",
+			dump: `
+				TranslationUnit 1:1
+				  BadDecl 1:1
+				  VarDecl y int 2:1`,
+			errors: []string{"2:1: Syntax Error: expected SEMICOLON, got INT"},
+		},
+		{
+			name:   "函数缺少 } 时不吞掉下一个函数定义",
+			source: "void f(void) { a = 1;\nint g(void) { return 0; }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) void 1:1
+				    Block 1:14
+				      ExprStmt 1:16
+				        Assign ASSIGN 1:16
+				          Ident a 1:16
+				          NumberLit 1 1:20
+				  FuncDef g func(void) int 2:1
+				    Block 2:13
+				      Return 2:15
+				        NumberLit 0 2:22`,
+			errors: []string{"2:1: Syntax Error: expected RBRACE, got INT"},
+		},
+		{
+			name:   "嵌套的块缺少 } 时只报告一次",
+			source: "void f(void) { if (a) { b;\n}\nint g(void) { return 0; }",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) void 1:1
+				    Block 1:14
+				      If 1:16
+				        Ident a 1:20
+				        Block 1:23
+				          ExprStmt 1:25
+				            Ident b 1:25
+				  FuncDef g func(void) int 3:1
+				    Block 3:13
+				      Return 3:15
+				        NumberLit 0 3:22`,
+			errors: []string{"3:1: Syntax Error: expected RBRACE, got INT"},
+		},
+		{
+			name:   "块中的声明不是函数定义",
+			source: "void f(void) { int x;\nint y;",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) void 1:1
+				    Block 1:14
+				      DeclStmt 1:16
+				        VarDecl x int 1:16
+				      DeclStmt 2:1
+				        VarDecl y int 2:1`,
+			errors: []string{"2:7: Syntax Error: expected RBRACE, got EOF"},
+		},
+		{
+			name:   "多余的 }",
+			source: "int f(void) { }}\nint y;",
+			dump: `
+				TranslationUnit 1:1
+				  FuncDef f func(void) int 1:1
+				    Block 1:13
+				  BadDecl 1:16
+				  VarDecl y int 2:1`,
+			errors: []string{"1:16: Syntax Error: expected declaration, got RBRACE"},
+		},
+	})
+}
@@ -0,0 +1,35 @@
+package rec_des_parser
+
+import (
+	"fmt"
+	"io"
+)
+
+// 推导过程跟踪器，在进入非终结符和选定产生式时被调用
+type Tracer interface {
+	Enter(nonterminal string)
+	Production(production string)
+}
+
+// 默认不输出任何内容
+type nopTracer struct{}
+
+func (nopTracer) Enter(string)      {}
+func (nopTracer) Production(string) {}
+
+// 按 "Entering X" 与产生式的格式把推导过程写入 w
+type PrintTracer struct {
+	w io.Writer
+}
+
+func NewPrintTracer(w io.Writer) *PrintTracer {
+	return &PrintTracer{w: w}
+}
+
+func (t *PrintTracer) Enter(nonterminal string) {
+	fmt.Fprintf(t.w, "Entering %s\n", nonterminal)
+}
+
+func (t *PrintTracer) Production(production string) {
+	fmt.Fprintln(t.w, production)
+}
@@ -0,0 +1,86 @@
+package rec_des_parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 声明中的类型，由声明说明符与声明符共同构成
+type Type interface {
+	String() string
+	typeNode()
+}
+
+// 基本类型或 typedef 名，Name 为类型说明符按书写顺序的拼写，如 "unsigned int"
+type BasicType struct {
+	Name       string
+	Qualifiers []string // const、volatile、restrict
+}
+
+// 指针类型，Qualifiers 为修饰指针本身的限定符
+type PointerType struct {
+	Elem       Type
+	Qualifiers []string
+}
+
+// 数组类型，Len 为 nil 表示未指定长度
+type ArrayType struct {
+	Elem Type
+	Len  Expr
+}
+
+// 函数类型。Params 为空时，Void 区分没有形参的 (void) 与未指定形参的 ()
+type FuncType struct {
+	Result   Type
+	Params   []*Param
+	Variadic bool
+	Void     bool
+}
+
+// 函数形参，抽象声明符的形参没有名字
+type Param struct {
+	Name *Ident
+	Type Type
+}
+
+func (*BasicType) typeNode()   {}
+func (*PointerType) typeNode() {}
+func (*ArrayType) typeNode()   {}
+func (*FuncType) typeNode()    {}
+
+// 类型按从外到内的顺序书写，例如 *[3]int 表示指向 int 数组的指针
+func (t *BasicType) String() string {
+	return strings.Join(append(append([]string{}, t.Qualifiers...), t.Name), " ")
+}
+
+func (t *PointerType) String() string {
+	s := "*"
+	for _, q := range t.Qualifiers {
+		s += q + " "
+	}
+	return s + t.Elem.String()
+}
+
+func (t *ArrayType) String() string {
+	if t.Len == nil {
+		return "[]" + t.Elem.String()
+	}
+	if n, ok := t.Len.(*NumberLit); ok {
+		return fmt.Sprintf("[%s]%s", n.Value, t.Elem)
+	}
+	return "[expr]" + t.Elem.String()
+}
+
+func (t *FuncType) String() string {
+	params := make([]string, 0, len(t.Params)+1)
+	for _, p := range t.Params {
+		params = append(params, p.Type.String())
+	}
+	if t.Variadic {
+		params = append(params, "...")
+	}
+	if t.Void {
+		params = append(params, "void")
+	}
+	return fmt.Sprintf("func(%s) %s", strings.Join(params, ", "), t.Result)
+}